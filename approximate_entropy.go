@@ -42,7 +42,6 @@ func ApproximateEntropyTestBytes(data []byte, m int) (float64, float64) {
 // m: m长度
 func ApproximateEntropyProto(bits []bool, m int) (float64, float64) {
 	n := len(bits)
-	numOfBlocks := float64(n)
 	if n == 0 {
 		panic("please provide test bits")
 	}
@@ -50,9 +49,7 @@ func ApproximateEntropyProto(bits []bool, m int) (float64, float64) {
 		panic("block size m must be less than sequence length")
 	}
 
-	var ApEn [2]float64
-	var V float64
-	var P float64
+	var patterns [2][]int
 
 	// Compute phi for blockSize=m and then blockSize=m+1.
 	// 优化版本：使用位操作和滑动窗口技术
@@ -69,23 +66,33 @@ func ApproximateEntropyProto(bits []bool, m int) (float64, float64) {
 			// 对于较大的blockSize，使用滑动窗口优化
 			approximateEntropyOptimizedLarge(bits, pattern, blockSize, n)
 		}
+		patterns[blockSize-m] = pattern
+	}
 
+	P := approximateEntropyP(patterns[0], patterns[1], n, m)
+	return P, P
+}
+
+// approximateEntropyP 由 m 与 m+1 比特循环重叠模式的频数计算近似熵检测P值
+func approximateEntropyP(pm, pm1 []int, n, m int) float64 {
+	numOfBlocks := float64(n)
+	var ApEn [2]float64
+	for j, pattern := range [2][]int{pm, pm1} {
 		// Compute the terms of the phi formula
 		sum := float64(0.0)
-		for i := 0; i < powLen; i++ {
+		for i := range pattern {
 			if pattern[i] > 0 {
 				sum += float64(pattern[i]) * math.Log(float64(pattern[i])/numOfBlocks)
 			}
 		}
 		sum /= numOfBlocks
-		ApEn[blockSize-m] = sum
+		ApEn[j] = sum
 	}
 
 	apen := ApEn[0] - ApEn[1]
-	V = 2.0 * numOfBlocks * (math.Log(2) - apen)
+	V := 2.0 * numOfBlocks * (math.Log(2) - apen)
 	_2mMinus1 := 1 << uint(m-1)
-	P = igamc(float64(_2mMinus1), V/2.0)
-	return P, P
+	return igamc(float64(_2mMinus1), V/2.0)
 }
 
 // approximateEntropyOptimizedSmall 针对小块大小的优化实现
@@ -0,0 +1,115 @@
+// Copyright (c) 2021 Quan guanyu
+// randomness is licensed under Mulan PSL v2.
+// You can use this software according to the terms and conditions of the Mulan PSL v2.
+// You may obtain a copy of Mulan PSL v2 at:
+//          http://license.coscl.org.cn/MulanPSL2
+// THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND,
+// EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT,
+// MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+// See the Mulan PSL v2 for more details.
+
+package randomness
+
+// BookStack 书堆检测
+func BookStack(data []byte) *TestResult {
+	s, K := bookStackParams(len(data) * 8)
+	p, q := BookStackTestBytes(data, s, K)
+	return &TestResult{Name: "书堆检测", P: p, Q: q, Pass: p >= Alpha}
+}
+
+// BookStackTest 书堆检测，参数按序列长度选取，见 BookStackProto
+func BookStackTest(bits []bool) (float64, float64) {
+	s, K := bookStackParams(len(bits))
+	return BookStackProto(bits, s, K)
+}
+
+// BookStackTestBytes 书堆检测
+// 直接由字节组装 s 比特符号，避免字节切片到位切片的转换。
+//
+// data: 检测序列
+// s: 符号比特数
+// K: 书堆上部的大小
+func BookStackTestBytes(data []byte, s, K int) (float64, float64) {
+	return bookStackWords(Words(data, s, MSBFirst), s, K)
+}
+
+// BookStackProto 书堆检测（Ryabko-Monarev book stack test）
+// 将序列划分为不重叠的 s 比特符号，2^s 个可能的符号排成一摞“书”，初始时按符号值从小到大排列。
+// 依次读取符号，记录其当前位置是否位于书堆上部（前 K 个位置），然后将其移到书堆顶部。
+// 随机序列下每个符号位于上部的概率均为 K/2^s 且相互独立，上部命中次数服从二项分布，
+// 使用自由度为1的卡方检验。序列中近期出现过的符号再次出现得更频繁时（如短周期、状态空间小的发生器），
+// 上部命中次数偏大。
+//
+// 参数选取建议：n=10^6 时 s=16、K=2^10；n=10^8 时 s=20、K=2^12，
+// 即使上部的期望命中次数在 10^3~10^4 量级，同时 K 远小于 2^s。
+//
+// bits: 检测序列
+// s: 符号比特数，1<=s<=32
+// K: 书堆上部的大小，0<K<2^s
+func BookStackProto(bits []bool, s, K int) (float64, float64) {
+	return bookStackWords(bitWords(bits, s), s, K)
+}
+
+// bookStackParams 按序列长度选取书堆检测的参数
+func bookStackParams(n int) (int, int) {
+	if n >= 100000000 {
+		return 20, 1 << 12
+	}
+	return 16, 1 << 10
+}
+
+// bookStackWords 对 s 比特符号序列进行书堆检测
+func bookStackWords(words []uint64, s, K int) (float64, float64) {
+	if s < 1 || s > 32 || K <= 0 || K >= 1<<uint(s) {
+		panic("invalid book stack parameters")
+	}
+	N := len(words)
+	A := 1 << uint(s)
+	pu := float64(K) / float64(A)
+	if float64(N)*pu*(1-pu) < 5 {
+		panic("please provide valid test bits")
+	}
+
+	// 书堆中符号的位置等于上次读取该符号之后读取过的不同符号个数（移到顶部的规则）。
+	// 初始排列视为符号 i 在时刻 A-1-i 被读取，第 j 个符号在时刻 A+j 被读取，
+	// 用树状数组记录各符号最近一次读取的时刻，位置即为晚于该时刻的标记个数。
+	last := make([]int, A)
+	tree := make([]int32, A+N+1)
+	for i := range last {
+		last[i] = A - 1 - i
+		tree[last[i]+1] = 1
+	}
+	for i := 1; i < len(tree); i++ {
+		if j := i + i&-i; j < len(tree) {
+			tree[j] += tree[i]
+		}
+	}
+	prefix := func(t int) int {
+		c := 0
+		for i := t + 1; i > 0; i -= i & -i {
+			c += int(tree[i])
+		}
+		return c
+	}
+	update := func(t int, d int32) {
+		for i := t + 1; i < len(tree); i += i & -i {
+			tree[i] += d
+		}
+	}
+
+	upper := 0
+	for j, w := range words {
+		pos := A - prefix(last[w])
+		if pos < K {
+			upper++
+		}
+		update(last[w], -1)
+		last[w] = A + j
+		update(last[w], 1)
+	}
+
+	d := float64(upper) - float64(N)*pu
+	V := d * d / (float64(N) * pu * (1 - pu))
+	P := igamc(0.5, V/2)
+	return P, P
+}
@@ -0,0 +1,46 @@
+package randomness
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestBookStackSample(t *testing.T) {
+	p, q := BookStackProto(sampleTestBits128, 4, 4)
+	fmt.Printf("n: %v, P-value: %f, Q-value: %f\n", len(sampleTestBits128), p, q)
+	if fmt.Sprintf("%.6f", p) != "0.683091" || fmt.Sprintf("%.6f", q) != "0.683091" {
+		t.FailNow()
+	}
+	p, q = BookStackTestBytes([]byte{0xcc, 0x15, 0x6c, 0x4c, 0xe0, 0x02, 0x4d, 0x51, 0x13, 0xd6, 0x80, 0xd7, 0xcc, 0xe6, 0xd8, 0xb2}, 4, 4)
+	if fmt.Sprintf("%.6f", p) != "0.683091" || fmt.Sprintf("%.6f", q) != "0.683091" {
+		t.FailNow()
+	}
+}
+
+func TestBookStackE(t *testing.T) {
+	bits := getEConstantBits()
+	p, q := BookStackProto(bits, 8, 16)
+	fmt.Printf("n: %v, P-value: %f, Q-value: %f\n", len(bits), p, q)
+	if fmt.Sprintf("%.6f", p) != "0.043840" || fmt.Sprintf("%.6f", q) != "0.043840" {
+		t.FailNow()
+	}
+	p, q = BookStackTestBytes(getEConstantBytes(), 16, 1<<10)
+	p2, q2 := BookStackProto(bits, 16, 1<<10)
+	fmt.Printf("s: 16, K: 1024, P-value: %f, Q-value: %f\n", p, q)
+	if fmt.Sprintf("%.6f", p) != "0.712207" || fmt.Sprintf("%.6f", q) != "0.712207" || p != p2 || q != q2 {
+		t.Fatalf("bytes %f %f, proto %f %f", p, q, p2, q2)
+	}
+}
+
+func TestBookStackRepeats(t *testing.T) {
+	// 每250个8比特符号中有一个重复7个符号之前的符号，频数类检测无法发现
+	bits := append([]bool(nil), getEConstantBits()...)
+	for i := 250; i < len(bits)/8; i += 250 {
+		copy(bits[i*8:i*8+8], bits[(i-7)*8:])
+	}
+	p, _ := BookStackProto(bits, 8, 16)
+	fmt.Printf("n: %v, P-value: %f\n", len(bits), p)
+	if fmt.Sprintf("%.6f", p) != "0.000466" {
+		t.FailNow()
+	}
+}
@@ -0,0 +1,127 @@
+// Copyright (c) 2021 Quan guanyu
+// randomness is licensed under Mulan PSL v2.
+// You can use this software according to the terms and conditions of the Mulan PSL v2.
+// You may obtain a copy of Mulan PSL v2 at:
+//          http://license.coscl.org.cn/MulanPSL2
+// THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND,
+// EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT,
+// MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+// See the Mulan PSL v2 for more details.
+
+package randomness
+
+import "math"
+
+// ByteStatistics 字节级统计量，各项定义与 ent 工具（John Walker, https://www.fourmilab.ch/random/）一致
+type ByteStatistics struct {
+	N                 int64   // 字节数
+	Entropy           float64 // 每字节的香农熵（比特）
+	Compression       float64 // 最优压缩率（%），(8-Entropy)/8*100
+	ChiSquare         float64 // 256个字节值频数的卡方统计量，自由度255
+	ChiSquareP        float64 // 随机序列下卡方统计量超过 ChiSquare 的概率
+	Mean              float64 // 字节的算术平均值，随机序列约为127.5
+	MonteCarloPi      float64 // 蒙特卡洛法估计的 π
+	MonteCarloPiError float64 // π 估计值的相对误差（%）
+	SerialCorrelation float64 // 相邻字节的序列相关系数（首尾相接），所有字节相同时无定义，记为0
+}
+
+// ByteStats 计算序列的字节级统计量
+// data: 检测序列
+func ByteStats(data []byte) *ByteStatistics {
+	w := NewByteStatsWriter()
+	_, _ = w.Write(data)
+	return w.Statistics()
+}
+
+// ByteStatsWriter 以流的方式累计字节级统计量，可用于单次读取大文件
+type ByteStatsWriter struct {
+	counts [256]int64
+
+	// 蒙特卡洛法：每6个字节组成一个点，前3字节为 x，后3字节为 y
+	monte  [6]byte
+	mp     int
+	inmont int64
+	mcount int64
+
+	// 序列相关系数
+	first                bool
+	u0, last             float64
+	sum1, sum2, sumOfSqr float64
+}
+
+// NewByteStatsWriter 创建字节级统计量累计器
+func NewByteStatsWriter() *ByteStatsWriter {
+	return &ByteStatsWriter{first: true}
+}
+
+// Write 累计字节，总是返回 len(p), nil
+func (w *ByteStatsWriter) Write(p []byte) (int, error) {
+	const incirc = float64((1<<24 - 1) * (1<<24 - 1))
+	for _, b := range p {
+		w.counts[b]++
+
+		w.monte[w.mp] = b
+		w.mp++
+		if w.mp == len(w.monte) {
+			w.mp = 0
+			w.mcount++
+			x := float64(uint32(w.monte[0])<<16 | uint32(w.monte[1])<<8 | uint32(w.monte[2]))
+			y := float64(uint32(w.monte[3])<<16 | uint32(w.monte[4])<<8 | uint32(w.monte[5]))
+			if x*x+y*y <= incirc {
+				w.inmont++
+			}
+		}
+
+		u := float64(b)
+		if w.first {
+			w.first = false
+			w.u0 = u
+		} else {
+			w.sum1 += w.last * u
+		}
+		w.sum2 += u
+		w.sumOfSqr += u * u
+		w.last = u
+	}
+	return len(p), nil
+}
+
+// Statistics 计算已累计字节的统计量
+func (w *ByteStatsWriter) Statistics() *ByteStatistics {
+	var N int64
+	var sum float64
+	for i, c := range w.counts {
+		N += c
+		sum += float64(i) * float64(c)
+	}
+	s := &ByteStatistics{N: N}
+	if N == 0 {
+		return s
+	}
+	fN := float64(N)
+
+	e := fN / 256
+	for _, c := range w.counts {
+		if c > 0 {
+			p := float64(c) / fN
+			s.Entropy -= p * math.Log2(p)
+		}
+		d := float64(c) - e
+		s.ChiSquare += d * d / e
+	}
+	s.Compression = (8 - s.Entropy) / 8 * 100
+	s.ChiSquareP = igamc(255.0/2, s.ChiSquare/2)
+	s.Mean = sum / fN
+
+	if w.mcount > 0 {
+		s.MonteCarloPi = 4 * float64(w.inmont) / float64(w.mcount)
+		s.MonteCarloPiError = math.Abs(s.MonteCarloPi-math.Pi) / math.Pi * 100
+	}
+
+	t1 := w.sum1 + w.last*w.u0
+	t2 := w.sum2 * w.sum2
+	if d := fN*w.sumOfSqr - t2; d != 0 {
+		s.SerialCorrelation = (fN*t1 - t2) / d
+	}
+	return s
+}
@@ -0,0 +1,52 @@
+package randomness
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestByteStatsUniform(t *testing.T) {
+	// 每个字节值恰好出现4次
+	data := make([]byte, 1024)
+	for i := range data {
+		data[i] = byte(i)
+	}
+	s := ByteStats(data)
+	if s.N != 1024 || s.Entropy != 8 || s.Compression != 0 || s.ChiSquare != 0 || s.ChiSquareP != 1 || s.Mean != 127.5 {
+		t.Fatalf("unexpected statistics: %+v", s)
+	}
+}
+
+func TestByteStatsDefinitions(t *testing.T) {
+	// 序列相关系数首尾相接：t1=1*2+2*3+3*4+4*1=24，(4*24-10^2)/(4*30-10^2)=-0.2
+	if s := ByteStats([]byte{1, 2, 3, 4}); math.Abs(s.SerialCorrelation+0.2) > 1e-12 {
+		t.Fatalf("serial correlation = %f, want -0.2", s.SerialCorrelation)
+	}
+	// 蒙特卡洛：(0,0) 在圆内，(2^24-1,2^24-1) 在圆外，不足6字节的尾部被忽略
+	data := []byte{0, 0, 0, 0, 0, 0, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0}
+	if s := ByteStats(data); s.MonteCarloPi != 2 {
+		t.Fatalf("monte carlo pi = %f, want 2", s.MonteCarloPi)
+	}
+	if s := ByteStats([]byte{7, 7, 7}); s.SerialCorrelation != 0 || s.Entropy != 0 {
+		t.Fatalf("constant data: %+v", s)
+	}
+}
+
+func TestByteStatsWriter(t *testing.T) {
+	data := make([]byte, 1000000)
+	rand.New(rand.NewSource(1)).Read(data)
+	w := NewByteStatsWriter()
+	for i := 0; i < len(data); i += 999 {
+		_, _ = w.Write(data[i:min(i+999, len(data))])
+	}
+	s := w.Statistics()
+	fmt.Printf("%+v\n", s)
+	if *s != *ByteStats(data) {
+		t.Fatalf("streaming statistics differ")
+	}
+	if s.ChiSquareP < Alpha || s.MonteCarloPiError > 1 || math.Abs(s.SerialCorrelation) > 0.01 || math.Abs(s.Mean-127.5) > 0.5 {
+		t.Fatalf("unexpected statistics on random data: %+v", s)
+	}
+}
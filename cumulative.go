@@ -37,7 +37,6 @@ func CumulativeTest(bits []bool, forward bool) (float64, float64) {
 
 	var S int = 0
 	var Z int = 0
-	var P float64 = 1.0
 
 	for i := 0; i < n; i++ {
 		if forward {
@@ -56,6 +55,13 @@ func CumulativeTest(bits []bool, forward bool) (float64, float64) {
 		Z = max(Z, abs(S))
 	}
 
+	P := cumulativeP(Z, n)
+	return P, P
+}
+
+// cumulativeP 由累加和绝对值的最大值 Z 计算累加和检测P值
+func cumulativeP(Z, n int) float64 {
+	var P float64 = 1.0
 	sqrtN := math.Sqrt(float64(n)) // 提前求平方根，避免下面多次求平方根
 	for i := ((-n / Z) + 1) / 4; i <= ((n/Z)-1)/4; i++ {
 		P -= normal_CDF(float64((4*i+1)*Z)/sqrtN) - normal_CDF(float64((4*i-1)*Z)/sqrtN)
@@ -63,5 +69,5 @@ func CumulativeTest(bits []bool, forward bool) (float64, float64) {
 	for i := ((-n / Z) - 3) / 4; i <= ((n/Z)-1)/4; i++ {
 		P += normal_CDF(float64((4*i+3)*Z)/sqrtN) - normal_CDF(float64((4*i+1)*Z)/sqrtN)
 	}
-	return P, P
+	return P
 }
@@ -0,0 +1,208 @@
+// Copyright (c) 2021 Quan guanyu
+// randomness is licensed under Mulan PSL v2.
+// You can use this software according to the terms and conditions of the Mulan PSL v2.
+// You may obtain a copy of Mulan PSL v2 at:
+//          http://license.coscl.org.cn/MulanPSL2
+// THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND,
+// EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT,
+// MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+// See the Mulan PSL v2 for more details.
+
+package randomness
+
+import (
+	"bufio"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/bits"
+	"strings"
+)
+
+// Encoding 待检测数据的编码格式
+type Encoding int
+
+const (
+	// EncodingBinary 原始二进制，每字节8比特，即 NIST STS 的二进制（Binary）输入格式
+	EncodingBinary Encoding = iota
+	// EncodingASCII ASCII 字符 '0'、'1' 组成的文本，忽略空白字符，即 NIST STS 的 ASCII 输入格式
+	EncodingASCII
+	// EncodingHex 十六进制文本，忽略空白字符
+	EncodingHex
+	// EncodingBase64 标准 Base64 文本，忽略空白字符
+	EncodingBase64
+)
+
+var encodingNames = []string{"bin", "ascii", "hex", "base64"}
+
+// String 编码格式名称
+func (e Encoding) String() string {
+	if e < 0 || int(e) >= len(encodingNames) {
+		return fmt.Sprintf("Encoding(%d)", int(e))
+	}
+	return encodingNames[e]
+}
+
+// ParseEncoding 由名称解析编码格式，支持 bin、ascii、hex、base64，
+// 以及 NIST STS 输入格式名称 nist-bin、nist-ascii
+func ParseEncoding(name string) (Encoding, error) {
+	switch strings.ToLower(name) {
+	case "bin", "binary", "raw", "nist-bin":
+		return EncodingBinary, nil
+	case "ascii", "txt", "nist-ascii":
+		return EncodingASCII, nil
+	case "hex":
+		return EncodingHex, nil
+	case "base64", "b64":
+		return EncodingBase64, nil
+	}
+	return 0, fmt.Errorf("unknown encoding %q", name)
+}
+
+// ErrPartialByte ASCII 文本的比特数不是8的倍数，结尾不足一个字节的比特无法按字节输出
+var ErrPartialByte = errors.New("number of bits is not a multiple of 8")
+
+// NewDecoder 返回按 enc 解码 r 的 io.Reader，读出的字节按字节内高位在前与其他检测函数一致
+// order 为原始二进制、十六进制与 Base64 解码后字节的比特序，LSBFirst 时将每个字节的比特反转，
+// ASCII 文本按字符顺序给出比特序列，与 order 无关。
+// 输入中出现非法字符时 Read 返回错误；ASCII 文本结尾不足一个字节的比特被丢弃并返回 ErrPartialByte。
+func NewDecoder(r io.Reader, enc Encoding, order BitOrder) (io.Reader, error) {
+	var d io.Reader
+	switch enc {
+	case EncodingBinary:
+		d = r
+	case EncodingASCII:
+		return &asciiDecoder{r: bufio.NewReader(r)}, nil
+	case EncodingHex:
+		d = hex.NewDecoder(&spaceSkipper{r: bufio.NewReader(r)})
+	case EncodingBase64:
+		d = base64.NewDecoder(base64.StdEncoding, &spaceSkipper{r: bufio.NewReader(r)})
+	default:
+		return nil, fmt.Errorf("unknown encoding %v", enc)
+	}
+	if order == LSBFirst {
+		d = &bitReverser{r: d}
+	}
+	return d, nil
+}
+
+// Decode 解码 r 中的全部数据，参数见 NewDecoder
+func Decode(r io.Reader, enc Encoding, order BitOrder) ([]byte, error) {
+	d, err := NewDecoder(r, enc, order)
+	if err != nil {
+		return nil, err
+	}
+	return ioutil.ReadAll(d)
+}
+
+// DecodeASCIIBits 解码 ASCII '0'/'1' 文本（NIST STS ASCII 格式）为比特序列，保留结尾不足一个字节的比特
+func DecodeASCIIBits(r io.Reader) ([]bool, error) {
+	br := bufio.NewReader(r)
+	var res []bool
+	for offset := 0; ; offset++ {
+		c, err := br.ReadByte()
+		if err == io.EOF {
+			return res, nil
+		}
+		if err != nil {
+			return res, err
+		}
+		switch {
+		case c == '0' || c == '1':
+			res = append(res, c == '1')
+		case !isSpace(c):
+			return res, fmt.Errorf("invalid character %q at offset %d", c, offset)
+		}
+	}
+}
+
+// asciiDecoder 将 ASCII '0'/'1' 文本按8比特一组打包为字节
+type asciiDecoder struct {
+	r      *bufio.Reader
+	offset int // 已读取的字符数
+	err    error
+}
+
+func (d *asciiDecoder) Read(p []byte) (int, error) {
+	n := 0
+	for n < len(p) && d.err == nil {
+		var v byte
+		k := 0
+		for k < 8 {
+			c, err := d.r.ReadByte()
+			if err != nil {
+				d.err = err
+				if err == io.EOF && k > 0 {
+					d.err = ErrPartialByte
+				}
+				break
+			}
+			d.offset++
+			switch {
+			case c == '0' || c == '1':
+				v = v<<1 | (c - '0')
+				k++
+			case !isSpace(c):
+				d.err = fmt.Errorf("invalid character %q at offset %d", c, d.offset-1)
+			}
+			if d.err != nil {
+				break
+			}
+		}
+		if k == 8 {
+			p[n] = v
+			n++
+		}
+	}
+	if n > 0 {
+		return n, nil
+	}
+	return 0, d.err
+}
+
+// spaceSkipper 跳过空白字符
+type spaceSkipper struct {
+	r *bufio.Reader
+}
+
+func (s *spaceSkipper) Read(p []byte) (int, error) {
+	n := 0
+	for n < len(p) {
+		c, err := s.r.ReadByte()
+		if err != nil {
+			if n > 0 {
+				return n, nil
+			}
+			return 0, err
+		}
+		if !isSpace(c) {
+			p[n] = c
+			n++
+		}
+		// 已有数据且没有缓冲的输入时先返回，避免阻塞在流式输入上
+		if n > 0 && s.r.Buffered() == 0 {
+			break
+		}
+	}
+	return n, nil
+}
+
+// bitReverser 反转每个字节的比特序
+type bitReverser struct {
+	r io.Reader
+}
+
+func (b *bitReverser) Read(p []byte) (int, error) {
+	n, err := b.r.Read(p)
+	for i := range p[:n] {
+		p[i] = bits.Reverse8(p[i])
+	}
+	return n, err
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\v' || c == '\f'
+}
@@ -0,0 +1,90 @@
+package randomness
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"math/rand"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestDecode(t *testing.T) {
+	data := make([]byte, 10000)
+	rand.New(rand.NewSource(1)).Read(data)
+
+	var ascii strings.Builder
+	for i, b := range B2bitArr(data) {
+		ascii.WriteByte('0' + byte(b2i(b)))
+		if i%60 == 59 {
+			ascii.WriteString("\r\n")
+		} else if i%8 == 7 {
+			ascii.WriteByte(' ')
+		}
+	}
+	hexText := hex.EncodeToString(data)
+	b64 := base64.StdEncoding.EncodeToString(data)
+	for _, c := range []struct {
+		enc  Encoding
+		text string
+	}{
+		{EncodingBinary, string(data)},
+		{EncodingASCII, ascii.String()},
+		{EncodingHex, hexText[:1001] + "\n " + hexText[1001:]},
+		{EncodingBase64, b64[:76] + "\n" + b64[76:] + "\n"},
+	} {
+		got, err := Decode(strings.NewReader(c.text), c.enc, MSBFirst)
+		if err != nil || !bytes.Equal(got, data) {
+			t.Fatalf("%v: err = %v, equal = %v", c.enc, err, bytes.Equal(got, data))
+		}
+		enc, err := ParseEncoding(c.enc.String())
+		if err != nil || enc != c.enc {
+			t.Fatalf("ParseEncoding(%q) = %v, %v", c.enc.String(), enc, err)
+		}
+	}
+
+	got, err := Decode(bytes.NewReader([]byte{0x01, 0x80, 0xC4}), EncodingBinary, LSBFirst)
+	if err != nil || !bytes.Equal(got, []byte{0x80, 0x01, 0x23}) {
+		t.Fatalf("LSBFirst: %x, %v", got, err)
+	}
+}
+
+func TestDecodeErrors(t *testing.T) {
+	if _, err := Decode(strings.NewReader("0101 2"), EncodingASCII, MSBFirst); err == nil || err == ErrPartialByte {
+		t.Fatalf("invalid character not reported: %v", err)
+	}
+	if got, err := Decode(strings.NewReader("11110000 101"), EncodingASCII, MSBFirst); err != ErrPartialByte || !bytes.Equal(got, []byte{0xF0}) {
+		t.Fatalf("partial byte: %x, %v", got, err)
+	}
+	if _, err := Decode(strings.NewReader("0g"), EncodingHex, MSBFirst); err == nil {
+		t.Fatal("invalid hex not reported")
+	}
+	if _, err := Decode(strings.NewReader("!!!!"), EncodingBase64, MSBFirst); err == nil {
+		t.Fatal("invalid base64 not reported")
+	}
+	if _, err := ParseEncoding("ebcdic"); err == nil {
+		t.Fatal("unknown encoding accepted")
+	}
+}
+
+func TestDecodeASCIIBits(t *testing.T) {
+	f, err := os.Open("data/data_e")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	bits, err := DecodeASCIIBits(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(bits) != 1004882 {
+		t.Fatalf("read %d bits", len(bits))
+	}
+	e := ReadGroupInASCIIFormat("data/data_e")
+	for i := range e {
+		if e[i] != bits[i] {
+			t.Fatalf("bit %d differs", i)
+		}
+	}
+}
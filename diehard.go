@@ -0,0 +1,212 @@
+// Copyright (c) 2021 Quan guanyu
+// randomness is licensed under Mulan PSL v2.
+// You can use this software according to the terms and conditions of the Mulan PSL v2.
+// You may obtain a copy of Mulan PSL v2 at:
+//          http://license.coscl.org.cn/MulanPSL2
+// THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND,
+// EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT,
+// MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+// See the Mulan PSL v2 for more details.
+
+package randomness
+
+import (
+	"math"
+	"sort"
+)
+
+// Marsaglia Diehard 检测组。
+//
+// Diehard 检测把序列视为 32 比特整数序列，每项检测由若干次相同规模的试验组成，
+// 每次试验得到一个P值，再用 Kolmogorov-Smirnov 检验这些P值是否服从均匀分布，得到合并的P值。
+// 单次试验的规模与 Diehard 一致，试验次数不超过 Diehard 的设置，数据不足时按数据量减少试验次数；
+// 同一比特位置的多组试验（如生日间隔检测的9个比特窗口）复用同一组整数。
+//
+// Diehard 在 x86 平台按小端序读取 32 比特整数，与其结果对照时使用 DiehardTests(data, LSBFirst)。
+
+// diehardTest Diehard 检测项
+type diehardTest struct {
+	name     string
+	minWords int // 至少一次试验所需的整数个数
+	run      func(words []uint64) *TestResult
+}
+
+var diehardTests = []diehardTest{
+	{"生日间隔检测", birthdayMinWords, diehardBirthdaySpacings},
+	{"重叠5元排列检测", operm5MinWords, diehardOverlappingPermutations},
+	{"31x31矩阵秩检测", rank31MinWords, diehardRank31},
+	{"6x8矩阵秩检测", rank6x8MinWords, diehardRank6x8},
+	{"OPSO检测", monkeyMinWords, diehardOPSO},
+	{"OQSO检测", monkeyMinWords, diehardOQSO},
+	{"DNA检测", monkeyMinWords, diehardDNA},
+	{"字节流1计数检测", countOnesStreamMinWords, diehardCountOnesStream},
+	{"指定字节1计数检测", countOnesBytesMinWords, diehardCountOnesBytes},
+	{"停车场检测", parkingMinWords, diehardParkingLot},
+	{"最小距离检测", minDistanceMinWords, diehardMinimumDistance},
+	{"三维球检测", spheresMinWords, diehard3DSpheres},
+	{"挤压检测", squeezeMinWords, diehardSqueeze},
+	{"重叠和检测", sumsMinWords, diehardOverlappingSums},
+	{"升降游程检测", runsMinWords, diehardRunsUpDown},
+	{"掷骰子检测", crapsMinWords, diehardCraps},
+}
+
+// DiehardTests 运行全部 Diehard 检测，数据量不足一次试验的检测被跳过
+// data: 检测序列
+// order: 32比特整数的比特序
+func DiehardTests(data []byte, order BitOrder) []*TestResult {
+	words := Words(data, 32, order)
+	var res []*TestResult
+	for _, t := range diehardTests {
+		if len(words) < t.minWords {
+			continue
+		}
+		res = append(res, t.run(words))
+	}
+	return res
+}
+
+// diehardRun 以大端序（比特序列顺序）读取整数后运行 Diehard 检测项
+func diehardRun(data []byte, run func(words []uint64) *TestResult) *TestResult {
+	return run(Words(data, 32, MSBFirst))
+}
+
+// diehardWords 检查数据量是否足够一次试验
+func diehardWords(words []uint64, min int) {
+	if len(words) < min {
+		panic("please provide valid test bits")
+	}
+}
+
+// diehardResult 由各次试验的P值经 KS 检验合并得到检测结果
+func diehardResult(name string, p []float64) *TestResult {
+	P := diehardCombine(p)
+	return &TestResult{Name: name, P: P, Q: P, Pass: P >= Alpha}
+}
+
+// diehardResult2 由两组试验P值分别经 KS 检验合并得到检测结果
+func diehardResult2(name string, p1, p2 []float64) *TestResult {
+	P1 := diehardCombine(p1)
+	P2 := diehardCombine(p2)
+	return &TestResult{Name: name, P: P1, Q: P1, P2: P2, Q2: P2, Pass: math.Min(P1, P2) >= Alpha}
+}
+
+// diehardCombine 与 Diehard 一致，多次试验的P值经 KS 检验合并，只有一次试验时直接使用其P值
+func diehardCombine(p []float64) float64 {
+	if len(p) == 1 {
+		return p[0]
+	}
+	return KolmogorovSmirnov(p)
+}
+
+// uniform32 将32比特整数转换为 [0,1) 上的均匀分布随机数
+func uniform32(v uint64) float64 {
+	return float64(v) / (1 << 32)
+}
+
+// KolmogorovSmirnov 检验P值序列是否服从 [0,1] 上的均匀分布，返回 KS 检验的P值
+// 使用 Marsaglia、Tsang 与 Wang（2003）的方法精确计算 Kolmogorov 分布。
+// p: P值序列
+func KolmogorovSmirnov(p []float64) float64 {
+	n := len(p)
+	if n == 0 {
+		panic("please provide valid p-values")
+	}
+	s := append([]float64(nil), p...)
+	sort.Float64s(s)
+	D := 0.0
+	for i, v := range s {
+		if d := float64(i+1)/float64(n) - v; d > D {
+			D = d
+		}
+		if d := v - float64(i)/float64(n); d > D {
+			D = d
+		}
+	}
+	P := 1 - kolmogorovCDF(n, D)
+	if P < 0 {
+		P = 0
+	}
+	return P
+}
+
+// kolmogorovCDF P(D_n < d)，Marsaglia-Tsang-Wang 算法
+func kolmogorovCDF(n int, d float64) float64 {
+	fn := float64(n)
+	s := d * d * fn
+	if s > 7.24 || (s > 3.76 && n > 99) {
+		return 1 - 2*math.Exp(-(2.000071+0.331/math.Sqrt(fn)+1.409/fn)*s)
+	}
+	k := int(fn*d) + 1
+	m := 2*k - 1
+	h := float64(k) - fn*d
+	H := make([]float64, m*m)
+	for i := 0; i < m; i++ {
+		for j := 0; j < m; j++ {
+			if i-j+1 >= 0 {
+				H[i*m+j] = 1
+			}
+		}
+	}
+	for i := 0; i < m; i++ {
+		H[i*m] -= math.Pow(h, float64(i+1))
+		H[(m-1)*m+i] -= math.Pow(h, float64(m-i))
+	}
+	if 2*h-1 > 0 {
+		H[(m-1)*m] += math.Pow(2*h-1, float64(m))
+	}
+	for i := 0; i < m; i++ {
+		for j := 0; j < m; j++ {
+			if i-j+1 > 0 {
+				for g := 1; g <= i-j+1; g++ {
+					H[i*m+j] /= float64(g)
+				}
+			}
+		}
+	}
+	Q, eQ := matrixPower(H, m, n)
+	v := Q[(k-1)*m+k-1]
+	for i := 1; i <= n; i++ {
+		v = v * float64(i) / fn
+		if v < 1e-140 {
+			v *= 1e140
+			eQ -= 140
+		}
+	}
+	return v * math.Pow(10, float64(eQ))
+}
+
+// matrixPower 计算 m 阶方阵 A 的 n 次幂，结果为 Q*10^e
+func matrixPower(A []float64, m, n int) ([]float64, int) {
+	if n == 1 {
+		return append([]float64(nil), A...), 0
+	}
+	V, eV := matrixPower(A, m, n/2)
+	B := matrixMultiply(V, V, m)
+	eB := 2 * eV
+	if n%2 == 1 {
+		B = matrixMultiply(A, B, m)
+	}
+	if B[(m/2)*m+m/2] > 1e140 {
+		for i := range B {
+			B[i] *= 1e-140
+		}
+		eB += 140
+	}
+	return B, eB
+}
+
+func matrixMultiply(A, B []float64, m int) []float64 {
+	C := make([]float64, m*m)
+	for i := 0; i < m; i++ {
+		for k := 0; k < m; k++ {
+			a := A[i*m+k]
+			if a == 0 {
+				continue
+			}
+			for j := 0; j < m; j++ {
+				C[i*m+j] += a * B[k*m+j]
+			}
+		}
+	}
+	return C
+}
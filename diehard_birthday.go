@@ -0,0 +1,91 @@
+// Copyright (c) 2021 Quan guanyu
+// randomness is licensed under Mulan PSL v2.
+// You can use this software according to the terms and conditions of the Mulan PSL v2.
+// You may obtain a copy of Mulan PSL v2 at:
+//          http://license.coscl.org.cn/MulanPSL2
+// THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND,
+// EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT,
+// MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+// See the Mulan PSL v2 for more details.
+
+package randomness
+
+import (
+	"math"
+	"sort"
+)
+
+const (
+	birthdayDays     = 512 // 每次试验的生日个数 m
+	birthdayBits     = 24  // 一年的天数 n=2^24
+	birthdayOffsets  = 9   // 24比特窗口在32比特整数中的位置个数
+	birthdaySamples  = 500 // 每个窗口的试验次数
+	birthdayMinWords = 50 * birthdayDays
+)
+
+// DiehardBirthdaySpacings Diehard 生日间隔检测
+func DiehardBirthdaySpacings(data []byte) *TestResult {
+	return diehardRun(data, diehardBirthdaySpacings)
+}
+
+func diehardBirthdaySpacings(words []uint64) *TestResult {
+	return diehardResult("生日间隔检测", DiehardBirthdaySpacingsProto(words))
+}
+
+// DiehardBirthdaySpacingsProto Diehard 生日间隔检测
+// 每次试验取 m=512 个整数的24比特作为一年 n=2^24 天中的生日，排序后计算相邻生日的间隔，
+// 统计重复出现的间隔值个数 j，j 近似服从均值 λ=m^3/(4n)=2 的泊松分布。
+// 对每个比特窗口（高位起第1~24比特至第9~32比特）统计至多500次试验的 j 并进行卡方检验，
+// 返回9个窗口的P值。
+//
+// words: 32比特整数序列
+func DiehardBirthdaySpacingsProto(words []uint64) []float64 {
+	diehardWords(words, birthdayMinWords)
+	samples := len(words) / birthdayDays
+	if samples > birthdaySamples {
+		samples = birthdaySamples
+	}
+
+	// 泊松分布 j=0..maxJ-1 及尾部
+	const maxJ = 20
+	lambda := math.Pow(birthdayDays, 3) / (4 * (1 << birthdayBits))
+	dist := make([]float64, maxJ+1)
+	tail := 1.0
+	for j := 0; j < maxJ; j++ {
+		dist[j] = math.Exp(-lambda + float64(j)*math.Log(lambda) - logGamma(float64(j)+1))
+		tail -= dist[j]
+	}
+	dist[maxJ] = tail
+	category, probs := mergeCategories(dist, float64(samples), 5)
+
+	p := make([]float64, birthdayOffsets)
+	b := make([]int, birthdayDays)
+	for s := 0; s < birthdayOffsets; s++ {
+		shift := uint(32 - birthdayBits - s)
+		counts := make([]int, len(probs))
+		for e := 0; e < samples; e++ {
+			for i, v := range words[e*birthdayDays : (e+1)*birthdayDays] {
+				b[i] = int(v>>shift) & (1<<birthdayBits - 1)
+			}
+			sort.Ints(b)
+			// 间隔：第一个生日到年初的距离以及相邻生日之差
+			prev := 0
+			for i, v := range b {
+				b[i], prev = v-prev, v
+			}
+			sort.Ints(b)
+			j := 0
+			for i := 1; i < len(b); i++ {
+				if b[i] == b[i-1] {
+					j++
+				}
+			}
+			if j > maxJ {
+				j = maxJ
+			}
+			counts[category[j]]++
+		}
+		p[s] = chiSquareP(counts, probs)
+	}
+	return p
+}
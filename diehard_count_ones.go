@@ -0,0 +1,124 @@
+// Copyright (c) 2021 Quan guanyu
+// randomness is licensed under Mulan PSL v2.
+// You can use this software according to the terms and conditions of the Mulan PSL v2.
+// You may obtain a copy of Mulan PSL v2 at:
+//          http://license.coscl.org.cn/MulanPSL2
+// THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND,
+// EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT,
+// MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+// See the Mulan PSL v2 for more details.
+
+package randomness
+
+import (
+	"math"
+	"math/bits"
+)
+
+const (
+	countOnesWords          = 256000 // 每次试验的5字母单词个数
+	countOnesStreamRuns     = 2      // 字节流检测的试验次数
+	countOnesStreamMinWords = (countOnesWords + 4 + 3) / 4
+	countOnesBytesOffsets   = 25
+	countOnesBytesMinWords  = countOnesWords + 4
+)
+
+// countOnesLetter 字节中1的个数对应的字母：0~2、3、4、5、6~8 分别为字母 0~4
+var countOnesLetter = [9]int{0, 0, 0, 1, 2, 3, 4, 4, 4}
+
+// countOnesProbs 各字母的概率
+var countOnesProbs = [5]float64{37.0 / 256, 56.0 / 256, 70.0 / 256, 56.0 / 256, 37.0 / 256}
+
+// DiehardCountOnesStream Diehard 字节流1计数检测
+func DiehardCountOnesStream(data []byte) *TestResult {
+	return diehardRun(data, diehardCountOnesStream)
+}
+
+func diehardCountOnesStream(words []uint64) *TestResult {
+	return diehardResult("字节流1计数检测", DiehardCountOnesStreamProto(words))
+}
+
+// DiehardCountOnesStreamProto Diehard 字节流1计数检测
+// 将整数序列视为字节流，每个字节按其中1的个数转换为5个字母之一，
+// 统计256000个重叠的5字母单词与4字母单词的频数，Q5-Q4 近似服从自由度为2500的卡方分布，
+// P值为 Φ((Q5-Q4-2500)/√5000)。至多2次试验，返回各次试验的P值。
+//
+// words: 32比特整数序列
+func DiehardCountOnesStreamProto(words []uint64) []float64 {
+	diehardWords(words, countOnesStreamMinWords)
+	runs := (len(words)*4 - 4) / countOnesWords
+	if runs > countOnesStreamRuns {
+		runs = countOnesStreamRuns
+	}
+	letters := make([]int, countOnesWords+4)
+	p := make([]float64, runs)
+	for r := 0; r < runs; r++ {
+		for i := range letters {
+			pos := r*countOnesWords + i
+			b := words[pos/4] >> uint(24-8*(pos%4)) & 0xff
+			letters[i] = countOnesLetter[bits.OnesCount8(uint8(b))]
+		}
+		p[r] = countOnesPValue(letters)
+	}
+	return p
+}
+
+// DiehardCountOnesBytes Diehard 指定字节1计数检测
+func DiehardCountOnesBytes(data []byte) *TestResult {
+	return diehardRun(data, diehardCountOnesBytes)
+}
+
+func diehardCountOnesBytes(words []uint64) *TestResult {
+	return diehardResult("指定字节1计数检测", DiehardCountOnesBytesProto(words))
+}
+
+// DiehardCountOnesBytesProto Diehard 指定字节1计数检测
+// 从每个整数的固定位置取一个字节，按其中1的个数转换为字母，统计方法同字节流1计数检测。
+// 字节位置从高位起第1~8比特至第25~32比特，对每个位置各得到一个P值。
+//
+// words: 32比特整数序列
+func DiehardCountOnesBytesProto(words []uint64) []float64 {
+	diehardWords(words, countOnesBytesMinWords)
+	letters := make([]int, countOnesWords+4)
+	p := make([]float64, countOnesBytesOffsets)
+	for s := range p {
+		shift := uint(24 - s)
+		for i := range letters {
+			letters[i] = countOnesLetter[bits.OnesCount8(uint8(words[i]>>shift))]
+		}
+		p[s] = countOnesPValue(letters)
+	}
+	return p
+}
+
+// countOnesPValue 由字母序列计算 Q5-Q4 统计量的P值
+func countOnesPValue(letters []int) float64 {
+	N := len(letters) - 4
+	c4 := make([]int, 625)
+	c5 := make([]int, 3125)
+	for i := 0; i < N; i++ {
+		w := 0
+		for j := 0; j < 4; j++ {
+			w = w*5 + letters[i+j]
+		}
+		c4[w]++
+		c5[w*5+letters[i+4]]++
+	}
+	Q := countOnesChiSquare(c5, 5, float64(N)) - countOnesChiSquare(c4, 4, float64(N))
+	return normal_CDF((Q - 2500) / math.Sqrt(5000))
+}
+
+// countOnesChiSquare 长度为 k 的单词频数的卡方统计量
+func countOnesChiSquare(counts []int, k int, N float64) float64 {
+	var V float64 = 0
+	for w, c := range counts {
+		e := N
+		for j := 0; j < k; j++ {
+			e *= countOnesProbs[w%5]
+			w /= 5
+		}
+		d := float64(c) - e
+		V += d * d / e
+	}
+	return V
+}
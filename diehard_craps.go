@@ -0,0 +1,113 @@
+// Copyright (c) 2021 Quan guanyu
+// randomness is licensed under Mulan PSL v2.
+// You can use this software according to the terms and conditions of the Mulan PSL v2.
+// You may obtain a copy of Mulan PSL v2 at:
+//          http://license.coscl.org.cn/MulanPSL2
+// THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND,
+// EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT,
+// MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+// See the Mulan PSL v2 for more details.
+
+package randomness
+
+import "math"
+
+const (
+	crapsGames     = 200000
+	crapsMaxThrows = 21 // 掷骰次数不小于21的合并为一类
+	crapsMinWords  = 20000
+)
+
+// DiehardCraps Diehard 掷骰子检测
+func DiehardCraps(data []byte) *TestResult {
+	return diehardRun(data, diehardCraps)
+}
+
+func diehardCraps(words []uint64) *TestResult {
+	p1, p2 := DiehardCrapsProto(words)
+	return diehardResult2("掷骰子检测", []float64{p1}, []float64{p2})
+}
+
+// DiehardCrapsProto Diehard 掷骰子检测
+// 每个整数 v 给出一个骰子点数 1+⌊6v/2^32⌋，每次掷两个骰子，按掷骰子（craps）规则进行至多200000局游戏。
+// 获胜局数近似服从 p=244/495 的二项分布，由正态近似得到双侧P值 p1；
+// 每局的掷骰次数（1、2、...、20、>=21）的分布精确计算，卡方检验得到P值 p2，期望频数过小的类别依次合并。
+//
+// words: 32比特整数序列
+func DiehardCrapsProto(words []uint64) (float64, float64) {
+	diehardWords(words, crapsMinWords)
+	pos := 0
+	throw := func() (int, bool) {
+		if pos+2 > len(words) {
+			return 0, false
+		}
+		s := int(words[pos]*6>>32) + int(words[pos+1]*6>>32) + 2
+		pos += 2
+		return s, true
+	}
+
+	wins := 0
+	var throws []int
+	for len(throws) < crapsGames {
+		s, ok := throw()
+		if !ok {
+			break
+		}
+		t, win := 1, false
+		switch s {
+		case 7, 11:
+			win = true
+		case 2, 3, 12:
+		default:
+			point := s
+			for {
+				if s, ok = throw(); !ok {
+					break
+				}
+				t++
+				if s == point || s == 7 {
+					win = s == point
+					break
+				}
+			}
+		}
+		if !ok {
+			break
+		}
+		if win {
+			wins++
+		}
+		throws = append(throws, min(t, crapsMaxThrows))
+	}
+
+	N := float64(len(throws))
+	p := 244.0 / 495
+	z := (float64(wins) - N*p) / math.Sqrt(N*p*(1-p))
+	p1 := math.Erfc(math.Abs(z) / math.Sqrt2)
+
+	category, probs := mergeCategories(crapsThrowProbs(), N, minExpected)
+	counts := make([]int, len(probs))
+	for _, t := range throws {
+		counts[category[t-1]]++
+	}
+	p2 := chiSquareP(counts, probs)
+	return p1, p2
+}
+
+// crapsThrowProbs 一局游戏掷骰次数为 1、2、...、20、>=21 的概率
+func crapsThrowProbs() []float64 {
+	probs := make([]float64, crapsMaxThrows)
+	probs[0] = 12.0 / 36
+	rest := 1 - probs[0]
+	// 点数 4、5、6、8、9、10 的组合数
+	for _, ways := range []float64{3, 4, 5, 5, 4, 3} {
+		e := (ways + 6) / 36
+		for t := 2; t < crapsMaxThrows; t++ {
+			q := ways / 36 * math.Pow(1-e, float64(t-2)) * e
+			probs[t-1] += q
+			rest -= q
+		}
+	}
+	probs[crapsMaxThrows-1] = rest
+	return probs
+}
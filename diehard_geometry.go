@@ -0,0 +1,177 @@
+// Copyright (c) 2021 Quan guanyu
+// randomness is licensed under Mulan PSL v2.
+// You can use this software according to the terms and conditions of the Mulan PSL v2.
+// You may obtain a copy of Mulan PSL v2 at:
+//          http://license.coscl.org.cn/MulanPSL2
+// THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND,
+// EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT,
+// MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+// See the Mulan PSL v2 for more details.
+
+package randomness
+
+import (
+	"math"
+	"sort"
+)
+
+const (
+	parkingAttempts = 12000 // 每次试验尝试停车的次数
+	parkingRuns     = 10
+	parkingMinWords = 2 * parkingAttempts
+
+	minDistancePoints   = 8000
+	minDistanceRuns     = 100
+	minDistanceMinWords = 2 * minDistancePoints
+
+	spheresPoints   = 4000
+	spheresRuns     = 20
+	spheresMinWords = 3 * spheresPoints
+)
+
+// DiehardParkingLot Diehard 停车场检测
+func DiehardParkingLot(data []byte) *TestResult {
+	return diehardRun(data, diehardParkingLot)
+}
+
+func diehardParkingLot(words []uint64) *TestResult {
+	return diehardResult("停车场检测", DiehardParkingLotProto(words))
+}
+
+// DiehardParkingLotProto Diehard 停车场检测
+// 在 100x100 的正方形停车场中随机停放边长为1的车辆（以中心坐标表示，各坐标之差的绝对值均不超过1时相撞），
+// 尝试12000次后成功停放的车辆数 k 近似服从均值3523、标准差21.9的正态分布，P值为 Φ((k-3523)/21.9)。
+// 至多10次试验，返回各次试验的P值。
+//
+// words: 32比特整数序列
+func DiehardParkingLotProto(words []uint64) []float64 {
+	diehardWords(words, parkingMinWords)
+	runs := len(words) / parkingMinWords
+	if runs > parkingRuns {
+		runs = parkingRuns
+	}
+	p := make([]float64, runs)
+	// 以边长为1的网格索引已停放的车辆，只需检查相邻网格
+	grid := make([][]int, 100*100)
+	for r := range p {
+		for i := range grid {
+			grid[i] = grid[i][:0]
+		}
+		xs := make([]float64, 0, parkingAttempts)
+		ys := make([]float64, 0, parkingAttempts)
+		for i := 0; i < parkingAttempts; i++ {
+			x := 100 * uniform32(words[r*parkingMinWords+2*i])
+			y := 100 * uniform32(words[r*parkingMinWords+2*i+1])
+			gx, gy := int(x), int(y)
+			crashed := false
+			for cx := gx - 1; cx <= gx+1 && !crashed; cx++ {
+				for cy := gy - 1; cy <= gy+1 && !crashed; cy++ {
+					if cx < 0 || cx >= 100 || cy < 0 || cy >= 100 {
+						continue
+					}
+					for _, j := range grid[cx*100+cy] {
+						if math.Abs(x-xs[j]) <= 1 && math.Abs(y-ys[j]) <= 1 {
+							crashed = true
+							break
+						}
+					}
+				}
+			}
+			if !crashed {
+				grid[gx*100+gy] = append(grid[gx*100+gy], len(xs))
+				xs = append(xs, x)
+				ys = append(ys, y)
+			}
+		}
+		p[r] = normal_CDF((float64(len(xs)) - 3523) / 21.9)
+	}
+	return p
+}
+
+// DiehardMinimumDistance Diehard 最小距离检测
+func DiehardMinimumDistance(data []byte) *TestResult {
+	return diehardRun(data, diehardMinimumDistance)
+}
+
+func diehardMinimumDistance(words []uint64) *TestResult {
+	return diehardResult("最小距离检测", DiehardMinimumDistanceProto(words))
+}
+
+// DiehardMinimumDistanceProto Diehard 最小距离检测
+// 在边长为10000的正方形中随机选取8000个点，点间最小距离 d 的平方近似服从均值0.995的指数分布，
+// P值为 1-exp(-d^2/0.995)。至多100次试验，返回各次试验的P值。
+//
+// words: 32比特整数序列
+func DiehardMinimumDistanceProto(words []uint64) []float64 {
+	diehardWords(words, minDistanceMinWords)
+	runs := len(words) / minDistanceMinWords
+	if runs > minDistanceRuns {
+		runs = minDistanceRuns
+	}
+	p := make([]float64, runs)
+	points := make([][3]float64, minDistancePoints)
+	for r := range p {
+		for i := range points {
+			points[i] = [3]float64{
+				10000 * uniform32(words[r*minDistanceMinWords+2*i]),
+				10000 * uniform32(words[r*minDistanceMinWords+2*i+1]),
+			}
+		}
+		d := minDistance(points)
+		p[r] = 1 - math.Exp(-d*d/0.995)
+	}
+	return p
+}
+
+// Diehard3DSpheres Diehard 三维球检测
+func Diehard3DSpheres(data []byte) *TestResult {
+	return diehardRun(data, diehard3DSpheres)
+}
+
+func diehard3DSpheres(words []uint64) *TestResult {
+	return diehardResult("三维球检测", Diehard3DSpheresProto(words))
+}
+
+// Diehard3DSpheresProto Diehard 三维球检测
+// 在边长为1000的立方体中随机选取4000个点，点间最小距离 r 的立方近似服从均值30的指数分布，
+// P值为 1-exp(-r^3/30)。至多20次试验，返回各次试验的P值。
+//
+// words: 32比特整数序列
+func Diehard3DSpheresProto(words []uint64) []float64 {
+	diehardWords(words, spheresMinWords)
+	runs := len(words) / spheresMinWords
+	if runs > spheresRuns {
+		runs = spheresRuns
+	}
+	p := make([]float64, runs)
+	points := make([][3]float64, spheresPoints)
+	for r := range p {
+		for i := range points {
+			for k := 0; k < 3; k++ {
+				points[i][k] = 1000 * uniform32(words[r*spheresMinWords+3*i+k])
+			}
+		}
+		d := minDistance(points)
+		p[r] = 1 - math.Exp(-d*d*d/30)
+	}
+	return p
+}
+
+// minDistance 点集中两点间的最小欧氏距离，按第一个坐标排序后扫描，会修改 points 的顺序
+func minDistance(points [][3]float64) float64 {
+	sort.Slice(points, func(i, j int) bool { return points[i][0] < points[j][0] })
+	best := math.Inf(1)
+	for i := range points {
+		for j := i + 1; j < len(points) && points[j][0]-points[i][0] < best; j++ {
+			var d2 float64 = 0
+			for k := 0; k < 3; k++ {
+				d := points[j][k] - points[i][k]
+				d2 += d * d
+			}
+			if d := math.Sqrt(d2); d < best {
+				best = d
+			}
+		}
+	}
+	return best
+}
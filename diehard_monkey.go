@@ -0,0 +1,82 @@
+// Copyright (c) 2021 Quan guanyu
+// randomness is licensed under Mulan PSL v2.
+// You can use this software according to the terms and conditions of the Mulan PSL v2.
+// You may obtain a copy of Mulan PSL v2 at:
+//          http://license.coscl.org.cn/MulanPSL2
+// THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND,
+// EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT,
+// MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+// See the Mulan PSL v2 for more details.
+
+package randomness
+
+import "math/bits"
+
+const (
+	monkeyKeystrokes = 1 << 21 // 每次试验敲击的字母个数
+	monkeyWordBits   = 20      // 单词共 20 比特，可能的单词个数为 2^20
+	monkeyMean       = 141909  // 缺失单词个数的均值
+	monkeyMinWords   = monkeyKeystrokes + 9
+)
+
+// DiehardOPSO Diehard OPSO（重叠字母对稀疏占用）检测
+func DiehardOPSO(data []byte) *TestResult {
+	return diehardRun(data, diehardOPSO)
+}
+
+func diehardOPSO(words []uint64) *TestResult {
+	return diehardResult("OPSO检测", DiehardMonkeyProto(words, 10, 290))
+}
+
+// DiehardOQSO Diehard OQSO（重叠字母四元组稀疏占用）检测
+func DiehardOQSO(data []byte) *TestResult {
+	return diehardRun(data, diehardOQSO)
+}
+
+func diehardOQSO(words []uint64) *TestResult {
+	return diehardResult("OQSO检测", DiehardMonkeyProto(words, 5, 295))
+}
+
+// DiehardDNA Diehard DNA 检测
+func DiehardDNA(data []byte) *TestResult {
+	return diehardRun(data, diehardDNA)
+}
+
+func diehardDNA(words []uint64) *TestResult {
+	return diehardResult("DNA检测", DiehardMonkeyProto(words, 2, 339))
+}
+
+// DiehardMonkeyProto Diehard 猴子检测（OPSO、OQSO、DNA）
+// 从每个整数的固定位置取 k 比特作为一个字母，由 2^21 次敲击得到的字母序列中
+// 重叠的 20/k 个字母构成单词，统计 2^20 个可能的单词中未出现的个数 M，
+// M 近似服从均值 141909、标准差为 sd 的正态分布，P值为 Φ((M-141909)/sd)。
+// 字母位置从高位起第1~k比特至第(33-k)~32比特，对每个位置各得到一个P值。
+//
+// words: 32比特整数序列
+// k: 字母的比特数，OPSO 为10，OQSO 为5，DNA 为2
+// sd: 缺失单词个数的标准差，OPSO 为290，OQSO 为295，DNA 为339
+func DiehardMonkeyProto(words []uint64, k int, sd float64) []float64 {
+	diehardWords(words, monkeyMinWords)
+	letters := monkeyWordBits / k
+	seen := make([]uint64, 1<<monkeyWordBits/64)
+	p := make([]float64, 33-k)
+	for s := range p {
+		shift := uint(32 - k - s)
+		for i := range seen {
+			seen[i] = 0
+		}
+		w := 0
+		for i := 0; i < monkeyKeystrokes+letters-1; i++ {
+			w = (w<<uint(k) | int(words[i]>>shift)&(1<<uint(k)-1)) & (1<<monkeyWordBits - 1)
+			if i >= letters-1 {
+				seen[w>>6] |= 1 << uint(w&63)
+			}
+		}
+		missing := 0
+		for _, v := range seen {
+			missing += 64 - bits.OnesCount64(v)
+		}
+		p[s] = normal_CDF((float64(missing) - monkeyMean) / sd)
+	}
+	return p
+}
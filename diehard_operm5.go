@@ -0,0 +1,227 @@
+// Copyright (c) 2021 Quan guanyu
+// randomness is licensed under Mulan PSL v2.
+// You can use this software according to the terms and conditions of the Mulan PSL v2.
+// You may obtain a copy of Mulan PSL v2 at:
+//          http://license.coscl.org.cn/MulanPSL2
+// THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND,
+// EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT,
+// MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+// See the Mulan PSL v2 for more details.
+
+package randomness
+
+import (
+	"math"
+	"sync"
+)
+
+const (
+	operm5Tuples   = 1000000 // 每次试验的重叠5元组个数
+	operm5Runs     = 2       // 试验次数
+	operm5MinWords = 120*50 + 4
+)
+
+var (
+	operm5Once sync.Once
+	operm5Inv  []float64 // 单个5元组的协方差矩阵的伪逆，120x120
+	operm5Rank int
+)
+
+// DiehardOverlappingPermutations Diehard 重叠5元排列检测
+func DiehardOverlappingPermutations(data []byte) *TestResult {
+	return diehardRun(data, diehardOverlappingPermutations)
+}
+
+func diehardOverlappingPermutations(words []uint64) *TestResult {
+	return diehardResult("重叠5元排列检测", DiehardOverlappingPermutationsProto(words))
+}
+
+// DiehardOverlappingPermutationsProto Diehard 重叠5元排列检测（OPERM5）
+// 统计每个重叠的5元组 (U_i,...,U_i+4) 的大小关系对应的 120 种排列的出现次数。
+// 相邻的5元组相关，计数向量 x 的协方差矩阵 NΣ 由枚举 5+d（d=1..4）个元素的全部排列精确计算，
+// 统计量 V = x^T Σ^+ x / N（Σ^+ 为伪逆）服从自由度为 rank(Σ)=96 的卡方分布。
+// 每次试验至多使用 10^6 个5元组，至多2次试验，返回各次试验的P值。
+//
+// words: 32比特整数序列
+func DiehardOverlappingPermutationsProto(words []uint64) []float64 {
+	diehardWords(words, operm5MinWords)
+	operm5Once.Do(operm5Init)
+	N := len(words) - 4
+	runs := N / operm5Tuples
+	if runs > operm5Runs {
+		runs = operm5Runs
+	}
+	size := operm5Tuples
+	if runs == 0 {
+		runs, size = 1, N
+	}
+
+	p := make([]float64, runs)
+	for r := 0; r < runs; r++ {
+		counts := make([]float64, 120)
+		for i := r * size; i < (r+1)*size; i++ {
+			counts[permutationIndex(words[i:i+5])]++
+		}
+		fN := float64(size)
+		for a := range counts {
+			counts[a] -= fN / 120
+		}
+		var V float64 = 0
+		for a := 0; a < 120; a++ {
+			for b := 0; b < 120; b++ {
+				V += counts[a] * operm5Inv[a*120+b] * counts[b]
+			}
+		}
+		V /= fN
+		p[r] = igamc(float64(operm5Rank)/2, V/2)
+	}
+	return p
+}
+
+// permutationIndex 5个元素大小关系的排列编号（Knuth 算法 P），相等元素按位置先后比较
+func permutationIndex(x []uint64) int {
+	var u [5]uint64
+	copy(u[:], x)
+	idx := 0
+	for r := 5; r > 1; r-- {
+		s := 0
+		for j := 1; j < r; j++ {
+			if u[j] >= u[s] {
+				s = j
+			}
+		}
+		idx = r*idx + s
+		u[r-1], u[s] = u[s], u[r-1]
+	}
+	return idx
+}
+
+// operm5Init 计算单个5元组计数的协方差矩阵 Σ 及其伪逆
+// Σ = diag(1/120) - 1/120^2 + Σ_{d=1..4} (M_d + M_d^T)，
+// M_d[a][b] = P(第 i 个5元组为排列 a，第 i+d 个为排列 b) - 1/120^2。
+func operm5Init() {
+	const K = 120
+	S := make([]float64, K*K)
+	for a := 0; a < K; a++ {
+		for b := 0; b < K; b++ {
+			S[a*K+b] = -1.0 / (K * K)
+		}
+		S[a*K+a] += 1.0 / K
+	}
+	for d := 1; d <= 4; d++ {
+		joint := make([]float64, K*K)
+		n := 5 + d
+		total := 0
+		perm := make([]uint64, n)
+		for i := range perm {
+			perm[i] = uint64(i)
+		}
+		forEachPermutation(perm, func(p []uint64) {
+			joint[permutationIndex(p[:5])*K+permutationIndex(p[d:d+5])]++
+			total++
+		})
+		for a := 0; a < K; a++ {
+			for b := 0; b < K; b++ {
+				m := joint[a*K+b]/float64(total) - 1.0/(K*K)
+				S[a*K+b] += m
+				S[b*K+a] += m
+			}
+		}
+	}
+
+	values, vectors := symmetricEigen(S, K)
+	maxV := 0.0
+	for _, v := range values {
+		maxV = math.Max(maxV, math.Abs(v))
+	}
+	operm5Inv = make([]float64, K*K)
+	for k, v := range values {
+		if math.Abs(v) <= 1e-9*maxV {
+			continue
+		}
+		operm5Rank++
+		for a := 0; a < K; a++ {
+			for b := 0; b < K; b++ {
+				operm5Inv[a*K+b] += vectors[a*K+k] * vectors[b*K+k] / v
+			}
+		}
+	}
+}
+
+// forEachPermutation 枚举 p 的全部排列（Heap 算法）
+func forEachPermutation(p []uint64, fn func(p []uint64)) {
+	n := len(p)
+	c := make([]int, n)
+	fn(p)
+	for i := 0; i < n; {
+		if c[i] < i {
+			if i%2 == 0 {
+				p[0], p[i] = p[i], p[0]
+			} else {
+				p[c[i]], p[i] = p[i], p[c[i]]
+			}
+			fn(p)
+			c[i]++
+			i = 0
+		} else {
+			c[i] = 0
+			i++
+		}
+	}
+}
+
+// symmetricEigen 对称矩阵的特征分解（循环 Jacobi 方法）
+// 返回特征值及按列存放的特征向量矩阵。
+func symmetricEigen(A []float64, n int) ([]float64, []float64) {
+	a := append([]float64(nil), A...)
+	V := make([]float64, n*n)
+	for i := 0; i < n; i++ {
+		V[i*n+i] = 1
+	}
+	for sweep := 0; sweep < 100; sweep++ {
+		off := 0.0
+		for p := 0; p < n; p++ {
+			for q := p + 1; q < n; q++ {
+				off += a[p*n+q] * a[p*n+q]
+			}
+		}
+		if off < 1e-30 {
+			break
+		}
+		for p := 0; p < n; p++ {
+			for q := p + 1; q < n; q++ {
+				apq := a[p*n+q]
+				if math.Abs(apq) < 1e-300 {
+					continue
+				}
+				theta := (a[q*n+q] - a[p*n+p]) / (2 * apq)
+				t := 1 / (math.Abs(theta) + math.Sqrt(theta*theta+1))
+				if theta < 0 {
+					t = -t
+				}
+				c := 1 / math.Sqrt(t*t+1)
+				s := t * c
+				for k := 0; k < n; k++ {
+					akp, akq := a[k*n+p], a[k*n+q]
+					a[k*n+p] = c*akp - s*akq
+					a[k*n+q] = s*akp + c*akq
+				}
+				for k := 0; k < n; k++ {
+					apk, aqk := a[p*n+k], a[q*n+k]
+					a[p*n+k] = c*apk - s*aqk
+					a[q*n+k] = s*apk + c*aqk
+				}
+				for k := 0; k < n; k++ {
+					vkp, vkq := V[k*n+p], V[k*n+q]
+					V[k*n+p] = c*vkp - s*vkq
+					V[k*n+q] = s*vkp + c*vkq
+				}
+			}
+		}
+	}
+	values := make([]float64, n)
+	for i := range values {
+		values[i] = a[i*n+i]
+	}
+	return values, V
+}
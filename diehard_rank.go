@@ -0,0 +1,150 @@
+// Copyright (c) 2021 Quan guanyu
+// randomness is licensed under Mulan PSL v2.
+// You can use this software according to the terms and conditions of the Mulan PSL v2.
+// You may obtain a copy of Mulan PSL v2 at:
+//          http://license.coscl.org.cn/MulanPSL2
+// THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND,
+// EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT,
+// MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+// See the Mulan PSL v2 for more details.
+
+package randomness
+
+import "math"
+
+const (
+	rank31Matrices  = 40000
+	rank31MinWords  = 31 * 2000
+	rank6x8Matrices = 100000
+	rank6x8Offsets  = 25
+	rank6x8MinWords = 6 * 2000
+)
+
+// DiehardRank31 Diehard 31x31矩阵秩检测
+func DiehardRank31(data []byte) *TestResult {
+	return diehardRun(data, diehardRank31)
+}
+
+func diehardRank31(words []uint64) *TestResult {
+	return diehardResult("31x31矩阵秩检测", DiehardRank31Proto(words))
+}
+
+// DiehardRank31Proto Diehard 31x31矩阵秩检测
+// 以31个整数的高31比特为行构成 GF(2) 上的 31x31 矩阵，统计至多40000个矩阵的秩
+// （<=28、29、30、31 四类）并进行卡方检验，返回P值。
+//
+// words: 32比特整数序列
+func DiehardRank31Proto(words []uint64) []float64 {
+	diehardWords(words, rank31MinWords)
+	N := len(words) / 31
+	if N > rank31Matrices {
+		N = rank31Matrices
+	}
+	rows := make([]uint64, 31)
+	counts := make([]int, 4)
+	for i := 0; i < N; i++ {
+		for j := range rows {
+			rows[j] = words[i*31+j] >> 1
+		}
+		r := gf2Rank(rows, 31) - 28
+		if r < 0 {
+			r = 0
+		}
+		counts[r]++
+	}
+	return []float64{chiSquareP(counts, binaryRankProbs(31, 31, 28))}
+}
+
+// DiehardRank6x8 Diehard 6x8矩阵秩检测
+func DiehardRank6x8(data []byte) *TestResult {
+	return diehardRun(data, diehardRank6x8)
+}
+
+func diehardRank6x8(words []uint64) *TestResult {
+	return diehardResult("6x8矩阵秩检测", DiehardRank6x8Proto(words))
+}
+
+// DiehardRank6x8Proto Diehard 6x8矩阵秩检测
+// 取6个整数中同一位置的一个字节为行构成 6x8 矩阵，统计至多100000个矩阵的秩（<=4、5、6 三类）
+// 并进行卡方检验。对字节的25个位置（高位起第1~8比特至第25~32比特）各得到一个P值。
+//
+// words: 32比特整数序列
+func DiehardRank6x8Proto(words []uint64) []float64 {
+	diehardWords(words, rank6x8MinWords)
+	N := len(words) / 6
+	if N > rank6x8Matrices {
+		N = rank6x8Matrices
+	}
+	probs := binaryRankProbs(6, 8, 4)
+	rows := make([]uint64, 6)
+	p := make([]float64, rank6x8Offsets)
+	for s := 0; s < rank6x8Offsets; s++ {
+		shift := uint(24 - s)
+		counts := make([]int, 3)
+		for i := 0; i < N; i++ {
+			for j := range rows {
+				rows[j] = words[i*6+j] >> shift & 0xff
+			}
+			r := gf2Rank(rows, 8) - 4
+			if r < 0 {
+				r = 0
+			}
+			counts[r]++
+		}
+		p[s] = chiSquareP(counts, probs)
+	}
+	return p
+}
+
+// gf2Rank GF(2) 上矩阵的秩，rows 为各行（低 cols 比特有效），计算过程中会修改 rows
+func gf2Rank(rows []uint64, cols int) int {
+	r := 0
+	for c := cols - 1; c >= 0 && r < len(rows); c-- {
+		bit := uint64(1) << uint(c)
+		pivot := -1
+		for i := r; i < len(rows); i++ {
+			if rows[i]&bit != 0 {
+				pivot = i
+				break
+			}
+		}
+		if pivot < 0 {
+			continue
+		}
+		rows[r], rows[pivot] = rows[pivot], rows[r]
+		for i := r + 1; i < len(rows); i++ {
+			if rows[i]&bit != 0 {
+				rows[i] ^= rows[r]
+			}
+		}
+		r++
+	}
+	return r
+}
+
+// binaryRankProbs GF(2) 上 m x n 随机矩阵的秩分布，秩 <=low 合并为第一类，
+// 返回秩为 <=low、low+1、...、min(m,n) 的概率
+func binaryRankProbs(m, n, low int) []float64 {
+	full := m
+	if n < full {
+		full = n
+	}
+	probs := make([]float64, full-low+1)
+	rest := 1.0
+	for r := full; r > low; r-- {
+		probs[r-low] = binaryRankProb(r, m, n)
+		rest -= probs[r-low]
+	}
+	probs[0] = rest
+	return probs
+}
+
+// binaryRankProb GF(2) 上 m x n 随机矩阵的秩为 r 的概率
+// P(r) = 2^{r(m+n-r)-mn} ∏_{i=0}^{r-1} (1-2^{i-m})(1-2^{i-n})/(1-2^{i-r})
+func binaryRankProb(r, m, n int) float64 {
+	P := math.Pow(2, float64(r*(m+n-r)-m*n))
+	for i := 0; i < r; i++ {
+		P *= (1 - math.Pow(2, float64(i-m))) * (1 - math.Pow(2, float64(i-n))) / (1 - math.Pow(2, float64(i-r)))
+	}
+	return P
+}
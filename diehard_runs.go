@@ -0,0 +1,91 @@
+// Copyright (c) 2021 Quan guanyu
+// randomness is licensed under Mulan PSL v2.
+// You can use this software according to the terms and conditions of the Mulan PSL v2.
+// You may obtain a copy of Mulan PSL v2 at:
+//          http://license.coscl.org.cn/MulanPSL2
+// THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND,
+// EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT,
+// MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+// See the Mulan PSL v2 for more details.
+
+package randomness
+
+const (
+	runsLength   = 10000 // 每次试验的整数个数
+	runsRuns     = 10
+	runsMinWords = runsLength
+)
+
+// runsA、runsB Knuth 升降游程检测的系数矩阵与期望（TAOCP 3.3.2 G）
+var runsA = [6][6]float64{
+	{4529.4, 9044.9, 13568, 18091, 22615, 27892},
+	{9044.9, 18097, 27139, 36187, 45234, 55789},
+	{13568, 27139, 40721, 54281, 67852, 83685},
+	{18091, 36187, 54281, 72414, 90470, 111580},
+	{22615, 45234, 67852, 90470, 113262, 139476},
+	{27892, 55789, 83685, 111580, 139476, 172860},
+}
+var runsB = [6]float64{1.0 / 6, 5.0 / 24, 11.0 / 120, 19.0 / 720, 29.0 / 5040, 1.0 / 840}
+
+// DiehardRunsUpDown Diehard 升降游程检测
+func DiehardRunsUpDown(data []byte) *TestResult {
+	return diehardRun(data, diehardRunsUpDown)
+}
+
+func diehardRunsUpDown(words []uint64) *TestResult {
+	up, down := DiehardRunsUpDownProto(words)
+	return diehardResult2("升降游程检测", up, down)
+}
+
+// DiehardRunsUpDownProto Diehard 升降游程检测
+// 统计10000个整数中长度为 1、2、...、5、>=6 的上升游程个数 C_i，
+// V = Σ (C_i-nb_i)(C_j-nb_j)a_ij/(n-6) 服从自由度为6的卡方分布；下降游程同理。
+// 至多10次试验，返回上升游程与下降游程在各次试验中的P值。
+//
+// words: 32比特整数序列
+func DiehardRunsUpDownProto(words []uint64) ([]float64, []float64) {
+	diehardWords(words, runsMinWords)
+	runs := len(words) / runsLength
+	if runs > runsRuns {
+		runs = runsRuns
+	}
+	up := make([]float64, runs)
+	down := make([]float64, runs)
+	for r := 0; r < runs; r++ {
+		w := words[r*runsLength : (r+1)*runsLength]
+		var cu, cd [6]int
+		lu, ld := 1, 1
+		for i := 1; i < len(w); i++ {
+			if w[i] > w[i-1] {
+				lu++
+			} else {
+				cu[min(lu, 6)-1]++
+				lu = 1
+			}
+			if w[i] < w[i-1] {
+				ld++
+			} else {
+				cd[min(ld, 6)-1]++
+				ld = 1
+			}
+		}
+		cu[min(lu, 6)-1]++
+		cd[min(ld, 6)-1]++
+		up[r] = runsPValue(cu)
+		down[r] = runsPValue(cd)
+	}
+	return up, down
+}
+
+// runsPValue 由游程个数计算P值
+func runsPValue(c [6]int) float64 {
+	n := float64(runsLength)
+	var V float64 = 0
+	for i := 0; i < 6; i++ {
+		for j := 0; j < 6; j++ {
+			V += (float64(c[i]) - n*runsB[i]) * (float64(c[j]) - n*runsB[j]) * runsA[i][j]
+		}
+	}
+	V /= n - 6
+	return igamc(3, V/2)
+}
@@ -0,0 +1,107 @@
+// Copyright (c) 2021 Quan guanyu
+// randomness is licensed under Mulan PSL v2.
+// You can use this software according to the terms and conditions of the Mulan PSL v2.
+// You may obtain a copy of Mulan PSL v2 at:
+//          http://license.coscl.org.cn/MulanPSL2
+// THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND,
+// EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT,
+// MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+// See the Mulan PSL v2 for more details.
+
+package randomness
+
+import "math"
+
+const (
+	squeezeStart    = 1 << 31 // 初始值 k
+	squeezeMax      = 48      // 步数上限，不小于48的合并为一类
+	squeezeMin      = 6       // 不大于6的步数合并为一类
+	squeezeReps     = 100000
+	squeezeMinWords = squeezeMax * 1000
+)
+
+// DiehardSqueeze Diehard 挤压检测
+func DiehardSqueeze(data []byte) *TestResult {
+	return diehardRun(data, diehardSqueeze)
+}
+
+func diehardSqueeze(words []uint64) *TestResult {
+	return diehardResult("挤压检测", DiehardSqueezeProto(words))
+}
+
+// DiehardSqueezeProto Diehard 挤压检测
+// 从 k=2^31 开始反复令 k=⌈k·U⌉（U 为 (0,1] 上的均匀分布随机数）直至 k=1，记录所需步数 j。
+// 每一步的 k 在 1..k 上均匀分布，j-1 的概率母函数为 exp(Σ_r H_r(z^r-1)/r)，H_r=Σ_{i=2}^{k} i^{-r}，
+// 据此精确计算 j 的分布。对至多100000次试验的 j（<=6、7、...、>=48）进行卡方检验，
+// 期望频数过小的相邻类别依次合并，返回P值。
+//
+// words: 32比特整数序列
+func DiehardSqueezeProto(words []uint64) []float64 {
+	diehardWords(words, squeezeMinWords)
+	var steps []int
+	pos := 0
+	for len(steps) < squeezeReps {
+		k := uint64(squeezeStart)
+		j := 0
+		for k != 1 && j < squeezeMax && pos < len(words) {
+			k = (k*(words[pos]+1) + 1<<32 - 1) >> 32
+			pos++
+			j++
+		}
+		if k != 1 && j < squeezeMax {
+			break
+		}
+		steps = append(steps, j)
+	}
+
+	category, probs := mergeCategories(squeezeProbs(), float64(len(steps)), minExpected)
+	counts := make([]int, len(probs))
+	for _, j := range steps {
+		if j < squeezeMin {
+			j = squeezeMin
+		}
+		counts[category[j-squeezeMin]]++
+	}
+	return []float64{chiSquareP(counts, probs)}
+}
+
+// squeezeProbs 步数 j 为 <=6、7、...、47、>=48 的概率
+func squeezeProbs() []float64 {
+	const n = squeezeMax
+	// H_r = Σ_{i=2}^{k} i^{-r}，r=1 使用调和数的渐近展开，其余直接求和并以积分近似尾部
+	H := make([]float64, n)
+	k := float64(squeezeStart)
+	H[1] = math.Log(k) + 0.57721566490153286 + 1/(2*k) - 1/(12*k*k) - 1
+	for r := 2; r < n; r++ {
+		for i := 2; i <= 10000; i++ {
+			H[r] += math.Pow(float64(i), -float64(r))
+		}
+		H[r] += (math.Pow(10000.5, float64(1-r)) - math.Pow(k+0.5, float64(1-r))) / float64(r-1)
+	}
+	// b_m = P(j-1 = m)，m·b_m = Σ_{r=1}^{m} H_r b_{m-r}
+	b := make([]float64, n)
+	sum := 0.0
+	for r := 1; r < n; r++ {
+		sum += H[r] / float64(r)
+	}
+	b[0] = math.Exp(-sum)
+	for m := 1; m < n; m++ {
+		for r := 1; r <= m; r++ {
+			b[m] += H[r] * b[m-r]
+		}
+		b[m] /= float64(m)
+	}
+
+	probs := make([]float64, squeezeMax-squeezeMin+1)
+	rest := 1.0
+	for j := 1; j < squeezeMax; j++ {
+		c := j - squeezeMin
+		if c < 0 {
+			c = 0
+		}
+		probs[c] += b[j-1]
+		rest -= b[j-1]
+	}
+	probs[len(probs)-1] = rest
+	return probs
+}
@@ -0,0 +1,88 @@
+// Copyright (c) 2021 Quan guanyu
+// randomness is licensed under Mulan PSL v2.
+// You can use this software according to the terms and conditions of the Mulan PSL v2.
+// You may obtain a copy of Mulan PSL v2 at:
+//          http://license.coscl.org.cn/MulanPSL2
+// THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND,
+// EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT,
+// MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+// See the Mulan PSL v2 for more details.
+
+package randomness
+
+import "math"
+
+const (
+	sumsLength   = 100 // 每个和的项数，也是每次试验的和的个数
+	sumsRuns     = 10
+	sumsMinWords = 2*sumsLength - 1
+)
+
+// DiehardOverlappingSums Diehard 重叠和检测
+func DiehardOverlappingSums(data []byte) *TestResult {
+	return diehardRun(data, diehardOverlappingSums)
+}
+
+func diehardOverlappingSums(words []uint64) *TestResult {
+	return diehardResult("重叠和检测", DiehardOverlappingSumsProto(words))
+}
+
+// DiehardOverlappingSumsProto Diehard 重叠和检测
+// 由199个均匀分布随机数得到100个重叠的和 S_j=U_j+...+U_{j+99}，S 近似服从均值50、
+// 协方差 (100-|i-j|)/12 的多元正态分布。以协方差矩阵的 Cholesky 分解 LL^T 变换 z=L^{-1}(S-50)
+// 得到100个独立标准正态变量，Φ(z) 经 KS 检验得到一次试验的P值。至多10次试验，返回各次试验的P值。
+// 与 Diehard 一致，变换后的变量并非严格服从正态分布，随机序列下合并P值的拒绝率略高于显著性水平（约1.5%）。
+//
+// words: 32比特整数序列
+func DiehardOverlappingSumsProto(words []uint64) []float64 {
+	diehardWords(words, sumsMinWords)
+	runs := len(words) / sumsMinWords
+	if runs > sumsRuns {
+		runs = sumsRuns
+	}
+
+	const n = sumsLength
+	// 协方差矩阵的 Cholesky 分解，L 为下三角矩阵
+	L := make([]float64, n*n)
+	for i := 0; i < n; i++ {
+		for j := 0; j <= i; j++ {
+			s := float64(n-(i-j)) / 12
+			for k := 0; k < j; k++ {
+				s -= L[i*n+k] * L[j*n+k]
+			}
+			if i == j {
+				L[i*n+i] = math.Sqrt(s)
+			} else {
+				L[i*n+j] = s / L[j*n+j]
+			}
+		}
+	}
+
+	p := make([]float64, runs)
+	S := make([]float64, n)
+	u := make([]float64, n)
+	for r := range p {
+		w := words[r*sumsMinWords : (r+1)*sumsMinWords]
+		sum := 0.0
+		for i := 0; i < n; i++ {
+			sum += uniform32(w[i])
+		}
+		for j := 0; j < n; j++ {
+			if j > 0 {
+				sum += uniform32(w[j+n-1]) - uniform32(w[j-1])
+			}
+			S[j] = sum - float64(n)/2
+		}
+		// 前代求解 Lz = S
+		for i := 0; i < n; i++ {
+			z := S[i]
+			for k := 0; k < i; k++ {
+				z -= L[i*n+k] * S[k]
+			}
+			S[i] = z / L[i*n+i]
+			u[i] = normal_CDF(S[i])
+		}
+		p[r] = KolmogorovSmirnov(u)
+	}
+	return p
+}
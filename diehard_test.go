@@ -0,0 +1,169 @@
+package randomness
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"testing"
+)
+
+func TestKolmogorovSmirnov(t *testing.T) {
+	// Marsaglia、Tsang 与 Wang 论文中的例子
+	if v := kolmogorovCDF(10, 0.274); math.Abs(v-0.6284796154565043) > 1e-12 {
+		t.Fatalf("K(10, 0.274) = %.16f", v)
+	}
+	// n=1 时 D=max(p,1-p)，P(D>=d)=2(1-d)
+	if p := KolmogorovSmirnov([]float64{0.2}); math.Abs(p-0.4) > 1e-12 {
+		t.Fatalf("P = %f, want 0.4", p)
+	}
+	if p := KolmogorovSmirnov([]float64{0.5, 0.94, 0.18, 0.33, 0.71, 0.02, 0.86, 0.47}); fmt.Sprintf("%.6f", p) != "0.997597" {
+		t.Fatalf("P = %f", p)
+	}
+	// P值集中在0附近
+	if p := KolmogorovSmirnov([]float64{0.01, 0.05, 0.12, 0.2, 0.03, 0.31, 0.08, 0.02, 0.15, 0.4}); fmt.Sprintf("%.6f", p) != "0.000568" {
+		t.Fatalf("P = %f", p)
+	}
+}
+
+func TestBinaryRankProbs(t *testing.T) {
+	// 与 Diehard 6x8 矩阵秩检测的概率及国标 32x32 矩阵秩检测的常数对照
+	for i, want := range []float64{0.009443, 0.217439, 0.773118} {
+		if p := binaryRankProbs(6, 8, 4)[i]; math.Abs(p-want) > 1e-6 {
+			t.Fatalf("6x8 rank category %d: %f, want %f", i, p, want)
+		}
+	}
+	if p := binaryRankProbs(32, 32, 30); math.Abs(p[2]-0.2888) > 1e-4 || math.Abs(p[1]-0.5776) > 1e-4 {
+		t.Fatalf("32x32 rank: %v", p)
+	}
+}
+
+func TestDiehardProbs(t *testing.T) {
+	for name, probs := range map[string][]float64{
+		"squeeze": squeezeProbs(),
+		"craps":   crapsThrowProbs(),
+	} {
+		sum := 0.0
+		for _, p := range probs {
+			if p < 0 {
+				t.Fatalf("%s: negative probability %v", name, probs)
+			}
+			sum += p
+		}
+		if math.Abs(sum-1) > 1e-12 {
+			t.Fatalf("%s: probabilities sum to %f", name, sum)
+		}
+	}
+}
+
+// sha256Stream SHA-256 计数器模式产生的固定序列，依次为 SHA-256(i) 的摘要，i 为32比特大端序计数
+func sha256Stream(n int) []byte {
+	data := make([]byte, 0, n+sha256.Size)
+	var ctr [4]byte
+	for i := uint32(0); len(data) < n; i++ {
+		binary.BigEndian.PutUint32(ctr[:], i)
+		h := sha256.Sum256(ctr[:])
+		data = append(data, h[:]...)
+	}
+	return data[:n]
+}
+
+// diehardCase Diehard 检测项及合并P值的已知结果，P2 为空时不检查
+type diehardCase struct {
+	test  func(data []byte) *TestResult
+	p, p2 string
+}
+
+func checkDiehard(t *testing.T, data []byte, order BitOrder, cases []diehardCase) {
+	res := DiehardTests(data, order)
+	if len(res) != len(cases) {
+		t.Fatalf("%d tests run, want %d", len(res), len(cases))
+	}
+	for i, c := range cases {
+		r := res[i]
+		fmt.Printf("%s P-value: %f, P2-value: %f\n", r.Name, r.P, r.P2)
+		if fmt.Sprintf("%.6f", r.P) != c.p || (c.p2 != "" && fmt.Sprintf("%.6f", r.P2) != c.p2) {
+			t.Fatalf("%s: P = %f, P2 = %f, want %s %s", r.Name, r.P, r.P2, c.p, c.p2)
+		}
+		// 单项检测按大端序读取整数
+		if order == MSBFirst {
+			if s := c.test(data); s.Name != r.Name || s.P != r.P || s.P2 != r.P2 {
+				t.Fatalf("%s: P = %f, DiehardTests %f", s.Name, s.P, r.P)
+			}
+		}
+	}
+}
+
+func TestDiehardE(t *testing.T) {
+	// e 的前10^6比特只有31250个整数，数据量不足一次试验的检测被跳过
+	checkDiehard(t, getEConstantBytes(), MSBFirst, []diehardCase{
+		{DiehardBirthdaySpacings, "0.561704", ""},
+		{DiehardOverlappingPermutations, "0.336391", ""},
+		{DiehardRank6x8, "0.544680", ""},
+		{DiehardParkingLot, "0.625377", ""},
+		{DiehardMinimumDistance, "0.367044", ""},
+		{Diehard3DSpheres, "0.933309", ""},
+		{DiehardOverlappingSums, "0.566697", ""},
+		{DiehardRunsUpDown, "0.160447", "0.540174"},
+		{DiehardCraps, "0.193833", "0.911877"},
+	})
+}
+
+func TestDiehardSHA256(t *testing.T) {
+	// 数据量足够运行全部检测
+	checkDiehard(t, sha256Stream(monkeyMinWords*4), MSBFirst, []diehardCase{
+		{DiehardBirthdaySpacings, "0.611169", ""},
+		{DiehardOverlappingPermutations, "0.038823", ""},
+		{DiehardRank31, "0.466240", ""},
+		{DiehardRank6x8, "0.735071", ""},
+		{DiehardOPSO, "0.820846", ""},
+		{DiehardOQSO, "0.635338", ""},
+		{DiehardDNA, "0.884778", ""},
+		{DiehardCountOnesStream, "0.668468", ""},
+		{DiehardCountOnesBytes, "0.468946", ""},
+		{DiehardParkingLot, "0.601844", ""},
+		{DiehardMinimumDistance, "0.336796", ""},
+		{Diehard3DSpheres, "0.219952", ""},
+		{DiehardSqueeze, "0.788131", ""},
+		{DiehardOverlappingSums, "0.094206", ""},
+		{DiehardRunsUpDown, "0.762163", "0.687202"},
+		{DiehardCraps, "0.375508", "0.198045"},
+	})
+}
+
+func TestDiehardTestsDefect(t *testing.T) {
+	// 线性同余发生器 x = 69069x + 1 mod 2^32 的低位周期短
+	data := make([]byte, 300000*4)
+	x := uint32(1)
+	for i := 0; i < len(data); i += 4 {
+		x = 69069*x + 1
+		binary.BigEndian.PutUint32(data[i:], x)
+	}
+	if res := DiehardCountOnesStream(data); fmt.Sprintf("%.6f", res.P) != "0.000000" {
+		t.Fatalf("%s: P = %f", res.Name, res.P)
+	}
+	checkDiehard(t, data, LSBFirst, []diehardCase{
+		{DiehardBirthdaySpacings, "0.228254", ""},
+		{DiehardOverlappingPermutations, "0.000000", ""},
+		{DiehardRank31, "0.248303", ""},
+		{DiehardRank6x8, "0.001356", ""},
+		{DiehardCountOnesStream, "0.000000", ""},
+		{DiehardCountOnesBytes, "0.000008", ""},
+		{DiehardParkingLot, "0.000000", ""},
+		{DiehardMinimumDistance, "0.000000", ""},
+		{Diehard3DSpheres, "0.000000", ""},
+		{DiehardSqueeze, "0.000000", ""},
+		{DiehardOverlappingSums, "0.591107", ""},
+		{DiehardRunsUpDown, "0.000000", "0.000000"},
+		{DiehardCraps, "0.000000", "0.000000"},
+	})
+}
+
+func TestDiehardInsufficient(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatalf("expected panic on insufficient data")
+		}
+	}()
+	DiehardSqueeze(make([]byte, 100))
+}
@@ -13,17 +13,30 @@ package randomness
 import (
 	"math"
 	"math/cmplx"
+	"runtime"
 	"sync"
 
 	"github.com/Trisia/randomness/fft"
 )
 
-// FFT缓存表，用于预置常见数据规模的FFT
+// FFT缓存表，用于预置常见数据规模的实数输入FFT
+// 缓存的FFT实例创建后只读，其旋转因子等表可在多个检测协程及FFT并行协程之间共享。
 var (
-	fftCache = make(map[int]fft.FFT)
+	fftCache = make(map[int]fft.RealPlan)
 	fftMutex sync.RWMutex
 )
 
+// DFTWorkers 离散傅里叶检测中双精度FFT使用的并行协程数，默认为CPU核心数。
+// 大规模数据使用分块的四步FFT算法，设置为1时在单个协程内分块计算。
+// 请在检测开始前设置。
+var DFTWorkers = runtime.NumCPU()
+
+// 任意长度FFT计划缓存表，用于精确长度模式
+var (
+	fftPlanCache = make(map[int]fft.Plan)
+	fftPlanMutex sync.RWMutex
+)
+
 // GMT 0005-2021 规范的附录A中的样本长度及检测设置
 const (
 	// SmallScale 小规模：2*10^4 bit
@@ -46,8 +59,8 @@ const (
 //	}
 //}
 
-// getFFT 获取FFT实例，优先使用缓存的预置表
-func getFFT(n int) (fft.FFT, error) {
+// getFFT 获取实数输入FFT实例，优先使用缓存的预置表
+func getFFT(n int) (fft.RealPlan, error) {
 	fftMutex.RLock()
 	if f, exists := fftCache[n]; exists {
 		fftMutex.RUnlock()
@@ -64,14 +77,39 @@ func getFFT(n int) (fft.FFT, error) {
 		return f, nil
 	}
 
-	f, err := fft.New(n)
+	f, err := fft.NewRealPlan(n)
 	if err != nil {
-		return fft.FFT{}, err
+		return fft.RealPlan{}, err
 	}
 	fftCache[n] = f
 	return f, nil
 }
 
+// getFFTPlan 获取任意长度的FFT计划，优先使用缓存
+func getFFTPlan(n int) (fft.Plan, error) {
+	fftPlanMutex.RLock()
+	if p, exists := fftPlanCache[n]; exists {
+		fftPlanMutex.RUnlock()
+		return p, nil
+	}
+	fftPlanMutex.RUnlock()
+
+	fftPlanMutex.Lock()
+	defer fftPlanMutex.Unlock()
+
+	// 双重检查，防止并发创建
+	if p, exists := fftPlanCache[n]; exists {
+		return p, nil
+	}
+
+	p, err := fft.NewPlan(n)
+	if err != nil {
+		return fft.Plan{}, err
+	}
+	fftPlanCache[n] = p
+	return p, nil
+}
+
 // DiscreteFourierTransform 离散傅里叶检测
 func DiscreteFourierTransform(data []byte) *TestResult {
 	p, q := DiscreteFourierTransformTestBytes(data)
@@ -79,8 +117,13 @@ func DiscreteFourierTransform(data []byte) *TestResult {
 }
 
 // DiscreteFourierTransformTestBytes 离散傅里叶检测
+// 不小于 2*10^4 bit 时直接对字节处理，避免字节切片到位切片的转换，节约内存。
 func DiscreteFourierTransformTestBytes(data []byte) (float64, float64) {
-	return DiscreteFourierTransformTest(B2bitArr(data))
+	n := len(data) * 8
+	if n < SmallScale {
+		return DiscreteFourierTransformTest(B2bitArr(data))
+	}
+	return discreteFourierTransformPadded(func(i int) bool { return data[i>>3]&(0x80>>uint(i&7)) != 0 }, n)
 }
 
 // DiscreteFourierTransformTest 离散傅里叶检测
@@ -94,18 +137,11 @@ func DiscreteFourierTransformTest(bits []bool) (float64, float64) {
 		panic("please provide test bits")
 	}
 
-	// 根据GMT 0005-2021规范的数据规模选择优化策略
-	switch {
-	case n >= LargeScale:
-		return discreteFourierTransformTestOptimized(bits, true)
-	case n >= MediumScale:
-		return discreteFourierTransformTestOptimized(bits, false)
-	case n >= SmallScale:
-		return discreteFourierTransformTestOptimized(bits, false)
-	default:
-		// 小于2*10^4 bit的数据使用标准算法
+	// 小于2*10^4 bit的数据使用标准算法
+	if n < SmallScale {
 		return discreteFourierTransformTestSmall(bits)
 	}
+	return discreteFourierTransformTestOptimized(bits)
 }
 
 // discreteFourierTransformTest 离散傅里叶检测，非分块处理版本
@@ -212,72 +248,225 @@ func discreteFourierTransformTestSmall(bits []bool) (float64, float64) {
 }
 
 // discreteFourierTransformTestOptimized 优化的离散傅里叶检测实现
-// 使用预置FFT表加速，支持GMT 0005-2021规范的数据规模
-func discreteFourierTransformTestOptimized(bits []bool, isLargeScale bool) (float64, float64) {
-	n := len(bits)
+// 使用预置FFT表加速，支持GMT 0005-2021规范的数据规模。
+// 序列为 ±1 实数序列，使用实数输入FFT（半长打包）在补零后的缓冲区上原地变换，
+// 内存消耗约为 ceilPow2(n)*8 字节。
+func discreteFourierTransformTestOptimized(bits []bool) (float64, float64) {
+	return discreteFourierTransformPadded(func(i int) bool { return bits[i] }, len(bits))
+}
 
-	// Step 1, 2 - 计算最接近的2的幂次
+// discreteFourierTransformPadded 补零模式的离散傅里叶检测实现
+// bit: 第 i 个比特
+// n: 序列长度
+func discreteFourierTransformPadded(bit func(i int) bool, n int) (float64, float64) {
+	// Step 1, 2 - 计算最接近的2的幂次，补零后进行实数输入FFT
 	N := ceilPow2(n)
-	rr := make([]complex128, N)
+	N_1 := dftRealCount(bit, n, N, false)
 
-	// 优化数据转换：使用预分配常量和批量处理
-	ones := complex(1.0, 0)
-	minusOnes := complex(-1.0, 0)
+	// Step 7 - 预计算分母
+	denominator := math.Sqrt(0.95 * 0.05 * float64(2.0*n) / 3.8)
+	V := (float64(N_1) - 0.95*float64(n)/2) / denominator
+	P := math.Erfc(math.Abs(V))
+	Q := math.Erfc(V) / 2
+
+	return P, Q
+}
 
-	// 根据数据规模选择不同的批量大小
-	batchSize := 1024
-	if isLargeScale {
-		batchSize = 8192 // 大规模数据使用更大的批量
+// dftRealCount 对 ±1 序列（补零至 N 点）进行实数输入FFT，返回前 n/2-1 个频点中模小于门限 T 的个数 N_1
+// bit: 第 i 个比特
+// n: 序列长度
+// N: 变换长度，N >= n 且为偶数
+// single: 是否使用单精度（float32）变换
+func dftRealCount(bit func(i int) bool, n, N int, single bool) int {
+	f, err := getFFT(N)
+	if err != nil {
+		panic(err)
 	}
 
-	// 批量转换数据
-	for i := 0; i < n; i += batchSize {
-		end := i + batchSize
-		if end > n {
-			end = n
-		}
+	// Step 4
+	T_squared := 2.995732274 * float64(n)
+	limit := n/2 - 1
 
-		for j := i; j < end; j++ {
-			if bits[j] {
-				rr[j] = ones
-			} else {
-				rr[j] = minusOnes
+	// Step 3, 6
+	var N_1 int = 0
+	if single {
+		// Step 1, 2 - 半长打包: z[k] = x[2k] + i*x[2k+1]，x = 2ε-1
+		sign := dftSign(bit, n)
+		z := make([]complex64, N/2)
+		for k := range z {
+			z[k] = complex(float32(sign(2*k)), float32(sign(2*k+1)))
+		}
+		f.Transform64(z)
+		// z[0] 的实部为 X[0]
+		if limit > 0 && float64(real(z[0]))*float64(real(z[0])) < T_squared {
+			N_1++
+		}
+		for i := 1; i < limit; i++ {
+			real, imag := float64(real(z[i])), float64(imag(z[i]))
+			if real*real+imag*imag < T_squared {
+				N_1++
 			}
 		}
+		return N_1
+	}
+
+	z := dftRealTransform(bit, n, N)
+	// z[0] 的实部为 X[0]
+	if limit > 0 && real(z[0])*real(z[0]) < T_squared {
+		N_1++
+	}
+	for i := 1; i < limit; i++ {
+		real, imag := real(z[i]), imag(z[i])
+		if real*real+imag*imag < T_squared {
+			N_1++
+		}
 	}
+	return N_1
+}
 
-	// 使用预置FFT表进行傅里叶变换
+// dftSign 返回补零后的 ±1 序列 x = 2ε-1，i >= n 时为 0
+func dftSign(bit func(i int) bool, n int) func(i int) float64 {
+	return func(i int) float64 {
+		if i >= n {
+			return 0
+		}
+		if bit(i) {
+			return 1.0
+		}
+		return -1.0
+	}
+}
+
+// dftRealTransform 对 ±1 序列（补零至 N 点）进行双精度实数输入FFT
+// 返回半长打包的频谱：z[0] = X[0] + i*X[N/2]，z[k] = X[k]，0 < k < N/2
+func dftRealTransform(bit func(i int) bool, n, N int) []complex128 {
 	f, err := getFFT(N)
 	if err != nil {
 		panic(err)
 	}
-	f.Transform(rr)
+	// Step 1, 2 - 半长打包: z[k] = x[2k] + i*x[2k+1]，x = 2ε-1
+	sign := dftSign(bit, n)
+	z := make([]complex128, N/2)
+	for k := range z {
+		z[k] = complex(sign(2*k), sign(2*k+1))
+	}
+	f.TransformParallel(z, DFTWorkers)
+	return z
+}
 
-	// Step 4 - 预计算常量
-	T := math.Sqrt(2.995732274 * float64(n))
+// DiscreteFourierTransformExact 离散傅里叶检测（精确长度模式）
+func DiscreteFourierTransformExact(data []byte) *TestResult {
+	p, q := DiscreteFourierTransformExactTestBytes(data)
+	return &TestResult{Name: "离散傅里叶检测", P: p, Q: q, Pass: p >= Alpha}
+}
 
-	// Step 5
-	N_0 := 0.95 * float64(n) / 2
+// DiscreteFourierTransformExactTestBytes 离散傅里叶检测（精确长度模式）
+// 这里直接对字节直接处理，避免字节切片到位切片的转换，节约内存。
+func DiscreteFourierTransformExactTestBytes(data []byte) (float64, float64) {
+	return discreteFourierTransformExact(func(i int) bool { return data[i>>3]&(0x80>>uint(i&7)) != 0 }, len(data)*8, false)
+}
 
-	// Step 6 - 优化循环计算
-	var N_1 int = 0
-	limit := n/2 - 1
-	T_squared := T * T // 避免重复计算平方
+// DiscreteFourierTransformExactTestBytesFloat32 离散傅里叶检测（精确长度模式，单精度）
+func DiscreteFourierTransformExactTestBytesFloat32(data []byte) (float64, float64) {
+	return discreteFourierTransformExact(func(i int) bool { return data[i>>3]&(0x80>>uint(i&7)) != 0 }, len(data)*8, true)
+}
 
-	for i := 0; i < limit; i++ {
-		// 使用平方比较避免开方运算
-		real := real(rr[i])
-		imag := imag(rr[i])
-		if real*real+imag*imag < T_squared {
-			N_1++
+// DiscreteFourierTransformExactTestFloat32 离散傅里叶检测（精确长度模式，单精度）
+// 使用 float32 进行傅里叶变换，变换缓冲区内存减半（10^8 比特约 400MB）。
+// 变换的相对误差上界见 fft.Float32ErrorBound，对 10^8 比特序列，频点模值的典型绝对误差远小于1，
+// 仅当频点模值与门限 T 几乎相等时才可能影响统计结果。
+func DiscreteFourierTransformExactTestFloat32(bits []bool) (float64, float64) {
+	return discreteFourierTransformExact(func(i int) bool { return bits[i] }, len(bits), true)
+}
+
+// DiscreteFourierTransformExactTest 离散傅里叶检测（精确长度模式）
+// 对长度恰为 n 的序列进行 n 点离散傅里叶变换，与GM/T 0005-2021、NIST SP 800-22中的公式假设一致。
+//
+// 与 DiscreteFourierTransformTest（补零模式）的区别：
+//
+// 补零模式将序列补零至 ceilPow2(n) 个点后进行基2 FFT，得到的是序列在 ceilPow2(n) 个等分频点上的频谱，
+// 统计的前 n/2-1 个频点只覆盖了 [0, n/(2*ceilPow2(n))) 的频带，且相邻频点之间存在相关性。
+// 例如 n = 10^6 时补零至 2^20，覆盖约 95% 的半频带；n = 10^8 时补零至 2^27，仅覆盖约 75% 的半频带。
+// 精确模式统计的是 n 点DFT的前 n/2-1 个频点，正好覆盖整个半频带，各频点在随机性假设下相互独立。
+//
+// 对于随机序列两种模式的P值分布均近似均匀，但对于具体序列两者的结果并不相同；
+// 在数据长度为2的幂次时两种模式结果一致。
+// 长度只含 2、3、5、7 因子时（如 2*10^4、10^6、10^8）使用混合基FFT，其他长度使用Bluestein算法。
+//
+// n 为偶数时使用实数输入FFT原地变换，10^8 比特序列的变换缓冲区约 800MB。
+func DiscreteFourierTransformExactTest(bits []bool) (float64, float64) {
+	return discreteFourierTransformExact(func(i int) bool { return bits[i] }, len(bits), false)
+}
+
+// discreteFourierTransformExact 精确长度模式的离散傅里叶检测实现
+// bit: 第 i 个比特
+// n: 序列长度
+// single: 是否使用单精度（float32）变换
+func discreteFourierTransformExact(bit func(i int) bool, n int, single bool) (float64, float64) {
+	if n == 0 {
+		panic("please provide test bits")
+	}
+
+	// Step 1 - 6
+	var N_1 int
+	if single && n%2 == 0 && n >= 4 {
+		N_1 = dftRealCount(bit, n, n, true)
+	} else {
+		power := dftExactPower(bit, n)
+		T_squared := 2.995732274 * float64(n)
+		limit := n/2 - 1
+		for j := 0; j < limit; j++ {
+			if power(j) < T_squared {
+				N_1++
+			}
 		}
 	}
 
-	// Step 7 - 预计算分母
-	denominator := math.Sqrt(0.95 * 0.05 * float64(2.0*n) / 3.8)
-	V := (float64(N_1) - N_0) / denominator
+	// Step 5
+	N_0 := 0.95 * float64(n) / 2
+
+	// Step 7
+	V := (float64(N_1) - N_0) / math.Sqrt(0.95*0.05*float64(2.0*n)/3.8)
 	P := math.Erfc(math.Abs(V))
 	Q := math.Erfc(V) / 2
 
 	return P, Q
 }
+
+// dftExactPower 对 ±1 序列进行 n 点双精度离散傅里叶变换
+// 返回第 j 个频点模的平方 |f_j|^2，0 <= j < n/2
+func dftExactPower(bit func(i int) bool, n int) func(j int) float64 {
+	return dftPower(bit, n, n)
+}
+
+// dftPower 对 ±1 序列（补零至 N 点）进行双精度离散傅里叶变换
+// 返回第 j 个频点模的平方 |f_j|^2，0 <= j < N/2
+func dftPower(bit func(i int) bool, n, N int) func(j int) float64 {
+	if N%2 == 0 && N >= 4 {
+		z := dftRealTransform(bit, n, N)
+		return func(j int) float64 {
+			if j == 0 {
+				return real(z[0]) * real(z[0])
+			}
+			return real(z[j])*real(z[j]) + imag(z[j])*imag(z[j])
+		}
+	}
+
+	// 奇数长度使用复数FFT
+	rr := make([]complex128, N)
+	for i := 0; i < n; i++ {
+		if bit(i) {
+			rr[i] = complex(1.0, 0)
+		} else {
+			rr[i] = complex(-1.0, 0)
+		}
+	}
+	f, err := getFFTPlan(N)
+	if err != nil {
+		panic(err)
+	}
+	f.Transform(rr)
+	return func(j int) float64 {
+		return real(rr[j])*real(rr[j]) + imag(rr[j])*imag(rr[j])
+	}
+}
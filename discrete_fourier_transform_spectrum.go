@@ -0,0 +1,250 @@
+// Copyright (c) 2021 Quan guanyu
+// randomness is licensed under Mulan PSL v2.
+// You can use this software according to the terms and conditions of the Mulan PSL v2.
+// You may obtain a copy of Mulan PSL v2 at:
+//          http://license.coscl.org.cn/MulanPSL2
+// THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND,
+// EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT,
+// MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+// See the Mulan PSL v2 for more details.
+
+package randomness
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+)
+
+// DFTPeak 离散傅里叶检测中模值超过门限 T 的频点
+type DFTPeak struct {
+	Index   int     // 频点序号 j
+	Modulus float64 // 模值 |f_j|
+	Period  float64 // 对应的周期（比特），变换长度/j，直流分量（j=0）记为0
+}
+
+// DFTSpectrum 离散傅里叶检测频谱诊断信息
+type DFTSpectrum struct {
+	N          int       // 序列长度 n
+	Length     int       // 变换长度，补零模式为 ceilPow2(n)，精确长度模式为 n
+	T          float64   // 门限值 T
+	Decimation int       // 抽取因子 D，Modulus[i] 为频点 [i*D, (i+1)*D) 内的最大模值
+	Modulus    []float64 // 前 n/2-1 个频点的模值（抽取后）
+	Peaks      []DFTPeak // 模值超过门限的频点，按模值降序排列
+	PeakCount  int       // 模值超过门限的频点总数（Peaks 可能被截断）
+	N0         float64   // 期望的低于门限的频点数 N_0
+	N1         int       // 实际低于门限的频点数 N_1
+	P          float64   // 检测结果P_value
+	Q          float64   // 检测结果Q_value
+}
+
+// DiscreteFourierTransformSpectrumBytes 离散傅里叶检测频谱诊断
+// data: 待检测序列
+// points: 频谱最多保留的点数，<=0 时保留全部频点
+// maxPeaks: 最多保留的峰值个数，<=0 时保留全部峰值
+func DiscreteFourierTransformSpectrumBytes(data []byte, points, maxPeaks int) *DFTSpectrum {
+	n := len(data) * 8
+	return discreteFourierTransformSpectrum(func(i int) bool { return data[i>>3]&(0x80>>uint(i&7)) != 0 }, n, ceilPow2(n), points, maxPeaks)
+}
+
+// DiscreteFourierTransformSpectrum 离散傅里叶检测频谱诊断
+// 与 DiscreteFourierTransformTest 相同，将序列补零至 ceilPow2(n) 点后计算频谱，返回频谱模值、门限 T、
+// 超过门限的峰值及其对应的周期，用于定位检测未通过时造成异常的频率，如环形振荡器TRNG中的时钟耦合。
+// 精确长度模式的检测结果使用 DiscreteFourierTransformExactSpectrum 诊断。
+//
+// 频谱较长时可通过 points 对其抽取，每个抽取点取对应区间内的最大模值，以保留峰值。
+//
+// bits: 待检测序列
+// points: 频谱最多保留的点数，<=0 时保留全部频点
+// maxPeaks: 最多保留的峰值个数，<=0 时保留全部峰值
+func DiscreteFourierTransformSpectrum(bits []bool, points, maxPeaks int) *DFTSpectrum {
+	return discreteFourierTransformSpectrum(func(i int) bool { return bits[i] }, len(bits), ceilPow2(len(bits)), points, maxPeaks)
+}
+
+// DiscreteFourierTransformExactSpectrumBytes 离散傅里叶检测频谱诊断（精确长度模式）
+func DiscreteFourierTransformExactSpectrumBytes(data []byte, points, maxPeaks int) *DFTSpectrum {
+	n := len(data) * 8
+	return discreteFourierTransformSpectrum(func(i int) bool { return data[i>>3]&(0x80>>uint(i&7)) != 0 }, n, n, points, maxPeaks)
+}
+
+// DiscreteFourierTransformExactSpectrum 离散傅里叶检测频谱诊断（精确长度模式）
+// 与 DiscreteFourierTransformExactTest 相同，计算 n 点频谱，参数与返回值同 DiscreteFourierTransformSpectrum。
+func DiscreteFourierTransformExactSpectrum(bits []bool, points, maxPeaks int) *DFTSpectrum {
+	return discreteFourierTransformSpectrum(func(i int) bool { return bits[i] }, len(bits), len(bits), points, maxPeaks)
+}
+
+// discreteFourierTransformSpectrum 频谱诊断实现
+// bit: 第 i 个比特
+// n: 序列长度
+// N: 变换长度，N >= n
+func discreteFourierTransformSpectrum(bit func(i int) bool, n, N, points, maxPeaks int) *DFTSpectrum {
+	if n < 4 {
+		panic("please provide valid test bits")
+	}
+	power := dftPower(bit, n, N)
+
+	limit := n/2 - 1
+	T := math.Sqrt(2.995732274 * float64(n))
+	T_squared := T * T
+	D := 1
+	if points > 0 && limit > points {
+		D = (limit + points - 1) / points
+	}
+
+	s := &DFTSpectrum{
+		N:          n,
+		Length:     N,
+		T:          T,
+		Decimation: D,
+		Modulus:    make([]float64, (limit+D-1)/D),
+		N0:         0.95 * float64(n) / 2,
+	}
+	for j := 0; j < limit; j++ {
+		pw := power(j)
+		m := math.Sqrt(pw)
+		if m > s.Modulus[j/D] {
+			s.Modulus[j/D] = m
+		}
+		if pw < T_squared {
+			s.N1++
+			continue
+		}
+		s.Peaks = append(s.Peaks, DFTPeak{Index: j, Modulus: m, Period: s.period(j)})
+	}
+	s.PeakCount = len(s.Peaks)
+	sort.SliceStable(s.Peaks, func(i, j int) bool { return s.Peaks[i].Modulus > s.Peaks[j].Modulus })
+	if maxPeaks > 0 && len(s.Peaks) > maxPeaks {
+		s.Peaks = s.Peaks[:maxPeaks]
+	}
+
+	V := (float64(s.N1) - s.N0) / math.Sqrt(0.95*0.05*float64(2.0*n)/3.8)
+	s.P = math.Erfc(math.Abs(V))
+	s.Q = math.Erfc(V) / 2
+	return s
+}
+
+// WriteCSV 以CSV格式输出频谱
+// 列：频点序号（抽取区间起点）、频率（周期/比特）、周期（比特）、模值、是否超过门限
+func (s *DFTSpectrum) WriteCSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write([]string{"频点", "频率", "周期(比特)", "模值", "超过门限"}); err != nil {
+		return err
+	}
+	for i, m := range s.Modulus {
+		j := i * s.Decimation
+		record := []string{
+			strconv.Itoa(j),
+			strconv.FormatFloat(float64(j)/float64(s.Length), 'g', 8, 64),
+			strconv.FormatFloat(s.period(j), 'f', 3, 64),
+			strconv.FormatFloat(m, 'f', 4, 64),
+			strconv.FormatBool(m >= s.T),
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// WritePeaksCSV 以CSV格式输出超过门限的峰值
+// 列：频点序号、频率（周期/比特）、周期（比特）、模值、模值/门限
+func (s *DFTSpectrum) WritePeaksCSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write([]string{"频点", "频率", "周期(比特)", "模值", "模值/门限"}); err != nil {
+		return err
+	}
+	for _, p := range s.Peaks {
+		record := []string{
+			strconv.Itoa(p.Index),
+			strconv.FormatFloat(float64(p.Index)/float64(s.Length), 'g', 8, 64),
+			strconv.FormatFloat(p.Period, 'f', 3, 64),
+			strconv.FormatFloat(p.Modulus, 'f', 4, 64),
+			strconv.FormatFloat(p.Modulus/s.T, 'f', 4, 64),
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// WriteSVG 以SVG图像输出频谱
+// 横轴为频点序号，纵轴为模值，红色虚线为门限 T，并标注模值最大的若干峰值的周期。
+func (s *DFTSpectrum) WriteSVG(w io.Writer) error {
+	const (
+		width, height = 1000, 400
+		left, right   = 70, 20
+		top, bottom   = 30, 50
+		plotW         = width - left - right
+		plotH         = height - top - bottom
+		labeledPeaks  = 5
+	)
+	maxM := s.T
+	for _, m := range s.Modulus {
+		if m > maxM {
+			maxM = m
+		}
+	}
+	maxM *= 1.05
+	bins := len(s.Modulus) * s.Decimation
+	x := func(j int) float64 { return left + float64(j)/float64(bins)*plotW }
+	y := func(m float64) float64 { return top + plotH - m/maxM*plotH }
+
+	var err error
+	printf := func(format string, a ...interface{}) {
+		if err == nil {
+			_, err = fmt.Fprintf(w, format, a...)
+		}
+	}
+
+	printf(`<?xml version="1.0" encoding="UTF-8"?>` + "\n")
+	printf(`<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" font-family="sans-serif" font-size="12">`+"\n", width, height, width, height)
+	printf(`<rect width="%d" height="%d" fill="white"/>`+"\n", width, height)
+	printf(`<text x="%d" y="18">离散傅里叶检测频谱 n=%d 变换长度=%d T=%.2f N1=%d N0=%.1f P=%.6f</text>`+"\n", left, s.N, s.Length, s.T, s.N1, s.N0, s.P)
+
+	// 坐标轴
+	printf(`<line x1="%d" y1="%d" x2="%d" y2="%d" stroke="black"/>`+"\n", left, top, left, top+plotH)
+	printf(`<line x1="%d" y1="%d" x2="%d" y2="%d" stroke="black"/>`+"\n", left, top+plotH, left+plotW, top+plotH)
+	for i := 0; i <= 4; i++ {
+		m := maxM * float64(i) / 4
+		printf(`<text x="%d" y="%.1f" text-anchor="end">%.0f</text>`+"\n", left-5, y(m)+4, m)
+		j := bins * i / 4
+		printf(`<text x="%.1f" y="%d" text-anchor="middle">%d</text>`+"\n", x(j), top+plotH+18, j)
+	}
+	printf(`<text x="%d" y="%d" text-anchor="middle">频点 j</text>`+"\n", left+plotW/2, height-8)
+
+	// 频谱
+	printf(`<polyline fill="none" stroke="steelblue" stroke-width="1" points="`)
+	for i, m := range s.Modulus {
+		printf("%.1f,%.1f ", x(i*s.Decimation), y(m))
+	}
+	printf(`"/>` + "\n")
+
+	// 门限
+	printf(`<line x1="%d" y1="%.1f" x2="%d" y2="%.1f" stroke="red" stroke-dasharray="6,4"/>`+"\n", left, y(s.T), left+plotW, y(s.T))
+	printf(`<text x="%d" y="%.1f" fill="red" text-anchor="end">T</text>`+"\n", left+plotW, y(s.T)-4)
+
+	// 峰值标注
+	for i, p := range s.Peaks {
+		if i >= labeledPeaks {
+			break
+		}
+		printf(`<circle cx="%.1f" cy="%.1f" r="3" fill="red"/>`+"\n", x(p.Index), y(p.Modulus))
+		printf(`<text x="%.1f" y="%.1f" fill="red">周期 %.2f</text>`+"\n", x(p.Index)+5, y(p.Modulus)+4, p.Period)
+	}
+	printf("</svg>\n")
+	return err
+}
+
+// period 频点 j 对应的周期（比特）
+func (s *DFTSpectrum) period(j int) float64 {
+	if j == 0 {
+		return 0
+	}
+	return float64(s.Length) / float64(j)
+}
@@ -0,0 +1,114 @@
+package randomness
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestDiscreteFourierTransformSpectrumSample(t *testing.T) {
+	s := DiscreteFourierTransformSpectrum(sampleTestBits100, 0, 0)
+	p, q := DiscreteFourierTransformTest(sampleTestBits100)
+	fmt.Printf("n: %v, P-value: %f, Q-value: %f, peaks: %d\n", s.N, s.P, s.Q, s.PeakCount)
+	if s.P != p || s.Q != q {
+		t.Errorf("P/Q 与检测结果不一致: %f/%f %f/%f", s.P, p, s.Q, q)
+	}
+	if s.Length != 128 || len(s.Modulus) != len(sampleTestBits100)/2-1 || s.Decimation != 1 {
+		t.Errorf("频谱长度错误: %d %d", s.Length, len(s.Modulus))
+	}
+	if s.N1+s.PeakCount != len(s.Modulus) {
+		t.Errorf("N1 + 峰值数 != 频点数")
+	}
+
+	s = DiscreteFourierTransformExactSpectrum(sampleTestBits100, 0, 0)
+	p, q = DiscreteFourierTransformExactTest(sampleTestBits100)
+	if s.P != p || s.Q != q || s.Length != len(sampleTestBits100) {
+		t.Errorf("精确长度模式 P/Q 与检测结果不一致: %f/%f %f/%f", s.P, p, s.Q, q)
+	}
+}
+
+// TestDiscreteFourierTransformSpectrumMatchesTest 频谱诊断与检测使用相同的变换
+func TestDiscreteFourierTransformSpectrumMatchesTest(t *testing.T) {
+	buf := make([]byte, SmallScale/8)
+	for i := range buf {
+		buf[i] = byte(i*131) ^ byte(i>>3)
+	}
+	s := DiscreteFourierTransformSpectrumBytes(buf, 0, 0)
+	p, q := DiscreteFourierTransformTestBytes(buf)
+	if s.P != p || s.Q != q || s.Length != ceilPow2(SmallScale) {
+		t.Errorf("补零模式: %f/%f %f/%f", s.P, p, s.Q, q)
+	}
+	s = DiscreteFourierTransformExactSpectrumBytes(buf, 0, 0)
+	p, q = DiscreteFourierTransformExactTestBytes(buf)
+	if s.P != p || s.Q != q || s.Length != SmallScale {
+		t.Errorf("精确长度模式: %f/%f %f/%f", s.P, p, s.Q, q)
+	}
+}
+
+// TestDiscreteFourierTransformSpectrumPeriod 周期信号的峰值应位于对应周期
+func TestDiscreteFourierTransformSpectrumPeriod(t *testing.T) {
+	n := SmallScale
+	bits := generateTestDataForDFT(n)
+	// 每 40 比特重复注入一段固定模式，模拟时钟耦合
+	for i := 0; i+4 <= n; i += 40 {
+		bits[i], bits[i+1], bits[i+2], bits[i+3] = true, true, true, true
+	}
+	// n 点频谱中周期 40 恰好落在频点 n/40 上
+	s := DiscreteFourierTransformExactSpectrum(bits, 1000, 10)
+	if len(s.Modulus) > 1000 || len(s.Peaks) > 10 {
+		t.Fatalf("抽取或截断无效: %d %d", len(s.Modulus), len(s.Peaks))
+	}
+	if s.P >= Alpha {
+		t.Errorf("周期序列应未通过检测, P=%f", s.P)
+	}
+	// 最大的非直流峰值的周期应为 40 的约数
+	for _, p := range s.Peaks {
+		if p.Index == 0 {
+			continue
+		}
+		r := 40 / p.Period
+		if math.Abs(r-math.Round(r)) > 1e-9 {
+			t.Errorf("峰值周期 %f 不是 40 的约数", p.Period)
+		}
+		break
+	}
+}
+
+func TestDiscreteFourierTransformSpectrumExport(t *testing.T) {
+	buf := make([]byte, 2500)
+	for i := range buf {
+		buf[i] = byte(i * 131)
+	}
+	s := DiscreteFourierTransformSpectrumBytes(buf, 500, 20)
+
+	var out bytes.Buffer
+	if err := s.WriteCSV(&out); err != nil {
+		t.Fatal(err)
+	}
+	records, err := csv.NewReader(&out).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != len(s.Modulus)+1 {
+		t.Errorf("CSV 行数错误: %d", len(records))
+	}
+
+	out.Reset()
+	if err := s.WritePeaksCSV(&out); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Count(out.String(), "\n") != len(s.Peaks)+1 {
+		t.Errorf("峰值CSV 行数错误")
+	}
+
+	out.Reset()
+	if err := s.WriteSVG(&out); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(out.String(), "<?xml") || !strings.Contains(out.String(), "</svg>") {
+		t.Errorf("SVG 格式错误")
+	}
+}
@@ -290,3 +290,104 @@ func TestDiscreteFourierTransformPerformanceComparison(t *testing.T) {
 		})
 	}
 }
+
+func TestDiscreteFourierTransformExactTestSample(t *testing.T) {
+	p, q := DiscreteFourierTransformExactTest(sampleTestBits100)
+	fmt.Printf("n: %v, P-value: %f, Q-value: %f\n", len(sampleTestBits100), p, q)
+	if fmt.Sprintf("%.6f", p) != "0.654721" || fmt.Sprintf("%.6f", q) != "0.672640" {
+		t.FailNow()
+	}
+}
+
+// TestDiscreteFourierTransformExactPow2 长度为2的幂次时精确模式与补零模式结果一致
+func TestDiscreteFourierTransformExactPow2(t *testing.T) {
+	for _, size := range []int{1 << 10, 1 << 16} {
+		bits := generateTestDataForDFT(size)
+		pPad, qPad := DiscreteFourierTransformTest(bits)
+		pExact, qExact := DiscreteFourierTransformExactTest(bits)
+		const epsilon = 1e-10
+		if math.Abs(pPad-pExact) > epsilon || math.Abs(qPad-qExact) > epsilon {
+			t.Errorf("n=%d 补零模式 P=%f Q=%f 精确模式 P=%f Q=%f", size, pPad, qPad, pExact, qExact)
+		}
+	}
+}
+
+// TestDiscreteFourierTransformExactVsPadded 对比GMT 0005-2021规模下精确模式与补零模式
+func TestDiscreteFourierTransformExactVsPadded(t *testing.T) {
+	for _, size := range []int{SmallScale, MediumScale} {
+		t.Run(fmt.Sprintf("Size_%d", size), func(t *testing.T) {
+			bits := generateTestDataForDFT(size)
+			pPad, qPad := DiscreteFourierTransformTest(bits)
+			pExact, qExact := DiscreteFourierTransformExactTest(bits)
+			t.Logf("补零模式 N=%d: P=%.6f, Q=%.6f", ceilPow2(size), pPad, qPad)
+			t.Logf("精确模式 N=%d: P=%.6f, Q=%.6f", size, pExact, qExact)
+			if pExact < 0 || pExact > 1 || qExact < 0 || qExact > 1 {
+				t.Errorf("Invalid P or Q values: P=%f, Q=%f", pExact, qExact)
+			}
+		})
+	}
+
+	// 周期为 10 的序列在精确模式下频谱能量集中于单一频点
+	bits := make([]bool, SmallScale)
+	for i := range bits {
+		bits[i] = i%10 < 5
+	}
+	if p, _ := DiscreteFourierTransformExactTest(bits); p >= Alpha {
+		t.Errorf("周期序列应未通过检测, P=%f", p)
+	}
+}
+
+// TestDiscreteFourierTransformExactBytes 字节版本、单精度版本与比特版本一致
+func TestDiscreteFourierTransformExactBytes(t *testing.T) {
+	buf := make([]byte, MediumScale/8)
+	rand.Read(buf)
+	bits := B2bitArr(buf)
+
+	p, q := DiscreteFourierTransformExactTest(bits)
+	pb, qb := DiscreteFourierTransformExactTestBytes(buf)
+	if p != pb || q != qb {
+		t.Errorf("字节版本结果不一致: P=%f/%f Q=%f/%f", p, pb, q, qb)
+	}
+
+	p32, q32 := DiscreteFourierTransformExactTestFloat32(bits)
+	t.Logf("双精度: P=%.6f Q=%.6f 单精度: P=%.6f Q=%.6f", p, q, p32, q32)
+	if math.Abs(p-p32) > 0.01 || math.Abs(q-q32) > 0.01 {
+		t.Errorf("单精度结果差异过大: P=%f/%f Q=%f/%f", p, p32, q, q32)
+	}
+	if pb32, qb32 := DiscreteFourierTransformExactTestBytesFloat32(buf); pb32 != p32 || qb32 != q32 {
+		t.Errorf("单精度字节版本结果不一致")
+	}
+}
+
+// TestDiscreteFourierTransformExactOdd 奇数长度使用复数FFT
+func TestDiscreteFourierTransformExactOdd(t *testing.T) {
+	bits := generateTestDataForDFT(10001)
+	p, q := DiscreteFourierTransformExactTest(bits)
+	if p < 0 || p > 1 || q < 0 || q > 1 {
+		t.Errorf("Invalid P or Q values: P=%f, Q=%f", p, q)
+	}
+}
+
+// TestDiscreteFourierTransformConcurrent 多个协程共享缓存的FFT表并行检测，结果与串行一致
+func TestDiscreteFourierTransformConcurrent(t *testing.T) {
+	bits := generateTestDataForDFT(MediumScale)
+	p, q := DiscreteFourierTransformExactTest(bits)
+
+	workers := DFTWorkers
+	defer func() { DFTWorkers = workers }()
+	DFTWorkers = 4
+
+	results := make(chan [2]float64, 4)
+	for i := 0; i < 4; i++ {
+		go func() {
+			p, q := DiscreteFourierTransformExactTest(bits)
+			results <- [2]float64{p, q}
+		}()
+	}
+	for i := 0; i < 4; i++ {
+		r := <-results
+		if math.Abs(r[0]-p) > 1e-10 || math.Abs(r[1]-q) > 1e-10 {
+			t.Errorf("并行结果不一致: P=%f/%f Q=%f/%f", p, r[0], q, r[1])
+		}
+	}
+}
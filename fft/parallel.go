@@ -0,0 +1,183 @@
+package fft
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// parallelMin is the smallest length for which TransformParallel uses the
+// four-step algorithm. Shorter transforms are computed serially.
+const parallelMin = 1 << 15
+
+// columnBlock is the number of adjacent columns gathered together in the
+// first step, so that every row access reads whole cache lines.
+const columnBlock = 16
+
+// fourStep is the four-step decomposition N = N1*N2 of a mixed-radix Plan.
+//
+// With the input viewed as an N1 x N2 row-major matrix, x[N2*n1 + n2]:
+//
+//  1. transform the N2 columns (N1 points each) and multiply the result
+//     at (k1, n2) by exp(-2πi n2*k1/N),
+//  2. transform the N1 rows (N2 points each),
+//  3. transpose the N1 x N2 matrix in-place, giving X[k1 + N1*k2].
+//
+// N1 and N2 are close to sqrt(N), so each sub-transform fits into the cache
+// and the sub-transforms of a step are independent of each other.
+type fourStep struct {
+	N1, N2 int
+	cols   Plan // Column transform, N1 points.
+	rows   Plan // Row transform, N2 points.
+}
+
+func newFourStep(N int, factors []int) (*fourStep, error) {
+	N1 := 1
+	for _, f := range factors {
+		if N1*N1 >= N {
+			break
+		}
+		N1 *= f
+	}
+	N2 := N / N1
+	cols, err := NewPlan(N1)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := NewPlan(N2)
+	if err != nil {
+		return nil, err
+	}
+	return &fourStep{N1: N1, N2: N2, cols: cols, rows: rows}, nil
+}
+
+// TransformParallel is the forward transform using up to workers goroutines.
+// workers <= 0 uses runtime.NumCPU().
+// The forward transform overwrites the input array.
+//
+// Large mixed-radix lengths use the four-step algorithm, whose sub-transforms
+// are cache-sized and distributed over the goroutines. The result equals
+// Transform within floating-point tolerance. Because of the better cache
+// locality the four-step algorithm is faster than Transform even with a
+// single worker. Short lengths and lengths which need Bluestein's algorithm
+// fall back to Transform.
+func (p Plan) TransformParallel(x []complex128, workers int) []complex128 {
+	if len(x) != p.N {
+		panic("Input dimension mismatches: Plan is not initialized, or called with wrong input.")
+	}
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	if p.four == nil {
+		return p.Transform(x)
+	}
+	f := p.four
+	N1, N2 := f.N1, f.N2
+
+	// Step 1: column transforms and twiddles, columnBlock columns at a time.
+	bufs := make([][]complex128, workers)
+	parallelFor((N2+columnBlock-1)/columnBlock, workers, func(w, blk int) {
+		if bufs[w] == nil {
+			bufs[w] = make([]complex128, columnBlock*N1)
+		}
+		c0 := blk * columnBlock
+		c1 := c0 + columnBlock
+		if c1 > N2 {
+			c1 = N2
+		}
+		nc := c1 - c0
+		buf := bufs[w]
+		for n1 := 0; n1 < N1; n1++ {
+			row := x[n1*N2+c0 : n1*N2+c1]
+			for c, v := range row {
+				buf[c*N1+n1] = v
+			}
+		}
+		for c := 0; c < nc; c++ {
+			col := buf[c*N1 : (c+1)*N1]
+			f.cols.Transform(col)
+			n2 := c0 + c
+			for k1 := 1; k1 < N1; k1++ {
+				col[k1] *= p.tw.at(n2 * k1)
+			}
+		}
+		for k1 := 0; k1 < N1; k1++ {
+			row := x[k1*N2+c0 : k1*N2+c1]
+			for c := range row {
+				row[c] = buf[c*N1+k1]
+			}
+		}
+	})
+
+	// Step 2: row transforms.
+	parallelFor(N1, workers, func(_, k1 int) {
+		f.rows.Transform(x[k1*N2 : (k1+1)*N2])
+	})
+
+	// Step 3: in-place transposition, X[k1 + N1*k2] = x[N2*k1 + k2].
+	if N1 != 1 && N2 != 1 {
+		M := p.N - 1
+		permute(x, func(d int) int {
+			if d == M {
+				return M
+			}
+			return int(int64(d) * int64(N2) % int64(M))
+		})
+	}
+	return x
+}
+
+// TransformParallel is the forward transform of packed real input using up
+// to workers goroutines, see Plan.TransformParallel.
+// The forward transform overwrites the input array.
+func (p RealPlan) TransformParallel(z []complex128, workers int) []complex128 {
+	if len(z) != p.N/2 {
+		panic("Input dimension mismatches: RealPlan is not initialized, or called with wrong input.")
+	}
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	p.half.TransformParallel(z, workers)
+	M := p.N / 2
+	z[0] = complex(real(z[0])+imag(z[0]), real(z[0])-imag(z[0]))
+	// The pairs (k, M-k) are independent, split them into chunks.
+	const chunk = 1 << 14
+	parallelFor((M/2+chunk-1)/chunk, workers, func(_, c int) {
+		k0 := c*chunk + 1
+		k1 := k0 + chunk
+		if k1 > M/2+1 {
+			k1 = M/2 + 1
+		}
+		for k := k0; k < k1; k++ {
+			e, wo := p.split(k, z[k], z[M-k])
+			z[k] = e + wo
+			z[M-k] = conj(e - wo)
+		}
+	})
+	return z
+}
+
+// parallelFor calls fn(w, i) for i in [0, n) on up to workers goroutines,
+// where w in [0, workers) identifies the calling goroutine.
+// Indexes are handed out dynamically to balance the load.
+func parallelFor(n, workers int, fn func(w, i int)) {
+	if workers > n {
+		workers = n
+	}
+	var next int64 = -1
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for w := 0; w < workers; w++ {
+		go func(w int) {
+			defer wg.Done()
+			for {
+				i := int(atomic.AddInt64(&next, 1))
+				if i >= n {
+					return
+				}
+				fn(w, i)
+			}
+		}(w)
+	}
+	wg.Wait()
+}
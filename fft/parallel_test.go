@@ -0,0 +1,71 @@
+package fft
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+)
+
+func TestPlanTransformParallel(t *testing.T) {
+	for _, N := range []int{1 << 15, 1 << 16, 20000 * 5, 1000000, 3 * 5 * 7 * 1024} {
+		t.Run(fmt.Sprintf("N_%d", N), func(t *testing.T) {
+			p, err := NewPlan(N)
+			if err != nil {
+				t.Fatal(err)
+			}
+			x := randomInput(N)
+			want := p.Transform(append([]complex128(nil), x...))
+			for _, workers := range []int{2, 3, 8} {
+				got := p.TransformParallel(append([]complex128(nil), x...), workers)
+				if d := maxDiff(got, want); d > 1e-9 {
+					t.Errorf("workers=%d max diff %g", workers, d)
+				}
+			}
+		})
+	}
+}
+
+func TestRealPlanTransformParallel(t *testing.T) {
+	N := 2000000
+	p, err := NewRealPlan(N)
+	if err != nil {
+		t.Fatal(err)
+	}
+	z := make([]complex128, N/2)
+	for k := range z {
+		z[k] = complex(float64(rand.Intn(2)*2-1), float64(rand.Intn(2)*2-1))
+	}
+	want := p.Transform(append([]complex128(nil), z...))
+	got := p.TransformParallel(z, 4)
+	if d := maxDiff(got, want); d > 1e-8 {
+		t.Errorf("max diff %g", d)
+	}
+}
+
+func TestParallelFor(t *testing.T) {
+	seen := make([]int32, 1000)
+	parallelFor(len(seen), 7, func(_, i int) { seen[i]++ })
+	for i, n := range seen {
+		if n != 1 {
+			t.Fatalf("index %d visited %d times", i, n)
+		}
+	}
+}
+
+func BenchmarkPlanTransform1E8(b *testing.B) {
+	p, _ := NewPlan(100000000 / 2)
+	x := make([]complex128, p.N)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		p.Transform(x)
+	}
+}
+
+func BenchmarkPlanTransformParallel1E8(b *testing.B) {
+	p, _ := NewPlan(100000000 / 2)
+	x := make([]complex128, p.N)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		p.TransformParallel(x, 0)
+	}
+}
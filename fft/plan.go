@@ -0,0 +1,505 @@
+package fft
+
+import (
+	"fmt"
+	"math"
+)
+
+// Plan kinds, selected by the factorization of N.
+const (
+	kindMixed     = iota // N has only small prime factors, mixed-radix Cooley-Tukey.
+	kindBluestein        // Any other N, Bluestein's chirp-z algorithm.
+)
+
+// maxRadix is the largest prime factor handled by the mixed-radix algorithm.
+// Lengths with a larger prime factor use Bluestein's algorithm.
+const maxRadix = 7
+
+// stageTableMax is the largest per-stage twiddle table (in entries) which is
+// precomputed for a mixed-radix stage. Larger stages compute their twiddles
+// on the fly from the compact roots table.
+const stageTableMax = 1 << 16
+
+// permTableMax is the largest length for which the digit-reversal permutation
+// is precomputed as an index table. Larger lengths compute it on the fly.
+const permTableMax = 1 << 20
+
+// Plan is a discrete Fourier transformation of arbitrary length N.
+//
+// Lengths whose prime factors are all <= 7 (powers of 2, 10^6 = 2^6*5^6,
+// 10^8 = 2^8*5^8, ...) are transformed by an in-place mixed-radix Cooley-Tukey
+// algorithm. Every other length falls back to Bluestein's algorithm, which
+// evaluates the N-point DFT as a convolution with a power of 2 FFT of
+// length >= 2N-1.
+//
+// Unlike FFT, a mixed-radix Plan keeps no length-N tables: the roots of 1 are
+// stored in a compact two-level table of about 2*sqrt(N) entries and the
+// input permutation is applied in-place, so the memory used by a transform
+// is essentially the input vector itself.
+//
+// A Plan is read-only after creation and can be shared by multiple goroutines.
+type Plan struct {
+	N       int        // Transform length.
+	kind    int        // One of kindMixed, kindBluestein.
+	factors []int      // Radices of the mixed-radix stages, in processing order.
+	tw      *twiddles  // Roots of 1 of order N, kindMixed only.
+	stages  []stage    // Precomputed stage tables, kindMixed only.
+	perm    []int32    // Digit-reversal source index table, kindMixed with N <= permTableMax only.
+	four    *fourStep  // Four-step decomposition for TransformParallel, large kindMixed only.
+	blue    *bluestein // Bluestein state, kindBluestein only.
+}
+
+// stage holds the precomputed tables of one mixed-radix stage.
+type stage struct {
+	r, L int          // Radix and length of the combined sub-transforms.
+	wr   []complex128 // The r roots of 1 of order r.
+	w    []complex128 // Twiddles w[j*(r-1)+q-1] = exp(-2πi q*j/(r*L)), nil if larger than stageTableMax.
+}
+
+// NewPlan allocates a Plan for input vectors of exactly N points.
+func NewPlan(N int) (p Plan, err error) {
+	if N < 2 {
+		return p, fmt.Errorf("fft input length must be >= 2")
+	} else if N > maxDim() {
+		return p, fmt.Errorf("fft input length must be < %d. It is: %d", maxDim(), N)
+	}
+	p.N = N
+	if factors, ok := factorize(N); ok {
+		p.kind = kindMixed
+		p.factors = factors
+		p.tw = newTwiddles(N)
+		p.stages = newStages(N, factors, p.tw)
+		if N <= permTableMax {
+			src := digitReversalSource(N, factors)
+			p.perm = make([]int32, N)
+			for i := range p.perm {
+				p.perm[i] = int32(src(i))
+			}
+		}
+		if N >= parallelMin {
+			p.four, err = newFourStep(N, factors)
+		}
+		return p, err
+	}
+	p.kind = kindBluestein
+	p.blue, err = newBluestein(N)
+	return p, err
+}
+
+// Transform Forward transform.
+// The forward transform overwrites the input array.
+func (p Plan) Transform(x []complex128) []complex128 {
+	if len(x) != p.N {
+		panic("Input dimension mismatches: Plan is not initialized, or called with wrong input.")
+	}
+	if p.kind == kindBluestein {
+		return p.blue.transform(x)
+	}
+	digitReversal(x, p.factors, p.perm)
+	mixedRadix(x, p.stages, p.tw)
+	return x
+}
+
+// Transform64 is the forward transform in single precision.
+// The forward transform overwrites the input array.
+//
+// Twiddle factors are computed in double precision and rounded once,
+// see Float32ErrorBound for the resulting accuracy. Lengths which need
+// Bluestein's algorithm are computed in double precision internally.
+func (p Plan) Transform64(x []complex64) []complex64 {
+	if len(x) != p.N {
+		panic("Input dimension mismatches: Plan is not initialized, or called with wrong input.")
+	}
+	if p.kind == kindBluestein {
+		y := make([]complex128, p.N)
+		for i, v := range x {
+			y[i] = complex128(v)
+		}
+		p.blue.transform(y)
+		for i, v := range y {
+			x[i] = complex64(v)
+		}
+		return x
+	}
+	digitReversal64(x, p.factors, p.perm)
+	mixedRadix64(x, p.stages, p.tw)
+	return x
+}
+
+// Inverse is the backwards transform.
+func (p Plan) Inverse(x []complex128) []complex128 {
+	if len(x) != p.N {
+		panic("Plan is not initialized, or called with wrong input. Input dimension mismatches.")
+	}
+	// ifft(x) = conj(fft(conj(x))) / N
+	for i := range x {
+		x[i] = complex(real(x[i]), -imag(x[i]))
+	}
+	p.Transform(x)
+	invN := 1.0 / float64(p.N)
+	for i := range x {
+		x[i] = complex(real(x[i])*invN, -imag(x[i])*invN)
+	}
+	return x
+}
+
+// Smooth reports whether N is transformed without Bluestein's algorithm,
+// i.e. N >= 2 has no prime factor larger than 7.
+func Smooth(N int) bool {
+	if N < 2 {
+		return false
+	}
+	_, ok := factorize(N)
+	return ok
+}
+
+// Float32ErrorBound returns an upper bound of the relative error
+// ||X' - X||_2 / ||X||_2 of a single precision transform of smooth length N,
+// following the classic bound for Cooley-Tukey FFTs (Higham, Accuracy and
+// Stability of Numerical Algorithms, Theorem 24.2):
+//
+//	log2(N) * η / (1 - log2(N) * η),  η = u + γ4 * (√2 + u),  u = 2^-24
+//
+// The typical (root mean square) error is much smaller, about u*sqrt(log2(N)).
+func Float32ErrorBound(N int) float64 {
+	u := math.Pow(2, -24)
+	gamma4 := 4 * u / (1 - 4*u)
+	eta := u + gamma4*(math.Sqrt2+u)
+	l := math.Ceil(math.Log2(float64(N)))
+	return l * eta / (1 - l*eta)
+}
+
+// factorize splits N into the radices of the mixed-radix stages.
+// Pairs of 2 are merged into radix 4 stages.
+// ok is false if N has a prime factor larger than maxRadix.
+func factorize(N int) (factors []int, ok bool) {
+	for N%4 == 0 {
+		factors = append(factors, 4)
+		N /= 4
+	}
+	for r := 2; r <= maxRadix; r++ {
+		for N%r == 0 {
+			factors = append(factors, r)
+			N /= r
+		}
+	}
+	return factors, N == 1
+}
+
+// twiddles is a compact table of the N complex roots of 1,
+//
+//	exp(-2πi k/N) = lo[k & mask] * hi[k >> shift],
+//
+// which needs about 2*sqrt(N) entries instead of N.
+type twiddles struct {
+	N     int
+	shift uint
+	mask  int
+	lo    []complex128 // exp(-2πi k/N), 0 <= k <= mask.
+	hi    []complex128 // exp(-2πi (k<<shift)/N).
+}
+
+func newTwiddles(N int) *twiddles {
+	var shift uint
+	for (1 << (2 * shift)) < N {
+		shift++
+	}
+	t := &twiddles{N: N, shift: shift, mask: 1<<shift - 1}
+	t.lo = make([]complex128, 1<<shift)
+	for k := range t.lo {
+		t.lo[k] = root(k, N)
+	}
+	t.hi = make([]complex128, N>>shift+1)
+	for k := range t.hi {
+		t.hi[k] = root(k<<shift, N)
+	}
+	return t
+}
+
+// at returns exp(-2πi k/N) for 0 <= k < N.
+func (t *twiddles) at(k int) complex128 {
+	return t.lo[k&t.mask] * t.hi[k>>t.shift]
+}
+
+// root computes exp(-2πi k/N).
+func root(k, N int) complex128 {
+	s, c := math.Sincos(-2.0 * math.Pi * float64(k) / float64(N))
+	return complex(c, s)
+}
+
+// digitReversalSource returns the function mapping an output position P
+// of the digit-reversal permutation to its input index.
+//
+// Position P with digits d_i (radix factors[i], least significant first)
+// receives the input element n = sum d_i * N/(factors[0]*...*factors[i]).
+// For radix 2 only this is the ordinary bit-reversal permutation.
+func digitReversalSource(N int, factors []int) func(P int) int {
+	weights := make([]int, len(factors))
+	w := N
+	for i, f := range factors {
+		w /= f
+		weights[i] = w
+	}
+	return func(P int) int {
+		n := 0
+		for i, f := range factors {
+			n += (P % f) * weights[i]
+			P /= f
+		}
+		return n
+	}
+}
+
+// digitReversal permutes x in-place into the mixed-radix digit-reversed order
+// needed by the decimation in time stages.
+// perm is the optional precomputed source index table of the permutation.
+func digitReversal(x []complex128, factors []int, perm []int32) {
+	if perm != nil {
+		permuteTable(x, perm)
+		return
+	}
+	permute(x, digitReversalSource(len(x), factors))
+}
+
+// permuteTable applies the permutation x'[P] = x[perm[P]] in-place, see permute.
+func permuteTable(x []complex128, perm []int32) {
+	permute(x, func(P int) int { return int(perm[P]) })
+}
+
+// permute applies the permutation x'[P] = x[src(P)] in-place by following
+// its cycles, which only needs a visited bitset of N bits instead of an
+// index table.
+func permute(x []complex128, src func(P int) int) {
+	var tmp complex128
+	followCycles(len(x), src,
+		func(start int) { tmp = x[start] },
+		func(P, s int) { x[P] = x[s] },
+		func(P int) { x[P] = tmp })
+}
+
+// followCycles walks the cycles of the permutation P -> src(P) of N elements
+// with a visited bitset. For each cycle it calls save(start) first, then
+// move(P, src(P)) along the cycle, and restore(P) for the element whose
+// source is the start of the cycle, which has already been overwritten.
+func followCycles(N int, src func(P int) int, save func(start int), move func(P, s int), restore func(P int)) {
+	visited := make([]uint64, (N+63)/64)
+	for start := 0; start < N; start++ {
+		if visited[start>>6]&(1<<uint(start&63)) != 0 {
+			continue
+		}
+		visited[start>>6] |= 1 << uint(start&63)
+		save(start)
+		P := start
+		for {
+			s := src(P)
+			if s == start {
+				restore(P)
+				break
+			}
+			move(P, s)
+			visited[s>>6] |= 1 << uint(s&63)
+			P = s
+		}
+	}
+}
+
+// newStages precomputes the tables of the mixed-radix stages.
+// Stage s combines r = factors[s] adjacent sub-transforms of length L
+// into transforms of length m = r*L.
+func newStages(N int, factors []int, t *twiddles) []stage {
+	stages := make([]stage, len(factors))
+	L := 1
+	for i, r := range factors {
+		stages[i] = stage{r: r, L: L, wr: radixRoots(r, t)}
+		if L*(r-1) <= stageTableMax {
+			stages[i].w = stageTwiddles(L, r, N/(L*r), t)
+		}
+		L *= r
+	}
+	return stages
+}
+
+// mixedRadix runs the decimation in time stages on digit-reversed input.
+func mixedRadix(x []complex128, stages []stage, t *twiddles) {
+	N := len(x)
+	for _, st := range stages {
+		r, L := st.r, st.L
+		m := L * r
+		s := N / m // exp(-2πi k/m) = t.at(k*s)
+		a := make([]complex128, r)
+		if st.w != nil {
+			// Many short blocks: use the precomputed twiddles of the stage.
+			for b := 0; b < N; b += m {
+				for j := 0; j < L; j++ {
+					butterfly(x, b+j, L, r, st.w[j*(r-1):(j+1)*(r-1)], st.wr, a)
+				}
+			}
+		} else {
+			// Few long blocks: compute the twiddles of each column once.
+			w := make([]complex128, r-1)
+			for j := 0; j < L; j++ {
+				for q := 1; q < r; q++ {
+					w[q-1] = t.at(q * j * s)
+				}
+				for b := 0; b < N; b += m {
+					butterfly(x, b+j, L, r, w, st.wr, a)
+				}
+			}
+		}
+	}
+}
+
+// radixRoots returns the r roots of 1 of order r.
+func radixRoots(r int, t *twiddles) []complex128 {
+	wr := make([]complex128, r)
+	for k := range wr {
+		wr[k] = t.at(k * (t.N / r))
+	}
+	return wr
+}
+
+// stageTwiddles returns the table w[j*(r-1)+q-1] = exp(-2πi q*j/m) of a stage.
+func stageTwiddles(L, r, s int, t *twiddles) []complex128 {
+	w := make([]complex128, L*(r-1))
+	for j := 0; j < L; j++ {
+		for q := 1; q < r; q++ {
+			w[j*(r-1)+q-1] = t.at(q * j * s)
+		}
+	}
+	return w
+}
+
+// Constants of the radix 3 and radix 5 butterflies.
+var (
+	sin3  = math.Sin(2 * math.Pi / 3)
+	cos5  = math.Cos(2 * math.Pi / 5)
+	cos25 = math.Cos(4 * math.Pi / 5)
+	sin5  = math.Sin(2 * math.Pi / 5)
+	sin25 = math.Sin(4 * math.Pi / 5)
+)
+
+// scale multiplies z by the real factor f.
+func scale(z complex128, f float64) complex128 {
+	return complex(real(z)*f, imag(z)*f)
+}
+
+// butterfly computes one radix r butterfly on x[i], x[i+L], ..., x[i+(r-1)L].
+// w holds the r-1 twiddles of the inputs 1..r-1, wr the r-th roots of 1
+// and a is scratch space of length r.
+func butterfly(x []complex128, i, L, r int, w, wr, a []complex128) {
+	switch r {
+	case 2:
+		a1 := x[i+L] * w[0]
+		x[i], x[i+L] = x[i]+a1, x[i]-a1
+	case 3:
+		i1, i2 := i+L, i+2*L
+		a0 := x[i]
+		a1 := x[i1] * w[0]
+		a2 := x[i2] * w[1]
+		b, d := a1+a2, a1-a2
+		t := a0 - b*0.5
+		// -i*sin(2π/3)*d
+		u := complex(imag(d)*sin3, -real(d)*sin3)
+		x[i], x[i1], x[i2] = a0+b, t+u, t-u
+	case 4:
+		i1, i2, i3 := i+L, i+2*L, i+3*L
+		a0 := x[i]
+		a1 := x[i1] * w[0]
+		a2 := x[i2] * w[1]
+		a3 := x[i3] * w[2]
+		t0, t1 := a0+a2, a0-a2
+		t2, t3 := a1+a3, a1-a3
+		// multiply t3 by -i
+		t3 = complex(imag(t3), -real(t3))
+		x[i], x[i1], x[i2], x[i3] = t0+t2, t1+t3, t0-t2, t1-t3
+	case 5:
+		i1, i2, i3, i4 := i+L, i+2*L, i+3*L, i+4*L
+		a0 := x[i]
+		a1 := x[i1] * w[0]
+		a2 := x[i2] * w[1]
+		a3 := x[i3] * w[2]
+		a4 := x[i4] * w[3]
+		b1, b2 := a1+a4, a2+a3
+		d1, d2 := a1-a4, a2-a3
+		t1 := a0 + scale(b1, cos5) + scale(b2, cos25)
+		t2 := a0 + scale(b1, cos25) + scale(b2, cos5)
+		// -i*(sin(2π/5)*d1 + sin(4π/5)*d2), -i*(sin(4π/5)*d1 - sin(2π/5)*d2)
+		v1 := scale(d1, sin5) + scale(d2, sin25)
+		v2 := scale(d1, sin25) - scale(d2, sin5)
+		u1 := complex(imag(v1), -real(v1))
+		u2 := complex(imag(v2), -real(v2))
+		x[i], x[i1], x[i2], x[i3], x[i4] = a0+b1+b2, t1+u1, t2+u2, t2-u2, t1-u1
+	default:
+		a[0] = x[i]
+		for q := 1; q < r; q++ {
+			a[q] = x[i+q*L] * w[q-1]
+		}
+		for k := 0; k < r; k++ {
+			sum := a[0]
+			e := 0
+			for q := 1; q < r; q++ {
+				e += k
+				if e >= r {
+					e -= r
+				}
+				sum += a[q] * wr[e]
+			}
+			x[i+k*L] = sum
+		}
+	}
+}
+
+// bluestein computes an N-point DFT as a circular convolution of length M,
+// where M is the smallest power of 2 >= 2N-1:
+//
+//	X_k = w_k * sum_n (x_n w_n) conj(w_{k-n}),  w_k = exp(-πi k²/N)
+type bluestein struct {
+	N int
+	f Plan         // Power of 2 transform of length M.
+	w []complex128 // Chirp w_k, length N.
+	b []complex128 // FFT of the conjugated chirp, length M.
+}
+
+func newBluestein(N int) (*bluestein, error) {
+	M := 2
+	for M < 2*N-1 {
+		M <<= 1
+	}
+	f, err := NewPlan(M)
+	if err != nil {
+		return nil, err
+	}
+	w := make([]complex128, N)
+	N2 := int64(2 * N)
+	for k := 0; k < N; k++ {
+		// k² mod 2N keeps the phase argument small and exact.
+		kk := int64(k) * int64(k) % N2
+		s, c := math.Sincos(-math.Pi * float64(kk) / float64(N))
+		w[k] = complex(c, s)
+	}
+	b := make([]complex128, M)
+	b[0] = complex(real(w[0]), -imag(w[0]))
+	for k := 1; k < N; k++ {
+		c := complex(real(w[k]), -imag(w[k]))
+		b[k] = c
+		b[M-k] = c
+	}
+	f.Transform(b)
+	return &bluestein{N: N, f: f, w: w, b: b}, nil
+}
+
+func (bs *bluestein) transform(x []complex128) []complex128 {
+	a := make([]complex128, bs.f.N)
+	for k := 0; k < bs.N; k++ {
+		a[k] = x[k] * bs.w[k]
+	}
+	bs.f.Transform(a)
+	for i := range a {
+		a[i] *= bs.b[i]
+	}
+	bs.f.Inverse(a)
+	for k := 0; k < bs.N; k++ {
+		x[k] = a[k] * bs.w[k]
+	}
+	return x
+}
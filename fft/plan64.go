@@ -0,0 +1,122 @@
+package fft
+
+// Single precision counterparts of the mixed-radix kernels in plan.go.
+// Twiddles are computed in double precision and rounded once to complex64.
+
+// digitReversal64 permutes x in-place into the mixed-radix digit-reversed order.
+func digitReversal64(x []complex64, factors []int, perm []int32) {
+	src := digitReversalSource(len(x), factors)
+	if perm != nil {
+		src = func(P int) int { return int(perm[P]) }
+	}
+	var tmp complex64
+	followCycles(len(x), src,
+		func(start int) { tmp = x[start] },
+		func(P, s int) { x[P] = x[s] },
+		func(P int) { x[P] = tmp })
+}
+
+// mixedRadix64 runs the decimation in time stages on digit-reversed input.
+func mixedRadix64(x []complex64, stages []stage, t *twiddles) {
+	N := len(x)
+	for _, st := range stages {
+		r, L := st.r, st.L
+		m := L * r
+		s := N / m
+		wr := to64(st.wr)
+		a := make([]complex64, r)
+		if st.w != nil {
+			w := to64(st.w)
+			for b := 0; b < N; b += m {
+				for j := 0; j < L; j++ {
+					butterfly64(x, b+j, L, r, w[j*(r-1):(j+1)*(r-1)], wr, a)
+				}
+			}
+		} else {
+			w := make([]complex64, r-1)
+			for j := 0; j < L; j++ {
+				for q := 1; q < r; q++ {
+					w[q-1] = complex64(t.at(q * j * s))
+				}
+				for b := 0; b < N; b += m {
+					butterfly64(x, b+j, L, r, w, wr, a)
+				}
+			}
+		}
+	}
+}
+
+// butterfly64 computes one radix r butterfly, see butterfly.
+func butterfly64(x []complex64, i, L, r int, w, wr, a []complex64) {
+	switch r {
+	case 2:
+		a1 := x[i+L] * w[0]
+		x[i], x[i+L] = x[i]+a1, x[i]-a1
+	case 3:
+		i1, i2 := i+L, i+2*L
+		a0 := x[i]
+		a1 := x[i1] * w[0]
+		a2 := x[i2] * w[1]
+		b, d := a1+a2, a1-a2
+		t := a0 - b*0.5
+		u := complex(imag(d)*float32(sin3), -real(d)*float32(sin3))
+		x[i], x[i1], x[i2] = a0+b, t+u, t-u
+	case 4:
+		i1, i2, i3 := i+L, i+2*L, i+3*L
+		a0 := x[i]
+		a1 := x[i1] * w[0]
+		a2 := x[i2] * w[1]
+		a3 := x[i3] * w[2]
+		t0, t1 := a0+a2, a0-a2
+		t2, t3 := a1+a3, a1-a3
+		// multiply t3 by -i
+		t3 = complex(imag(t3), -real(t3))
+		x[i], x[i1], x[i2], x[i3] = t0+t2, t1+t3, t0-t2, t1-t3
+	case 5:
+		i1, i2, i3, i4 := i+L, i+2*L, i+3*L, i+4*L
+		a0 := x[i]
+		a1 := x[i1] * w[0]
+		a2 := x[i2] * w[1]
+		a3 := x[i3] * w[2]
+		a4 := x[i4] * w[3]
+		b1, b2 := a1+a4, a2+a3
+		d1, d2 := a1-a4, a2-a3
+		t1 := a0 + scale64(b1, float32(cos5)) + scale64(b2, float32(cos25))
+		t2 := a0 + scale64(b1, float32(cos25)) + scale64(b2, float32(cos5))
+		v1 := scale64(d1, float32(sin5)) + scale64(d2, float32(sin25))
+		v2 := scale64(d1, float32(sin25)) - scale64(d2, float32(sin5))
+		u1 := complex(imag(v1), -real(v1))
+		u2 := complex(imag(v2), -real(v2))
+		x[i], x[i1], x[i2], x[i3], x[i4] = a0+b1+b2, t1+u1, t2+u2, t2-u2, t1-u1
+	default:
+		a[0] = x[i]
+		for q := 1; q < r; q++ {
+			a[q] = x[i+q*L] * w[q-1]
+		}
+		for k := 0; k < r; k++ {
+			sum := a[0]
+			e := 0
+			for q := 1; q < r; q++ {
+				e += k
+				if e >= r {
+					e -= r
+				}
+				sum += a[q] * wr[e]
+			}
+			x[i+k*L] = sum
+		}
+	}
+}
+
+// scale64 multiplies z by the real factor f.
+func scale64(z complex64, f float32) complex64 {
+	return complex(real(z)*f, imag(z)*f)
+}
+
+func to64(x []complex128) []complex64 {
+	y := make([]complex64, len(x))
+	for i, v := range x {
+		y[i] = complex64(v)
+	}
+	return y
+}
@@ -0,0 +1,154 @@
+package fft
+
+import (
+	"fmt"
+	"math"
+	"math/cmplx"
+	"math/rand"
+	"testing"
+)
+
+// naiveDFT 直接按定义计算的 O(N^2) 离散傅里叶变换，作为对比基准
+func naiveDFT(x []complex128) []complex128 {
+	N := len(x)
+	res := make([]complex128, N)
+	for k := 0; k < N; k++ {
+		var sum complex128
+		for n := 0; n < N; n++ {
+			s, c := math.Sincos(-2 * math.Pi * float64((k*n)%N) / float64(N))
+			sum += x[n] * complex(c, s)
+		}
+		res[k] = sum
+	}
+	return res
+}
+
+func randomInput(N int) []complex128 {
+	x := make([]complex128, N)
+	for i := range x {
+		x[i] = complex(rand.Float64()*2-1, rand.Float64()*2-1)
+	}
+	return x
+}
+
+func maxDiff(a, b []complex128) float64 {
+	d := 0.0
+	for i := range a {
+		d = math.Max(d, cmplx.Abs(a[i]-b[i]))
+	}
+	return d
+}
+
+func TestPlanTransform(t *testing.T) {
+	sizes := []int{2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 15, 16, 17, 25, 30, 49, 64, 97, 100, 125, 210, 243, 256, 500, 1000, 1001, 1024, 2000}
+	for _, N := range sizes {
+		t.Run(fmt.Sprintf("N_%d", N), func(t *testing.T) {
+			p, err := NewPlan(N)
+			if err != nil {
+				t.Fatal(err)
+			}
+			x := randomInput(N)
+			want := naiveDFT(x)
+			got := p.Transform(append([]complex128(nil), x...))
+			if d := maxDiff(got, want); d > 1e-9*float64(N) {
+				t.Errorf("Transform N=%d max diff %g", N, d)
+			}
+			back := p.Inverse(got)
+			if d := maxDiff(back, x); d > 1e-12*float64(N) {
+				t.Errorf("Inverse N=%d max diff %g", N, d)
+			}
+		})
+	}
+}
+
+func TestPlanKind(t *testing.T) {
+	tests := []struct {
+		N    int
+		kind int
+	}{
+		{1024, kindMixed},
+		{20000, kindMixed},
+		{1000000, kindMixed},
+		{100000000, kindMixed},
+		{1000003, kindBluestein},
+	}
+	for _, tt := range tests {
+		if factors, ok := factorize(tt.N); ok != (tt.kind != kindBluestein) {
+			t.Errorf("factorize(%d) = %v, %v", tt.N, factors, ok)
+		}
+		if tt.N > 1<<22 {
+			continue
+		}
+		p, err := NewPlan(tt.N)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if p.kind != tt.kind {
+			t.Errorf("NewPlan(%d) kind = %d, want %d", tt.N, p.kind, tt.kind)
+		}
+	}
+}
+
+func TestPlanMatchesRadix2(t *testing.T) {
+	N := 1 << 12
+	x := randomInput(N)
+	f, _ := New(N)
+	want := f.Transform(append([]complex128(nil), x...))
+
+	p, err := NewPlan(N)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := p.Transform(append([]complex128(nil), x...))
+	if d := maxDiff(got, want); d > 1e-9 {
+		t.Errorf("mixed radix differs from radix-2: %g", d)
+	}
+
+	bs, err := newBluestein(N)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got = bs.transform(append([]complex128(nil), x...))
+	if d := maxDiff(got, want); d > 1e-8 {
+		t.Errorf("bluestein differs from radix-2: %g", d)
+	}
+}
+
+func TestTwiddles(t *testing.T) {
+	for _, N := range []int{2, 3, 100, 4096, 1000000} {
+		tw := newTwiddles(N)
+		for _, k := range []int{0, 1, N / 3, N / 2, N - 1} {
+			if d := cmplx.Abs(tw.at(k) - root(k, N)); d > 1e-15 {
+				t.Errorf("twiddle N=%d k=%d diff %g", N, k, d)
+			}
+		}
+		if len(tw.lo)+len(tw.hi) > 2*int(math.Sqrt(float64(N)))+4 {
+			t.Errorf("twiddle table N=%d too large: %d", N, len(tw.lo)+len(tw.hi))
+		}
+	}
+}
+
+func TestPlanTransform64(t *testing.T) {
+	for _, N := range []int{16, 100, 1000, 1001, 20000} {
+		p, err := NewPlan(N)
+		if err != nil {
+			t.Fatal(err)
+		}
+		x := randomInput(N)
+		x64 := make([]complex64, N)
+		for i, v := range x {
+			x64[i] = complex64(v)
+		}
+		want := p.Transform(x)
+		p.Transform64(x64)
+		var num, den float64
+		for i := range want {
+			d := cmplx.Abs(complex128(x64[i]) - want[i])
+			num += d * d
+			den += cmplx.Abs(want[i]) * cmplx.Abs(want[i])
+		}
+		if rel, bound := math.Sqrt(num/den), Float32ErrorBound(N); rel > bound {
+			t.Errorf("N=%d relative error %g exceeds bound %g", N, rel, bound)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package fft
+
+import "fmt"
+
+// RealPlan is a discrete Fourier transformation of N real input values, N even.
+//
+// The real input x is packed into N/2 complex values z[k] = x[2k] + i*x[2k+1]
+// (half-length packing), which are transformed with an N/2-point Plan and
+// then separated into the spectrum of x. The transform works in-place on the
+// packed buffer, so a real transform of N points needs N float64 (or N float32
+// for Transform64) of memory instead of N complex128.
+//
+// The spectrum of a real input is conjugate symmetric, X[N-k] = conj(X[k]),
+// so only X[0..N/2] is returned. X[0] and X[N/2] are real and share z[0]:
+//
+//	z[0] = X[0] + i*X[N/2],  z[k] = X[k] for 0 < k < N/2
+//
+// A RealPlan is read-only after creation and can be shared by multiple goroutines.
+type RealPlan struct {
+	N    int       // Number of real input values.
+	half Plan      // Complex transform of length N/2.
+	tw   *twiddles // Roots of 1 of order N.
+}
+
+// NewRealPlan allocates a RealPlan for N real input values.
+func NewRealPlan(N int) (p RealPlan, err error) {
+	if N < 4 || N%2 != 0 {
+		return p, fmt.Errorf("real fft input length must be even and >= 4. It is: %d", N)
+	}
+	p.half, err = NewPlan(N / 2)
+	if err != nil {
+		return p, err
+	}
+	p.N = N
+	p.tw = newTwiddles(N)
+	return p, nil
+}
+
+// Transform Forward transform of packed real input, see RealPlan.
+// The forward transform overwrites the input array.
+func (p RealPlan) Transform(z []complex128) []complex128 {
+	if len(z) != p.N/2 {
+		panic("Input dimension mismatches: RealPlan is not initialized, or called with wrong input.")
+	}
+	p.half.Transform(z)
+	M := p.N / 2
+	z[0] = complex(real(z[0])+imag(z[0]), real(z[0])-imag(z[0]))
+	for k := 1; k <= M/2; k++ {
+		e, wo := p.split(k, z[k], z[M-k])
+		z[k] = e + wo
+		z[M-k] = conj(e - wo)
+	}
+	return z
+}
+
+// Transform64 is the forward transform of packed real input in single precision.
+// The separation step is computed in double precision.
+// The forward transform overwrites the input array.
+func (p RealPlan) Transform64(z []complex64) []complex64 {
+	if len(z) != p.N/2 {
+		panic("Input dimension mismatches: RealPlan is not initialized, or called with wrong input.")
+	}
+	p.half.Transform64(z)
+	M := p.N / 2
+	z[0] = complex(real(z[0])+imag(z[0]), real(z[0])-imag(z[0]))
+	for k := 1; k <= M/2; k++ {
+		e, wo := p.split(k, complex128(z[k]), complex128(z[M-k]))
+		z[k] = complex64(e + wo)
+		z[M-k] = complex64(conj(e - wo))
+	}
+	return z
+}
+
+// split separates Z[k] and Z[M-k] of the packed transform into the
+// transforms of the even and odd input values,
+// returning E[k] and exp(-2πi k/N) * O[k].
+func (p RealPlan) split(k int, zk, zm complex128) (e, wo complex128) {
+	c := conj(zm)
+	e = (zk + c) * 0.5
+	d := zk - c // O[k] = d / 2i
+	o := complex(imag(d)*0.5, -real(d)*0.5)
+	return e, p.tw.at(k) * o
+}
+
+func conj(x complex128) complex128 {
+	return complex(real(x), -imag(x))
+}
@@ -0,0 +1,82 @@
+package fft
+
+import (
+	"fmt"
+	"math"
+	"math/cmplx"
+	"math/rand"
+	"testing"
+)
+
+// pack 将实数序列按 z[k] = x[2k] + i*x[2k+1] 打包
+func pack(x []float64) []complex128 {
+	z := make([]complex128, len(x)/2)
+	for k := range z {
+		z[k] = complex(x[2*k], x[2*k+1])
+	}
+	return z
+}
+
+func TestRealPlanTransform(t *testing.T) {
+	for _, N := range []int{4, 6, 8, 10, 14, 22, 100, 128, 1000, 2002} {
+		t.Run(fmt.Sprintf("N_%d", N), func(t *testing.T) {
+			p, err := NewRealPlan(N)
+			if err != nil {
+				t.Fatal(err)
+			}
+			x := make([]float64, N)
+			c := make([]complex128, N)
+			for i := range x {
+				x[i] = rand.Float64()*2 - 1
+				c[i] = complex(x[i], 0)
+			}
+			want := naiveDFT(c)
+			z := p.Transform(pack(x))
+
+			tol := 1e-9 * float64(N)
+			if math.Abs(real(z[0])-real(want[0])) > tol || math.Abs(imag(z[0])-real(want[N/2])) > tol {
+				t.Errorf("X[0], X[N/2] = %v, want %v %v", z[0], want[0], want[N/2])
+			}
+			for k := 1; k < N/2; k++ {
+				if d := cmplx.Abs(z[k] - want[k]); d > tol {
+					t.Errorf("X[%d] = %v, want %v", k, z[k], want[k])
+				}
+			}
+		})
+	}
+}
+
+func TestRealPlanTransform64(t *testing.T) {
+	N := 20000
+	p, err := NewRealPlan(N)
+	if err != nil {
+		t.Fatal(err)
+	}
+	x := make([]float64, N)
+	for i := range x {
+		x[i] = float64(rand.Intn(2)*2 - 1)
+	}
+	want := p.Transform(pack(x))
+	z := make([]complex64, N/2)
+	for k := range z {
+		z[k] = complex(float32(x[2*k]), float32(x[2*k+1]))
+	}
+	p.Transform64(z)
+	var num, den float64
+	for k := range z {
+		d := cmplx.Abs(complex128(z[k]) - want[k])
+		num += d * d
+		den += cmplx.Abs(want[k]) * cmplx.Abs(want[k])
+	}
+	if rel, bound := math.Sqrt(num/den), Float32ErrorBound(N); rel > bound {
+		t.Errorf("relative error %g exceeds bound %g", rel, bound)
+	}
+}
+
+func TestNewRealPlanInvalid(t *testing.T) {
+	for _, N := range []int{0, 2, 3, 101} {
+		if _, err := NewRealPlan(N); err == nil {
+			t.Errorf("NewRealPlan(%d) should fail", N)
+		}
+	}
+}
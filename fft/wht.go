@@ -0,0 +1,81 @@
+package fft
+
+import "fmt"
+
+// whtByte holds the 8-point Walsh–Hadamard transform of the ±1 values
+// (-1)^b of the 8 bits of every byte value, most significant bit first.
+var whtByte [256][8]int8
+
+func init() {
+	for v := 0; v < 256; v++ {
+		var x [8]int8
+		for t := 0; t < 8; t++ {
+			x[t] = 1
+			if v&(0x80>>uint(t)) != 0 {
+				x[t] = -1
+			}
+		}
+		for h := 1; h < 8; h <<= 1 {
+			for i := 0; i < 8; i += h << 1 {
+				for j := i; j < i+h; j++ {
+					x[j], x[j+h] = x[j]+x[j+h], x[j]-x[j+h]
+				}
+			}
+		}
+		whtByte[v] = x
+	}
+}
+
+// WHT computes the Walsh–Hadamard transform of x in-place,
+//
+//	W(u) = sum_x x[x] * (-1)^popcount(u & x),
+//
+// in natural (Hadamard) order without normalization.
+// The length of x must be a power of 2.
+func WHT(x []int32) []int32 {
+	N := len(x)
+	if N == 0 || N&(N-1) != 0 {
+		panic(fmt.Sprintf("WHT input length must be a power of 2. It is: %d", N))
+	}
+	whtStages(x, 1)
+	return x
+}
+
+// WHTBytes computes the Walsh–Hadamard transform of the ±1 sequence
+// (-1)^b_x of the 8*len(data) bits packed in data (most significant bit
+// first), writing the coefficients into w and returning it.
+// w must have a length of 8*len(data), which must be a power of 2.
+//
+// The first three butterfly stages, which stay within one byte, are
+// replaced by a lookup of the precomputed 8-point transform of each byte.
+func WHTBytes(data []byte, w []int32) []int32 {
+	N := len(data) * 8
+	if N == 0 || N&(N-1) != 0 {
+		panic(fmt.Sprintf("WHT input length must be a power of 2. It is: %d", N))
+	}
+	if len(w) != N {
+		panic("Input dimension mismatches: output length must be 8*len(data).")
+	}
+	for j, v := range data {
+		t := &whtByte[v]
+		o := w[j*8 : j*8+8]
+		for i := range o {
+			o[i] = int32(t[i])
+		}
+	}
+	whtStages(w, 8)
+	return w
+}
+
+// whtStages runs the butterfly stages with spans h0, 2*h0, ..., len(x)/2.
+func whtStages(x []int32, h0 int) {
+	N := len(x)
+	for h := h0; h < N; h <<= 1 {
+		for i := 0; i < N; i += h << 1 {
+			a, b := x[i:i+h], x[i+h:i+2*h]
+			for j := range a {
+				a[j], b[j] = a[j]+b[j], a[j]-b[j]
+			}
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package fft
+
+import (
+	"math/bits"
+	"math/rand"
+	"testing"
+)
+
+func naiveWHT(x []int32) []int32 {
+	N := len(x)
+	res := make([]int32, N)
+	for u := 0; u < N; u++ {
+		var sum int32
+		for i := 0; i < N; i++ {
+			if bits.OnesCount(uint(u&i))%2 == 0 {
+				sum += x[i]
+			} else {
+				sum -= x[i]
+			}
+		}
+		res[u] = sum
+	}
+	return res
+}
+
+func TestWHT(t *testing.T) {
+	for _, N := range []int{1, 2, 8, 64, 256} {
+		x := make([]int32, N)
+		for i := range x {
+			x[i] = int32(rand.Intn(201) - 100)
+		}
+		want := naiveWHT(x)
+		got := WHT(append([]int32(nil), x...))
+		for u := range want {
+			if got[u] != want[u] {
+				t.Fatalf("N=%d W(%d) = %d, want %d", N, u, got[u], want[u])
+			}
+		}
+		// WHT(WHT(x)) = N * x
+		WHT(got)
+		for i := range x {
+			if got[i] != int32(N)*x[i] {
+				t.Fatalf("N=%d inverse mismatch at %d", N, i)
+			}
+		}
+	}
+}
+
+func TestWHTBytes(t *testing.T) {
+	for _, nb := range []int{1, 2, 8, 32} {
+		data := make([]byte, nb)
+		rand.Read(data)
+		x := make([]int32, nb*8)
+		for i := range x {
+			x[i] = 1
+			if data[i/8]&(0x80>>uint(i%8)) != 0 {
+				x[i] = -1
+			}
+		}
+		want := WHT(x)
+		got := WHTBytes(data, make([]int32, nb*8))
+		for u := range want {
+			if got[u] != want[u] {
+				t.Fatalf("bytes=%d W(%d) = %d, want %d", nb, u, got[u], want[u])
+			}
+		}
+	}
+}
@@ -0,0 +1,96 @@
+// Copyright (c) 2021 Quan guanyu
+// randomness is licensed under Mulan PSL v2.
+// You can use this software according to the terms and conditions of the Mulan PSL v2.
+// You may obtain a copy of Mulan PSL v2 at:
+//          http://license.coscl.org.cn/MulanPSL2
+// THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND,
+// EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT,
+// MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+// See the Mulan PSL v2 for more details.
+
+package randomness
+
+import (
+	"math"
+	"math/bits"
+)
+
+// HammingWeightDependency 汉明重量相关性检测
+func HammingWeightDependency(data []byte) *TestResult {
+	p, q := HammingWeightDependencyTestBytes(data, 8)
+	return &TestResult{Name: "汉明重量相关性检测", P: p, Q: q, Pass: p >= Alpha}
+}
+
+// HammingWeightDependencyTest 汉明重量相关性检测，w=8
+func HammingWeightDependencyTest(bits []bool) (float64, float64) {
+	return HammingWeightDependencyProto(bits, 8)
+}
+
+// HammingWeightDependencyTestBytes 汉明重量相关性检测
+// 直接由字节组装 w 比特整数，避免字节切片到位切片的转换。
+//
+// data: 检测序列
+// w: 整数比特数，w=8,16,32,64
+func HammingWeightDependencyTestBytes(data []byte, w int) (float64, float64) {
+	return hammingWeightDependencyWords(Words(data, w, MSBFirst), w)
+}
+
+// HammingWeightDependencyProto 汉明重量相关性检测
+// 将序列划分为 n 个 w 比特整数，随机序列下各整数的汉明重量相互独立且服从二项分布 B(w,1/2)。
+// 相邻的汉明重量依次合并为 k 个类别，使每个相邻重量对类别的期望频数不小于 minExpected。
+// 统计 n 个（首尾相接的）重叠相邻重量对的联合频数与单个重量的频数，
+// 卡方统计量之差 V=Q2-Q1 服从自由度为 k^2-k 的卡方分布（Good 序列检测），P=Q=igamc((k^2-k)/2, V/2)。
+// 单比特频数与扑克检测只考察单个整数，无法发现相邻整数汉明重量之间的相关性。
+//
+// bits: 检测序列
+// w: 整数比特数，w=8,16,32,64
+func HammingWeightDependencyProto(bits []bool, w int) (float64, float64) {
+	return hammingWeightDependencyWords(bitWords(bits, w), w)
+}
+
+// hammingWeightDependencyWords 对 w 比特整数序列进行汉明重量相关性检测
+func hammingWeightDependencyWords(words []uint64, w int) (float64, float64) {
+	if w != 8 && w != 16 && w != 32 && w != 64 {
+		panic("word size w must be 8, 16, 32 or 64")
+	}
+	n := len(words)
+	if n < 2 {
+		panic("please provide valid test bits")
+	}
+
+	// 汉明重量的二项分布，合并使每个类别的概率 p 满足 n*p^2 >= minExpected
+	probs := make([]float64, w+1)
+	for i := range probs {
+		probs[i] = math.Exp(logGamma(float64(w+1)) - logGamma(float64(i+1)) - logGamma(float64(w-i+1)) - float64(w)*math.Ln2)
+	}
+	category, merged := mergeCategories(probs, float64(n), math.Sqrt(minExpected*float64(n)))
+	k := len(merged)
+	if k < 2 {
+		panic("please provide valid test bits")
+	}
+
+	c1 := make([]int, k)
+	c2 := make([]int, k*k)
+	prev := category[bits.OnesCount64(words[n-1])]
+	for _, v := range words {
+		c := category[bits.OnesCount64(v)]
+		c1[c]++
+		c2[prev*k+c]++
+		prev = c
+	}
+
+	fN := float64(n)
+	var Q1, Q2 float64 = 0, 0
+	for a := 0; a < k; a++ {
+		e := fN * merged[a]
+		d := float64(c1[a]) - e
+		Q1 += d * d / e
+		for b := 0; b < k; b++ {
+			e := fN * merged[a] * merged[b]
+			d := float64(c2[a*k+b]) - e
+			Q2 += d * d / e
+		}
+	}
+	P := igamc(float64(k*k-k)/2, (Q2-Q1)/2)
+	return P, P
+}
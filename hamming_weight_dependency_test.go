@@ -0,0 +1,35 @@
+package randomness
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestHammingWeightDependencyE(t *testing.T) {
+	bits := getEConstantBits()
+	p, q := HammingWeightDependencyTest(bits)
+	fmt.Printf("n: %v, P-value: %f, Q-value: %f\n", len(bits), p, q)
+	if fmt.Sprintf("%.6f", p) != "0.564979" || fmt.Sprintf("%.6f", q) != "0.564979" {
+		t.FailNow()
+	}
+	p, q = HammingWeightDependencyProto(bits, 16)
+	fmt.Printf("w: 16, P-value: %f, Q-value: %f\n", p, q)
+	if fmt.Sprintf("%.6f", p) != "0.634593" || fmt.Sprintf("%.6f", q) != "0.634593" {
+		t.FailNow()
+	}
+}
+
+func TestHammingWeightDependencyCorrelated(t *testing.T) {
+	// 每140个字节中有一个为前一字节取反，单字节分布不变，扑克检测无法发现
+	bits := append([]bool(nil), getEConstantBits()...)
+	for i := 1; i < len(bits)/8; i += 140 {
+		for j := 0; j < 8; j++ {
+			bits[i*8+j] = !bits[(i-1)*8+j]
+		}
+	}
+	p, _ := HammingWeightDependencyTest(bits)
+	fmt.Printf("n: %v, P-value: %f\n", len(bits), p)
+	if fmt.Sprintf("%.6f", p) != "0.001089" {
+		t.FailNow()
+	}
+}
@@ -0,0 +1,643 @@
+// Copyright (c) 2021 Quan guanyu
+// randomness is licensed under Mulan PSL v2.
+// You can use this software according to the terms and conditions of the Mulan PSL v2.
+// You may obtain a copy of Mulan PSL v2 at:
+//          http://license.coscl.org.cn/MulanPSL2
+// THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND,
+// EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT,
+// MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+// See the Mulan PSL v2 for more details.
+
+package randomness
+
+import (
+	"fmt"
+	"math"
+)
+
+// Knuth《计算机程序设计艺术》第2卷 3.3.2 节中的经验检测。
+// 这些检测把序列视为 w 比特无符号整数序列 Y_0, Y_1, ...，对应 [0,1) 上的 U_j = Y_j / 2^w。
+
+// BitOrder 由比特序列组装整数时的比特序
+type BitOrder int
+
+const (
+	// MSBFirst 按比特序列顺序（字节内高位在前）读取，先读到的比特为整数的高位。
+	// w=32 时等价于按大端序读取 uint32。
+	MSBFirst BitOrder = iota
+	// LSBFirst 字节内低位在前读取比特，先读到的比特为整数的低位。
+	// w=32 时等价于按小端序读取 uint32。
+	LSBFirst
+)
+
+// Words 将序列按比特序 order 转换为 w 比特整数序列，末尾不足 w 比特的部分被丢弃
+// data: 检测序列
+// w: 整数比特数，1<=w<=64
+// order: 比特序
+func Words(data []byte, w int, order BitOrder) []uint64 {
+	if w < 1 || w > 64 {
+		panic("word size must be in [1, 64]")
+	}
+	n := len(data) * 8 / w
+	words := make([]uint64, n)
+	if order == MSBFirst && w%8 == 0 {
+		for i := range words {
+			var v uint64
+			for _, b := range data[i*w/8 : (i+1)*w/8] {
+				v = v<<8 | uint64(b)
+			}
+			words[i] = v
+		}
+		return words
+	}
+	pos := 0
+	for i := range words {
+		var v uint64
+		for j := 0; j < w; j++ {
+			if order == MSBFirst {
+				v = v<<1 | uint64(data[pos>>3]>>uint(7-pos&7)&1)
+			} else {
+				v |= uint64(data[pos>>3]>>uint(pos&7)&1) << uint(j)
+			}
+			pos++
+		}
+		words[i] = v
+	}
+	return words
+}
+
+// KnuthTests 对序列运行全部整数检测：间隔、集券、排列、t最大值、碰撞、序列对检测
+// data: 检测序列
+// w: 整数比特数，不小于8（碰撞检测的要求，w 过小时 panic）
+// order: 比特序
+func KnuthTests(data []byte, w int, order BitOrder) []*TestResult {
+	words := Words(data, w, order)
+	tests := []struct {
+		name  string
+		proto func([]uint64, int) (float64, float64)
+	}{
+		{"间隔检测", GapProto},
+		{"集券检测", CouponCollectorProto},
+		{"排列检测", PermutationProto},
+		{"t最大值检测", MaximumOfTProto},
+		{"碰撞检测", CollisionProto},
+		{"序列对检测", SerialPairsProto},
+	}
+	res := make([]*TestResult, 0, len(tests))
+	for _, t := range tests {
+		p, q := t.proto(words, w)
+		res = append(res, &TestResult{Name: fmt.Sprintf("%s(w=%d)", t.name, w), P: p, Q: q, Pass: p >= Alpha})
+	}
+	return res
+}
+
+// minExpected 卡方检验中合并类别时每个类别的最小期望频数
+// 期望频数过小时卡方近似的尾部概率偏大，取10使显著水平0.01下的误判率接近名义值。
+const minExpected = 10
+
+// wordMax w 比特整数的最大值 2^w-1
+func wordMax(w int) uint64 {
+	return ^uint64(0) >> uint(64-w)
+}
+
+// wordTop w 比特整数的高 k 比特
+func wordTop(v uint64, w, k int) int {
+	return int(v >> uint(w-k))
+}
+
+// chiSquareP 卡方拟合优度检测
+// counts: 各类别的观测频数
+// probs: 各类别的理论概率，和为1
+func chiSquareP(counts []int, probs []float64) float64 {
+	total := 0
+	for _, c := range counts {
+		total += c
+	}
+	N := float64(total)
+	var V float64 = 0
+	for i, c := range counts {
+		e := N * probs[i]
+		d := float64(c) - e
+		V += d * d / e
+	}
+	return igamc(float64(len(counts)-1)/2, V/2)
+}
+
+// Gap 间隔检测，w=32
+func Gap(data []byte) *TestResult {
+	p, q := GapTestBytes(data, 32, MSBFirst)
+	return &TestResult{Name: "间隔检测", P: p, Q: q, Pass: p >= Alpha}
+}
+
+// GapTestBytes 间隔检测
+// data: 检测序列
+// w: 整数比特数
+// order: 比特序
+func GapTestBytes(data []byte, w int, order BitOrder) (float64, float64) {
+	return GapProto(Words(data, w, order), w)
+}
+
+// GapProto 间隔检测（Knuth 算法 G）
+// 统计落在区间 [α,β)=[1/3,2/3) 内的整数之间的间隔长度 r，
+// 随机序列下 P(r)=p(1-p)^r，p 为区间的精确概率，长度不小于 t 的间隔合并为一类，
+// t 取使各类别期望频数都不小于 minExpected 的最大值（不超过32），使用自由度为 t 的卡方检验。
+//
+// words: w 比特整数序列
+// w: 整数比特数，w>=2
+func GapProto(words []uint64, w int) (float64, float64) {
+	if w < 2 {
+		panic("word size must be >= 2")
+	}
+	third := wordMax(w) / 3
+	lo, hi := third, 2*third
+	p := float64(hi-lo) / (float64(wordMax(w)) + 1)
+
+	var gaps []int
+	r := 0
+	for _, v := range words {
+		if v >= lo && v < hi {
+			gaps = append(gaps, r)
+			r = 0
+		} else {
+			r++
+		}
+	}
+	G := float64(len(gaps))
+	t := 0
+	for t < 32 && G*p*math.Pow(1-p, float64(t)) >= minExpected && G*math.Pow(1-p, float64(t+1)) >= minExpected {
+		t++
+	}
+	if t == 0 {
+		panic("please provide valid test bits")
+	}
+	counts := make([]int, t+1)
+	probs := make([]float64, t+1)
+	for _, g := range gaps {
+		if g > t {
+			g = t
+		}
+		counts[g]++
+	}
+	for i := 0; i < t; i++ {
+		probs[i] = p * math.Pow(1-p, float64(i))
+	}
+	probs[t] = math.Pow(1-p, float64(t))
+	P := chiSquareP(counts, probs)
+	return P, P
+}
+
+// CouponCollector 集券检测，w=32
+func CouponCollector(data []byte) *TestResult {
+	p, q := CouponCollectorTestBytes(data, 32, MSBFirst)
+	return &TestResult{Name: "集券检测", P: p, Q: q, Pass: p >= Alpha}
+}
+
+// CouponCollectorTestBytes 集券检测
+// data: 检测序列
+// w: 整数比特数
+// order: 比特序
+func CouponCollectorTestBytes(data []byte, w int, order BitOrder) (float64, float64) {
+	return CouponCollectorProto(Words(data, w, order), w)
+}
+
+// CouponCollectorProto 集券检测（Knuth 算法 C）
+// 取整数的高3比特得到 d=8 种取值，统计集齐全部 d 种取值所需的段长 r，
+// 段长分布由已出现取值个数的递推精确计算，相邻的段长依次合并使各类别期望频数不小于 minExpected，
+// 使用自由度为类别数减1的卡方检验。
+//
+// words: w 比特整数序列
+// w: 整数比特数，w>=3
+func CouponCollectorProto(words []uint64, w int) (float64, float64) {
+	const k = 3
+	const d = 1 << k
+	if w < k {
+		panic("word size must be >= 3")
+	}
+	var lengths []int
+	var seen [d]bool
+	q, r := 0, 0
+	for _, v := range words {
+		r++
+		y := wordTop(v, w, k)
+		if !seen[y] {
+			seen[y] = true
+			q++
+			if q == d {
+				lengths = append(lengths, r)
+				seen = [d]bool{}
+				q, r = 0, 0
+			}
+		}
+	}
+
+	// 段长分布：state[j] 为抽取若干次后恰好已有 j 种取值的概率，
+	// dist[r-d] = P(R = r)，最后一项为 P(R >= maxLen)
+	const maxLen = 200
+	state := make([]float64, d+1)
+	state[0] = 1
+	dist := make([]float64, 0, maxLen-d+1)
+	tail := 1.0
+	for r := 1; r < maxLen; r++ {
+		// 第 r 次抽取恰好集齐
+		pr := state[d-1] / d
+		next := make([]float64, d+1)
+		for j := 0; j < d; j++ {
+			next[j] += state[j] * float64(j) / d
+			next[j+1] += state[j] * float64(d-j) / d
+		}
+		state = next
+		if r >= d {
+			dist = append(dist, pr)
+			tail -= pr
+		}
+	}
+	dist = append(dist, tail)
+
+	category, probs := mergeCategories(dist, float64(len(lengths)), minExpected)
+	if len(probs) < 2 {
+		panic("please provide valid test bits")
+	}
+	counts := make([]int, len(probs))
+	for _, r := range lengths {
+		if r > maxLen {
+			r = maxLen
+		}
+		counts[category[r-d]]++
+	}
+	P := chiSquareP(counts, probs)
+	return P, P
+}
+
+// mergeCategories 依次合并相邻类别，使每个类别的期望频数 N*p 不小于 min，
+// 末尾不足的部分并入最后一个类别。
+// 返回原类别到合并后类别的映射，以及合并后各类别的概率。
+func mergeCategories(probs []float64, N, min float64) ([]int, []float64) {
+	category := make([]int, len(probs))
+	var merged []float64
+	acc := 0.0
+	for i, p := range probs {
+		category[i] = len(merged)
+		acc += p
+		if N*acc >= min {
+			merged = append(merged, acc)
+			acc = 0
+		}
+	}
+	if acc > 0 {
+		if len(merged) == 0 {
+			return category, []float64{acc}
+		}
+		merged[len(merged)-1] += acc
+		for i := len(probs) - 1; i >= 0 && category[i] == len(merged); i-- {
+			category[i] = len(merged) - 1
+		}
+	}
+	return category, merged
+}
+
+// Permutation 排列检测，w=32
+func Permutation(data []byte) *TestResult {
+	p, q := PermutationTestBytes(data, 32, MSBFirst)
+	return &TestResult{Name: "排列检测", P: p, Q: q, Pass: p >= Alpha}
+}
+
+// PermutationTestBytes 排列检测
+// data: 检测序列
+// w: 整数比特数
+// order: 比特序
+func PermutationTestBytes(data []byte, w int, order BitOrder) (float64, float64) {
+	return PermutationProto(Words(data, w, order), w)
+}
+
+// PermutationProto 排列检测（Knuth 算法 P）
+// 将整数序列划分为长度为 t 的组，统计每组元素大小关系的 t! 种排列，
+// 随机序列下各排列等概率，使用自由度为 t!-1 的卡方检验。
+// t 取 3、4、5 中使每种排列的期望频数不小于5的最大值；含相等元素的组被丢弃。
+//
+// words: w 比特整数序列
+// w: 整数比特数
+func PermutationProto(words []uint64, w int) (float64, float64) {
+	t, f := 5, 120
+	for t > 3 && len(words)/t < 5*f {
+		f /= t
+		t--
+	}
+	if len(words)/t < 5*f {
+		panic("please provide valid test bits")
+	}
+	counts := make([]int, f)
+	u := make([]uint64, t)
+	for g := 0; g+t <= len(words); g += t {
+		copy(u, words[g:g+t])
+		if hasTie(u) {
+			continue
+		}
+		// 算法 P：依次将最大元素交换到末尾，得到排列的阶乘进制编号
+		idx := 0
+		for r := t; r > 1; r-- {
+			s := 0
+			for j := 1; j < r; j++ {
+				if u[j] > u[s] {
+					s = j
+				}
+			}
+			idx = r*idx + s
+			u[r-1], u[s] = u[s], u[r-1]
+		}
+		counts[idx]++
+	}
+	probs := make([]float64, f)
+	for i := range probs {
+		probs[i] = 1 / float64(f)
+	}
+	P := chiSquareP(counts, probs)
+	return P, P
+}
+
+func hasTie(u []uint64) bool {
+	for i := range u {
+		for j := i + 1; j < len(u); j++ {
+			if u[i] == u[j] {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// MaximumOfT t最大值检测，w=32
+func MaximumOfT(data []byte) *TestResult {
+	p, q := MaximumOfTTestBytes(data, 32, MSBFirst)
+	return &TestResult{Name: "t最大值检测", P: p, Q: q, Pass: p >= Alpha}
+}
+
+// MaximumOfTTestBytes t最大值检测
+// data: 检测序列
+// w: 整数比特数
+// order: 比特序
+func MaximumOfTTestBytes(data []byte, w int, order BitOrder) (float64, float64) {
+	return MaximumOfTProto(Words(data, w, order), w)
+}
+
+// MaximumOfTProto t最大值检测
+// 将整数序列划分为长度为 t=8 的组，取每组的最大值 V。随机序列下 P(V<=v)=((v+1)/2^w)^t，
+// 按该分布将 V 划分为 k 个近似等概率的区间（k 不超过32，期望频数不小于5），
+// 区间概率由离散分布精确计算，使用自由度为 k-1 的卡方检验。
+//
+// words: w 比特整数序列
+// w: 整数比特数
+func MaximumOfTProto(words []uint64, w int) (float64, float64) {
+	const t = 8
+	groups := len(words) / t
+	k := groups / 5
+	if k > 32 {
+		k = 32
+	}
+	if k < 2 {
+		panic("please provide valid test bits")
+	}
+	M := float64(wordMax(w)) + 1
+	// 区间 j 为 [bounds[j], bounds[j+1])
+	bounds := make([]uint64, k+1)
+	for j := 1; j < k; j++ {
+		b := M * math.Pow(float64(j)/float64(k), 1.0/t)
+		if b >= M {
+			b = M - 1
+		}
+		bounds[j] = uint64(math.Ceil(b))
+	}
+	cdf := func(b uint64) float64 { return math.Pow(float64(b)/M, t) }
+	probs := make([]float64, k)
+	for j := 0; j < k; j++ {
+		hi := 1.0
+		if j+1 < k {
+			hi = cdf(bounds[j+1])
+		}
+		probs[j] = hi - cdf(bounds[j])
+	}
+
+	counts := make([]int, k)
+	for g := 0; g < groups; g++ {
+		var V uint64
+		for _, v := range words[g*t : (g+1)*t] {
+			if v > V {
+				V = v
+			}
+		}
+		j := k - 1
+		for j > 0 && V < bounds[j] {
+			j--
+		}
+		counts[j]++
+	}
+	P := chiSquareP(counts, probs)
+	return P, P
+}
+
+// Collision 碰撞检测，w=32
+func Collision(data []byte) *TestResult {
+	p, q := CollisionTestBytes(data, 32, MSBFirst)
+	return &TestResult{Name: "碰撞检测", P: p, Q: q, Pass: p >= Alpha}
+}
+
+// CollisionTestBytes 碰撞检测
+// data: 检测序列
+// w: 整数比特数
+// order: 比特序
+func CollisionTestBytes(data []byte, w int, order BitOrder) (float64, float64) {
+	return CollisionProto(Words(data, w, order), w)
+}
+
+// CollisionProto 碰撞检测
+// 取整数的高 k 比特作为 m=2^k 个盒子的编号，k=min(w, 20, ⌊log2(N/8)⌋+6)，N 为整数个数。
+// 每 n=m/64 个整数为一次试验（Knuth 3.3.2 I 的稀疏情形），统计落入本次试验中已有球的盒子的次数（碰撞数），
+// n 不超过 N/8，至少有8次试验；末尾不足 n 个的 N mod n 个整数作为最后一次不完整的试验，
+// 全部独立试验的碰撞数之和为 C。单次试验碰撞数的精确分布由已占用盒子数的递推计算，
+// 各次试验的卷积得到 C 的精确分布；C 的期望约为 N/128，要求 N 不小于 128*minExpected。
+// C 为离散统计量，使用 mid-P 使其在原假设下近似服从均匀分布：
+// P 为双侧概率 2*min(P(<C)+P(=C)/2, P(>C)+P(=C)/2)，Q 为 P(>C)+P(=C)/2。
+//
+// words: w 比特整数序列
+// w: 整数比特数，不小于8（盒子数过少时单次试验只有个位数的球）
+func CollisionProto(words []uint64, w int) (float64, float64) {
+	if w < 8 {
+		panic("word size must be >= 8")
+	}
+	N := len(words)
+	if N < 128*minExpected {
+		panic("please provide valid test bits")
+	}
+	k := 6
+	for 1<<uint(k-2) <= N && k < 20 {
+		k++
+	}
+	if k > w {
+		k = w
+	}
+	m := 1 << uint(k)
+	n := m / 64
+	trials := N / n
+	C := collisionCount(words, w, k, n)
+
+	// 完整试验次数的二进制展开做卷积，再卷积不完整的最后一次试验
+	one := collisionDist(n, m)
+	total := offsetDist{p: []float64{1}}
+	for t := trials; t > 0; t >>= 1 {
+		if t&1 == 1 {
+			total = total.convolve(one)
+		}
+		if t > 1 {
+			one = one.convolve(one)
+		}
+	}
+	if r := N % n; r > 0 {
+		total = total.convolve(collisionDist(r, m))
+	}
+
+	var below, equal float64
+	for x, p := range total.p {
+		switch c := total.lo + x; {
+		case c < C:
+			below += p
+		case c == C:
+			equal += p
+		}
+	}
+	lower := below + equal/2
+	upper := 1 - below - equal/2
+	P := 2 * math.Min(lower, upper)
+	if P > 1 {
+		P = 1
+	}
+	if upper < 0 {
+		upper = 0
+	}
+	return P, upper
+}
+
+// collisionCount 每 n 个整数一次试验、取高 k 比特为盒子编号时的碰撞数之和
+// 末尾不足 n 个的整数作为最后一次试验。
+func collisionCount(words []uint64, w, k, n int) int {
+	used := make([]uint64, (1<<uint(k)+63)/64)
+	C := 0
+	for i, v := range words {
+		if i%n == 0 {
+			for j := range used {
+				used[j] = 0
+			}
+		}
+		y := wordTop(v, w, k)
+		if used[y>>6]&(1<<uint(y&63)) != 0 {
+			C++
+		}
+		used[y>>6] |= 1 << uint(y&63)
+	}
+	return C
+}
+
+// offsetDist 取值为 lo, lo+1, ... 的离散分布，p[x] 为取值 lo+x 的概率
+type offsetDist struct {
+	lo int
+	p  []float64
+}
+
+// distEps 分布两端裁剪的可忽略概率
+const distEps = 1e-30
+
+// trim 裁剪两端可忽略的概率
+func (d offsetDist) trim() offsetDist {
+	a, b := 0, len(d.p)
+	for a < b-1 && d.p[a] < distEps {
+		a++
+	}
+	for b-1 > a && d.p[b-1] < distEps {
+		b--
+	}
+	return offsetDist{lo: d.lo + a, p: d.p[a:b]}
+}
+
+// convolve 两个独立随机变量之和的分布
+func (d offsetDist) convolve(e offsetDist) offsetDist {
+	p := make([]float64, len(d.p)+len(e.p)-1)
+	for i, x := range d.p {
+		for j, y := range e.p {
+			p[i+j] += x * y
+		}
+	}
+	return offsetDist{lo: d.lo + e.lo, p: p}.trim()
+}
+
+// collisionDist n 个球随机落入 m 个盒子时碰撞数（n - 占用盒子数）的精确分布
+func collisionDist(n, m int) offsetDist {
+	// occ[j-lo] 为已占用 j 个盒子的概率，只保留概率不可忽略的范围
+	buf := [2][]float64{make([]float64, n+1), make([]float64, n+1)}
+	occ := offsetDist{p: buf[0][:1]}
+	occ.p[0] = 1
+	fm := float64(m)
+	for i := 0; i < n; i++ {
+		next := buf[(i+1)&1][:len(occ.p)+1]
+		for x := range next {
+			next[x] = 0
+		}
+		for x, p := range occ.p {
+			j := float64(occ.lo + x)
+			next[x] += p * j / fm
+			next[x+1] += p * (fm - j) / fm
+		}
+		occ = offsetDist{lo: occ.lo, p: next}.trim()
+		// 保持 occ.p 与 buf 起点一致，供下一轮复用缓冲区
+		copy(buf[(i+1)&1], occ.p)
+		occ.p = buf[(i+1)&1][:len(occ.p)]
+	}
+	// 碰撞数 c = n - j，按 c 递增排列
+	res := offsetDist{lo: n - (occ.lo + len(occ.p) - 1), p: make([]float64, len(occ.p))}
+	for x, p := range occ.p {
+		res.p[len(occ.p)-1-x] = p
+	}
+	return res
+}
+
+// SerialPairs 序列对检测，w=32
+func SerialPairs(data []byte) *TestResult {
+	p, q := SerialPairsTestBytes(data, 32, MSBFirst)
+	return &TestResult{Name: "序列对检测", P: p, Q: q, Pass: p >= Alpha}
+}
+
+// SerialPairsTestBytes 序列对检测
+// data: 检测序列
+// w: 整数比特数
+// order: 比特序
+func SerialPairsTestBytes(data []byte, w int, order BitOrder) (float64, float64) {
+	return SerialPairsProto(Words(data, w, order), w)
+}
+
+// SerialPairsProto 序列对检测
+// 取整数的高 k 比特得到 d=2^k 种取值，统计不重叠的整数对 (Y_2j, Y_2j+1) 的 d^2 种组合，
+// 使用自由度为 d^2-1 的卡方检验。k 取 1~4 中使每种组合的期望频数不小于5的最大值（不超过 w）。
+//
+// words: w 比特整数序列
+// w: 整数比特数
+func SerialPairsProto(words []uint64, w int) (float64, float64) {
+	pairs := len(words) / 2
+	k := 4
+	if k > w {
+		k = w
+	}
+	for k > 1 && pairs < 5<<uint(2*k) {
+		k--
+	}
+	d2 := 1 << uint(2*k)
+	if pairs < 5*d2 {
+		panic("please provide valid test bits")
+	}
+	counts := make([]int, d2)
+	for j := 0; j < pairs; j++ {
+		counts[wordTop(words[2*j], w, k)<<uint(k)|wordTop(words[2*j+1], w, k)]++
+	}
+	probs := make([]float64, d2)
+	for i := range probs {
+		probs[i] = 1 / float64(d2)
+	}
+	P := chiSquareP(counts, probs)
+	return P, P
+}
@@ -0,0 +1,155 @@
+package randomness
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestWords(t *testing.T) {
+	data := []byte{0x12, 0x34, 0x56, 0x78, 0x9a, 0xbc, 0xde, 0xf0}
+	be := Words(data, 32, MSBFirst)
+	le := Words(data, 32, LSBFirst)
+	for i := 0; i < 2; i++ {
+		if be[i] != uint64(binary.BigEndian.Uint32(data[i*4:])) || le[i] != uint64(binary.LittleEndian.Uint32(data[i*4:])) {
+			t.Fatalf("word %d: %x %x", i, be[i], le[i])
+		}
+	}
+	// 0x12 0x34 = 00010 01000 11010 0
+	if w := Words(data[:2], 5, MSBFirst); len(w) != 3 || w[0] != 0x02 || w[1] != 0x08 || w[2] != 0x1a {
+		t.Fatalf("w=5 MSBFirst: %v", w)
+	}
+	// 0x12 0x34 字节内低位在前 = 01001 00000 10110 0，先读到的比特为低位
+	if w := Words(data[:2], 5, LSBFirst); len(w) != 3 || w[0] != 0x12 || w[1] != 0x00 || w[2] != 0x0d {
+		t.Fatalf("w=5 LSBFirst: %v", w)
+	}
+}
+
+func TestPermutationIndex(t *testing.T) {
+	// 每种排列恰好出现一次时，各类别频数相同
+	perms := [][]uint64{{}}
+	for k := 0; k < 5; k++ {
+		var next [][]uint64
+		for _, p := range perms {
+			for pos := 0; pos <= len(p); pos++ {
+				q := append(append(append([]uint64{}, p[:pos]...), uint64(k)), p[pos:]...)
+				next = append(next, q)
+			}
+		}
+		perms = next
+	}
+	var words []uint64
+	for i := 0; i < 5; i++ {
+		for _, p := range perms {
+			words = append(words, p...)
+		}
+	}
+	if p, _ := PermutationProto(words, 32); p != 1 {
+		t.Fatalf("P = %f, want 1", p)
+	}
+}
+
+func TestKnuthTestsRandom(t *testing.T) {
+	data := make([]byte, 125000)
+	rand.New(rand.NewSource(1)).Read(data)
+	for _, cfg := range []struct {
+		w     int
+		order BitOrder
+	}{{32, MSBFirst}, {8, LSBFirst}, {13, MSBFirst}} {
+		for _, res := range KnuthTests(data, cfg.w, cfg.order) {
+			fmt.Printf("%s P-value: %f, Q-value: %f\n", res.Name, res.P, res.Q)
+			if !res.Pass {
+				t.Fatalf("%s failed on random data", res.Name)
+			}
+		}
+	}
+}
+
+func TestKnuthTestsDefect(t *testing.T) {
+	// 线性同余发生器 x = 69069x + 1 mod 2^32 的低16比特周期仅为 2^16
+	data := make([]byte, 125000)
+	x := uint32(1)
+	for i := 0; i < len(data); i += 4 {
+		x = 69069*x + 1
+		binary.LittleEndian.PutUint32(data[i:], x)
+	}
+	failed := 0
+	for _, res := range KnuthTests(data, 16, LSBFirst) {
+		fmt.Printf("%s P-value: %f\n", res.Name, res.P)
+		if !res.Pass {
+			failed++
+		}
+	}
+	if failed == 0 {
+		t.Fatalf("no test detected the weak generator")
+	}
+
+	// 重复的整数
+	r := rand.New(rand.NewSource(2))
+	for i := 0; i < len(data); i += 8 {
+		r.Read(data[i : i+4])
+		copy(data[i+4:i+8], data[i:i+4])
+	}
+	if res := Collision(data); res.Pass {
+		t.Fatalf("collision test passed on repeated words, P = %f", res.P)
+	}
+}
+
+func TestCollisionDist(t *testing.T) {
+	// 3 个球落入 4 个盒子：无碰撞 4*3*2/64，两次碰撞 4/64，一次碰撞 36/64
+	d := collisionDist(3, 4)
+	if d.lo != 0 || len(d.p) != 3 {
+		t.Fatalf("support: lo=%d len=%d", d.lo, len(d.p))
+	}
+	for c, want := range []float64{24.0 / 64, 36.0 / 64, 4.0 / 64} {
+		if math.Abs(d.p[c]-want) > 1e-15 {
+			t.Fatalf("P(C=%d) = %v, want %v", c, d.p[c], want)
+		}
+	}
+	// 两次独立试验之和
+	s := d.convolve(d)
+	if math.Abs(s.p[0]-24.0*24/4096) > 1e-15 || math.Abs(s.p[4]-16.0/4096) > 1e-15 {
+		t.Fatalf("convolution: %v", s.p)
+	}
+}
+
+func TestCollisionCount(t *testing.T) {
+	// N=2000 时 k=13，n=128，15次完整试验和80个整数的不完整试验；试验内盒子编号互不相同
+	const N, w, k, n = 2000, 32, 13, 128
+	words := make([]uint64, N)
+	for i := range words {
+		words[i] = uint64(i%n) << (w - k)
+	}
+	p0, _ := CollisionProto(words, w)
+	if C := collisionCount(words, w, k, n); C != 0 {
+		t.Fatalf("C = %d, want 0", C)
+	}
+	// 后半段的整数同样参与计数，包括不完整的最后一次试验
+	for _, i := range []int{N/2 + 1, N - 1} {
+		words[i] = words[i-1]
+		if C := collisionCount(words, w, k, n); C != 1 {
+			t.Fatalf("word %d: C = %d, want 1", i, C)
+		}
+		if p, _ := CollisionProto(words, w); p == p0 {
+			t.Fatalf("word %d: P unchanged", i)
+		}
+		words[i] = uint64(i%n) << (w - k)
+	}
+}
+
+func TestCollisionUniform(t *testing.T) {
+	// 原假设下P值服从均匀分布，w=8 时盒子数最少，离散性最强
+	data := make([]byte, 125000)
+	for _, w := range []int{8, 32} {
+		ps := make([]float64, 200)
+		for i := range ps {
+			rand.New(rand.NewSource(int64(i))).Read(data)
+			ps[i], _ = CollisionTestBytes(data, w, MSBFirst)
+		}
+		if p := KolmogorovSmirnov(ps); p < 0.001 {
+			t.Fatalf("w=%d: P-values not uniform, KS P = %f", w, p)
+		}
+	}
+}
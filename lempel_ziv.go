@@ -0,0 +1,223 @@
+// Copyright (c) 2021 Quan guanyu
+// randomness is licensed under Mulan PSL v2.
+// You can use this software according to the terms and conditions of the Mulan PSL v2.
+// You may obtain a copy of Mulan PSL v2 at:
+//          http://license.coscl.org.cn/MulanPSL2
+// THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND,
+// EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT,
+// MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+// See the Mulan PSL v2 for more details.
+
+package randomness
+
+import (
+	"fmt"
+	"math"
+)
+
+// lzMoments 随机序列下短语个数的均值与方差
+// 由 crypto/rand 产生的随机序列模拟标定，见 lempel_ziv_test.go 中的 TestLempelZivCalibrate。
+type lzMoments struct {
+	Mean     float64
+	Variance float64
+}
+
+// lz76Moments LZ76 复杂度在各支持长度下的均值与方差
+var lz76Moments = map[int]lzMoments{
+	20000:   {1432.9507, 21.8413},   // 4000个样本
+	1000000: {50780.0535, 402.3242}, // 4000个样本
+}
+
+// lz78Moments LZ78 短语个数在各支持长度下的均值与方差
+// 10^8 比特的样本标定耗时较长，仅使用了300个样本，方差的相对误差约为8%。
+var lz78Moments = map[int]lzMoments{
+	20000:     {2138.1240, 5.1109},       // 4000个样本
+	1000000:   {69588.2050, 72.3601},     // 4000个样本
+	100000000: {4877773.2433, 2640.4314}, // 300个样本
+}
+
+// LempelZivComplexity Lempel-Ziv 复杂度检测（LZ76）
+func LempelZivComplexity(data []byte) *TestResult {
+	p, q := LempelZivComplexityTestBytes(data)
+	return &TestResult{Name: "Lempel-Ziv复杂度检测", P: p, Q: q, Pass: p >= Alpha}
+}
+
+// LempelZivComplexityTestBytes Lempel-Ziv 复杂度检测（LZ76）
+// data: 检测序列
+func LempelZivComplexityTestBytes(data []byte) (float64, float64) {
+	return lempelZivComplexity(func(i int) bool { return data[i>>3]&(0x80>>uint(i&7)) != 0 }, len(data)*8)
+}
+
+// LempelZivComplexityTest Lempel-Ziv 复杂度检测（LZ76）
+// 按 Lempel 与 Ziv（1976）及 Kaspar-Schuster 算法将序列分解为分量，每个分量为此前已出现过
+// （允许与自身重叠）的最长子串加上一个新比特，复杂度 c(n) 为分量个数（含末尾不完整的分量）。
+// 可压缩（存在重复结构）的序列复杂度偏低。
+//
+// 统计量 V = (c(n) - μ)/σ，μ、σ^2 为随机序列下 c(n) 的均值与方差，
+// 由模拟标定，支持的序列长度 n 为 2*10^4、10^6。
+//
+// bits: 检测序列
+func LempelZivComplexityTest(bits []bool) (float64, float64) {
+	return lempelZivComplexity(func(i int) bool { return bits[i] }, len(bits))
+}
+
+func lempelZivComplexity(bit func(i int) bool, n int) (float64, float64) {
+	mo, ok := lz76Moments[n]
+	if !ok {
+		panic(fmt.Sprintf("Lempel-Ziv complexity test: unsupported length %d", n))
+	}
+	return lzPValue(float64(lz76Phrases(bit, n)), mo)
+}
+
+// LempelZivCompression Lempel-Ziv 压缩检测（LZ78）
+func LempelZivCompression(data []byte) *TestResult {
+	p, q := LempelZivCompressionTestBytes(data)
+	return &TestResult{Name: "Lempel-Ziv压缩检测", P: p, Q: q, Pass: p >= Alpha}
+}
+
+// LempelZivCompressionTestBytes Lempel-Ziv 压缩检测（LZ78）
+// data: 检测序列
+func LempelZivCompressionTestBytes(data []byte) (float64, float64) {
+	return lempelZivCompression(func(i int) bool { return data[i>>3]&(0x80>>uint(i&7)) != 0 }, len(data)*8)
+}
+
+// LempelZivCompressionTest Lempel-Ziv 压缩检测（LZ78）
+// 按 LZ78 将序列依次分解为此前未出现过的最短短语，统计短语个数 W（含末尾不完整的短语），
+// 即 LZ78 压缩后的码字个数。可压缩的序列短语个数偏少。
+//
+// NIST SP 800-22 早期版本中的该检测因均值与方差标定有误而被删除，
+// 这里使用的均值与方差由模拟重新标定，支持的序列长度 n 为 2*10^4、10^6、10^8。
+// 统计量 V = (W - μ)/σ。
+//
+// bits: 检测序列
+func LempelZivCompressionTest(bits []bool) (float64, float64) {
+	return lempelZivCompression(func(i int) bool { return bits[i] }, len(bits))
+}
+
+func lempelZivCompression(bit func(i int) bool, n int) (float64, float64) {
+	mo, ok := lz78Moments[n]
+	if !ok {
+		panic(fmt.Sprintf("Lempel-Ziv compression test: unsupported length %d", n))
+	}
+	return lzPValue(float64(lz78Phrases(bit, n)), mo)
+}
+
+func lzPValue(W float64, mo lzMoments) (float64, float64) {
+	V := (W - mo.Mean) / math.Sqrt(mo.Variance)
+	P := math.Erfc(math.Abs(V) / math.Sqrt2)
+	Q := math.Erfc(V/math.Sqrt2) / 2
+	return P, Q
+}
+
+// lz78Phrases LZ78 分解的短语个数
+// 使用二叉字典树保存已出现的短语，child[2*i+b] 为节点 i 的子节点，0 表示不存在。
+func lz78Phrases(bit func(i int) bool, n int) int {
+	child := make([]int32, 2, 2*(n/16+2))
+	node, W := 0, 0
+	for i := 0; i < n; i++ {
+		k := 2 * node
+		if bit(i) {
+			k++
+		}
+		if c := child[k]; c != 0 {
+			node = int(c)
+			continue
+		}
+		child[k] = int32(len(child) / 2)
+		child = append(child, 0, 0)
+		W++
+		node = 0
+	}
+	if node != 0 {
+		W++
+	}
+	return W
+}
+
+// lz76Phrases LZ76 复杂度（Kaspar-Schuster 分量个数）
+// 对整个序列构造后缀自动机，并记录每个状态的最早结束位置 first。
+// 从位置 p 开始的子串 u 在 p 之前出现（允许重叠）当且仅当 first(u) - |u| + 1 < p，
+// 因此沿自动机转移逐比特延长分量即可，总复杂度 O(n)。
+func lz76Phrases(bit func(i int) bool, n int) int {
+	s := make([]byte, n)
+	for i := range s {
+		if bit(i) {
+			s[i] = 1
+		}
+	}
+	sam := newSuffixAutomaton(n)
+	for _, c := range s {
+		sam.extend(c)
+	}
+
+	c := 0
+	for p := 0; p < n; {
+		state, l := int32(0), 0
+		for p+l < n {
+			nx := sam.next[state][s[p+l]]
+			if nx == 0 || int(sam.first[nx])-l >= p {
+				break
+			}
+			state = nx
+			l++
+		}
+		c++
+		p += l + 1
+	}
+	return c
+}
+
+// suffixAutomaton 二元序列的后缀自动机
+type suffixAutomaton struct {
+	length []int32    // 状态对应的最长子串长度
+	link   []int32    // 后缀链接
+	next   [][2]int32 // 转移，0 表示不存在（初始状态不会是转移目标）
+	first  []int32    // 状态对应子串的最早结束位置
+	last   int32
+}
+
+func newSuffixAutomaton(n int) *suffixAutomaton {
+	capacity := 2*n + 1
+	a := &suffixAutomaton{
+		length: make([]int32, 1, capacity),
+		link:   make([]int32, 1, capacity),
+		next:   make([][2]int32, 1, capacity),
+		first:  make([]int32, 1, capacity),
+	}
+	a.link[0] = -1
+	a.first[0] = -1
+	return a
+}
+
+func (a *suffixAutomaton) newState(length, link, first int32, next [2]int32) int32 {
+	a.length = append(a.length, length)
+	a.link = append(a.link, link)
+	a.first = append(a.first, first)
+	a.next = append(a.next, next)
+	return int32(len(a.length) - 1)
+}
+
+func (a *suffixAutomaton) extend(c byte) {
+	l := a.length[a.last] + 1
+	cur := a.newState(l, 0, l-1, [2]int32{})
+	p := a.last
+	for p != -1 && a.next[p][c] == 0 {
+		a.next[p][c] = cur
+		p = a.link[p]
+	}
+	if p != -1 {
+		q := a.next[p][c]
+		if a.length[p]+1 == a.length[q] {
+			a.link[cur] = q
+		} else {
+			clone := a.newState(a.length[p]+1, a.link[q], a.first[q], a.next[q])
+			for p != -1 && a.next[p][c] == q {
+				a.next[p][c] = clone
+				p = a.link[p]
+			}
+			a.link[q] = clone
+			a.link[cur] = clone
+		}
+	}
+	a.last = cur
+}
@@ -0,0 +1,127 @@
+package randomness
+
+import (
+	"crypto/rand"
+	"fmt"
+	"math"
+	rand2 "math/rand"
+	"os"
+	"strings"
+	"testing"
+)
+
+// kasparSchuster LZ76 复杂度的直接实现
+func kasparSchuster(s string) int {
+	c, p := 0, 0
+	for p < len(s) {
+		l := 1
+		for p+l <= len(s) && strings.Contains(s[:p+l-1], s[p:p+l]) {
+			l++
+		}
+		c++
+		p += l
+	}
+	return c
+}
+
+func TestLZ76Phrases(t *testing.T) {
+	s := "0001101001000101"
+	if c := lz76Phrases(func(i int) bool { return s[i] == '1' }, len(s)); c != 6 {
+		t.Fatalf("c = %d, want 6", c)
+	}
+	r := rand2.New(rand2.NewSource(1))
+	for i := 0; i < 200; i++ {
+		b := make([]byte, 1+r.Intn(300))
+		for j := range b {
+			b[j] = '0'
+			if r.Intn(3) == 0 {
+				b[j] = '1'
+			}
+		}
+		s := string(b)
+		if c, want := lz76Phrases(func(i int) bool { return s[i] == '1' }, len(s)), kasparSchuster(s); c != want {
+			t.Fatalf("%s: c = %d, want %d", s, c, want)
+		}
+	}
+}
+
+func TestLZ78Phrases(t *testing.T) {
+	// 0|1|00|01|10|11|000|0
+	s := "0100011011000" + "0"
+	if W := lz78Phrases(func(i int) bool { return s[i] == '1' }, len(s)); W != 8 {
+		t.Fatalf("W = %d, want 8", W)
+	}
+}
+
+func TestLempelZivE(t *testing.T) {
+	bits := getEConstantBits()
+	data := getEConstantBytes()
+	for _, c := range []struct {
+		n                  int
+		wantLZ76, wantLZ78 [2]string
+	}{
+		{20000, [2]string{"0.514098", "0.257049"}, [2]string{"0.023419", "0.988290"}},
+		// e 的前10^6比特 W = 69559，比随机序列的均值少约3.4个标准差
+		{1000000, [2]string{"0.619975", "0.309987"}, [2]string{"0.000596", "0.999702"}},
+	} {
+		p1, q1 := LempelZivComplexityTest(bits[:c.n])
+		p2, q2 := LempelZivComplexityTestBytes(data[:c.n/8])
+		fmt.Printf("n: %v, LZ76 P-value: %f, Q-value: %f\n", c.n, p1, q1)
+		if fmt.Sprintf("%.6f", p1) != c.wantLZ76[0] || fmt.Sprintf("%.6f", q1) != c.wantLZ76[1] || p1 != p2 || q1 != q2 {
+			t.Fatalf("n=%d LZ76 %f %f, bytes %f %f", c.n, p1, q1, p2, q2)
+		}
+		p1, q1 = LempelZivCompressionTest(bits[:c.n])
+		p2, q2 = LempelZivCompressionTestBytes(data[:c.n/8])
+		fmt.Printf("n: %v, LZ78 P-value: %f, Q-value: %f\n", c.n, p1, q1)
+		if fmt.Sprintf("%.6f", p1) != c.wantLZ78[0] || fmt.Sprintf("%.6f", q1) != c.wantLZ78[1] || p1 != p2 || q1 != q2 {
+			t.Fatalf("n=%d LZ78 %f %f, bytes %f %f", c.n, p1, q1, p2, q2)
+		}
+	}
+}
+
+func TestLempelZivCompressible(t *testing.T) {
+	// 每320比特的前16比特重复前一块的前16比特
+	bits := append([]bool(nil), getEConstantBits()[:20000]...)
+	for i := 320; i+16 <= len(bits); i += 320 {
+		copy(bits[i:i+16], bits[i-320:])
+	}
+	p, q := LempelZivComplexityTest(bits)
+	fmt.Printf("LZ76 P-value: %f, Q-value: %f\n", p, q)
+	if fmt.Sprintf("%.6f", p) != "0.000287" || fmt.Sprintf("%.6f", q) != "0.999857" {
+		t.FailNow()
+	}
+	p, q = LempelZivCompressionTest(bits)
+	fmt.Printf("LZ78 P-value: %f, Q-value: %f\n", p, q)
+	if fmt.Sprintf("%.6f", p) != "0.000008" || fmt.Sprintf("%.6f", q) != "0.999996" {
+		t.FailNow()
+	}
+}
+
+// TestLempelZivCalibrate 模拟标定随机序列下短语个数的均值与方差
+// 耗时较长，仅在设置环境变量 RANDOMNESS_LZ_CALIBRATE=<样本数> 时运行。
+func TestLempelZivCalibrate(t *testing.T) {
+	var samples int
+	if _, err := fmt.Sscan(os.Getenv("RANDOMNESS_LZ_CALIBRATE"), &samples); err != nil || samples <= 1 {
+		t.Skip("set RANDOMNESS_LZ_CALIBRATE=<samples> to run")
+	}
+	moments := func(n int, count func(bit func(i int) bool, n int) int) lzMoments {
+		data := make([]byte, n/8)
+		var sum, sum2 float64
+		for i := 0; i < samples; i++ {
+			_, _ = rand.Read(data)
+			W := float64(count(func(i int) bool { return data[i>>3]&(0x80>>uint(i&7)) != 0 }, n))
+			sum += W
+			sum2 += W * W
+		}
+		mean := sum / float64(samples)
+		return lzMoments{Mean: mean, Variance: (sum2 - float64(samples)*mean*mean) / float64(samples-1)}
+	}
+	for n := range lz76Moments {
+		m := moments(n, lz76Phrases)
+		fmt.Printf("LZ76 n=%d: {%.4f, %.4f} sd=%.4f\n", n, m.Mean, m.Variance, math.Sqrt(m.Variance))
+	}
+	for n := range lz78Moments {
+		m := moments(n, lz78Phrases)
+		fmt.Printf("LZ78 n=%d: {%.4f, %.4f} sd=%.4f\n", n, m.Mean, m.Variance, math.Sqrt(m.Variance))
+	}
+}
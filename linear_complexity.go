@@ -59,18 +59,9 @@ func LinearComplexityProtoSerial(bits []bool, m int) (float64, float64) {
 	N := n / m
 
 	var v = [7]float64{0.0, 0.0, 0.0, 0.0, 0.0, 0.0, 0.0}
-	var pi = [7]float64{0.010417, 0.03125, 0.12500, 0.5000, 0.25000, 0.06250, 0.020833}
-	var V float64 = 0.0
-	var P float64 = 0
 
 	// Step 3, miu - 预计算 _1_m
-	var _1_m float64
-	if m%2 == 0 {
-		_1_m = 1.0
-	} else {
-		_1_m = -1.0
-	}
-	miu := float64(m)/2.0 + (9.0+_1_m)/36.0 - (float64(m)/3.0+2.0/9.0)/math.Pow(2.0, float64(m))
+	_1_m, miu := linearComplexityMiu(m)
 
 	// 预分配数组，避免重复分配
 	arr := make([]bool, m)
@@ -87,34 +78,10 @@ func LinearComplexityProtoSerial(bits []bool, m int) (float64, float64) {
 		complexity := linearComplexity(arr, m)
 		T := _1_m*(float64(complexity)-miu) + 2.0/9.0
 
-		// 优化条件判断顺序，从最可能的情况开始
-		if T > 2.5 {
-			v[6]++
-		} else if T > 1.5 {
-			v[5]++
-		} else if T > 0.5 {
-			v[4]++
-		} else if T > -0.5 {
-			v[3]++
-		} else if T > -1.5 {
-			v[2]++
-		} else if T > -2.5 {
-			v[1]++
-		} else {
-			v[0]++
-		}
+		v[linearComplexityClass(T)]++
 	}
 
-	// Step 6 - 优化循环，使用局部变量
-	NFloat := float64(N)
-	for i := 0; i < 7; i++ {
-		diff := v[i] - NFloat*pi[i]
-		V += diff * diff / (NFloat * pi[i])
-	}
-
-	// Step 7
-	P = igamc(3.0, V/2.0)
-
+	P := linearComplexityP(v, N)
 	return P, P
 }
 
@@ -124,18 +91,9 @@ func LinearComplexityProtoParallel(bits []bool, m int) (float64, float64) {
 	N := n / m
 
 	var v = [7]float64{0.0, 0.0, 0.0, 0.0, 0.0, 0.0, 0.0}
-	var pi = [7]float64{0.010417, 0.03125, 0.12500, 0.5000, 0.25000, 0.06250, 0.020833}
-	var V float64 = 0.0
-	var P float64 = 0
 
 	// Step 3, miu - 预计算 _1_m
-	var _1_m float64
-	if m%2 == 0 {
-		_1_m = 1.0
-	} else {
-		_1_m = -1.0
-	}
-	miu := float64(m)/2.0 + (9.0+_1_m)/36.0 - (float64(m)/3.0+2.0/9.0)/math.Pow(2.0, float64(m))
+	_1_m, miu := linearComplexityMiu(m)
 
 	// 并行计算各个块的线性复杂度
 	numWorkers := runtime.NumCPU()
@@ -168,21 +126,7 @@ func LinearComplexityProtoParallel(bits []bool, m int) (float64, float64) {
 				T := _1_m*(float64(complexity)-miu) + 2.0/9.0
 
 				// 分类统计
-				if T > 2.5 {
-					localV[6]++
-				} else if T > 1.5 {
-					localV[5]++
-				} else if T > 0.5 {
-					localV[4]++
-				} else if T > -0.5 {
-					localV[3]++
-				} else if T > -1.5 {
-					localV[2]++
-				} else if T > -2.5 {
-					localV[1]++
-				} else {
-					localV[0]++
-				}
+				localV[linearComplexityClass(T)]++
 			}
 
 			results <- localV
@@ -208,7 +152,48 @@ func LinearComplexityProtoParallel(bits []bool, m int) (float64, float64) {
 		}
 	}
 
-	// Step 6 - 优化循环，使用局部变量
+	P := linearComplexityP(v, N)
+	return P, P
+}
+
+// linearComplexityMiu 长度为 m 的随机序列线性复杂度的均值 miu 及 (-1)^m
+func linearComplexityMiu(m int) (_1_m float64, miu float64) {
+	if m%2 == 0 {
+		_1_m = 1.0
+	} else {
+		_1_m = -1.0
+	}
+	miu = float64(m)/2.0 + (9.0+_1_m)/36.0 - (float64(m)/3.0+2.0/9.0)/math.Pow(2.0, float64(m))
+	return
+}
+
+// linearComplexityClass 统计量 T 所属的类别
+func linearComplexityClass(T float64) int {
+	// 按区间从高到低判断
+	switch {
+	case T > 2.5:
+		return 6
+	case T > 1.5:
+		return 5
+	case T > 0.5:
+		return 4
+	case T > -0.5:
+		return 3
+	case T > -1.5:
+		return 2
+	case T > -2.5:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// linearComplexityP 由 N 个块的类别频数 v 计算线性复杂度检测P值
+func linearComplexityP(v [7]float64, N int) float64 {
+	var pi = [7]float64{0.010417, 0.03125, 0.12500, 0.5000, 0.25000, 0.06250, 0.020833}
+	var V float64 = 0.0
+
+	// Step 6
 	NFloat := float64(N)
 	for i := 0; i < 7; i++ {
 		diff := v[i] - NFloat*pi[i]
@@ -216,7 +201,5 @@ func LinearComplexityProtoParallel(bits []bool, m int) (float64, float64) {
 	}
 
 	// Step 7
-	P = igamc(3.0, V/2.0)
-
-	return P, P
+	return igamc(3.0, V/2.0)
 }
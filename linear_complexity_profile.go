@@ -0,0 +1,267 @@
+// Copyright (c) 2021 Quan guanyu
+// randomness is licensed under Mulan PSL v2.
+// You can use this software according to the terms and conditions of the Mulan PSL v2.
+// You may obtain a copy of Mulan PSL v2 at:
+//          http://license.coscl.org.cn/MulanPSL2
+// THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND,
+// EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT,
+// MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+// See the Mulan PSL v2 for more details.
+
+package randomness
+
+import (
+	"math"
+	"math/bits"
+)
+
+// LinearComplexityProfileMaxBits 线性复杂度曲线检测分析的最大比特数，各检测函数只分析序列的前 LinearComplexityProfileMaxBits 比特
+// Berlekamp-Massey 算法的时间复杂度为 O(n^2)，10^6 比特约需数秒，10^8 比特需数小时，分析更长的序列时请调大该值。
+var LinearComplexityProfileMaxBits = 1000000
+
+// LinearComplexityProfileResult 线性复杂度曲线检测结果
+type LinearComplexityProfileResult struct {
+	N           int     // 分析的序列长度 n
+	L           int     // 整个序列的线性复杂度 L_n，随机序列约为 n/2
+	Jumps       int     // 线性复杂度曲线的跳变次数，随机序列约为 n/4
+	Window      int     // 统计跳变次数的窗口长度 m
+	WindowJumps []int   // 各窗口内的跳变次数
+	JumpP       float64 // 跳变次数检测P值
+	Heights     []int   // 跳变高度分布，Heights[h-1] 为高度为 h 的跳变次数，最后一项为高度不小于 len(Heights) 的跳变次数
+	HeightP     float64 // 跳变高度检测P值
+	Polynomial  []int   // 线性复杂度较小（L<=n/4）时，生成序列的最短LFSR连接多项式中系数为1的项的次数（降序），否则为nil
+}
+
+// LinearComplexityProfile 线性复杂度曲线检测，m=10000
+// 分析序列的前 LinearComplexityProfileMaxBits 比特。
+func LinearComplexityProfile(data []byte) *TestResult {
+	p1, p2, q1, q2 := LinearComplexityProfileTestBytes(data, 10000)
+	return &TestResult{
+		Name: "线性复杂度曲线检测",
+		P:    p1, P2: p2,
+		Q: q1, Q2: q2,
+		Pass: math.Min(p1, p2) >= Alpha,
+	}
+}
+
+// LinearComplexityProfileTest 线性复杂度曲线检测，m=10000
+// 分析序列的前 LinearComplexityProfileMaxBits 比特。
+// bits: 检测序列
+// return:
+//
+//	p1: 跳变高度检测 P-value
+//	p2: 跳变次数检测 P-value
+func LinearComplexityProfileTest(bits []bool) (p1 float64, p2 float64, q1 float64, q2 float64) {
+	return LinearComplexityProfileProto(bits, 10000)
+}
+
+// LinearComplexityProfileTestBytes 线性复杂度曲线检测
+// 分析序列的前 LinearComplexityProfileMaxBits 比特。
+// data: 检测序列
+// m: 统计跳变次数的窗口长度
+// return:
+//
+//	p1: 跳变高度检测 P-value
+//	p2: 跳变次数检测 P-value
+func LinearComplexityProfileTestBytes(data []byte, m int) (p1 float64, p2 float64, q1 float64, q2 float64) {
+	r := LinearComplexityProfileAnalyze(func(i int) bool { return data[i>>3]&(0x80>>uint(i&7)) != 0 }, len(data)*8, m)
+	return r.HeightP, r.JumpP, r.HeightP, r.JumpP
+}
+
+// LinearComplexityProfileProto 线性复杂度曲线检测
+// 分析序列的前 LinearComplexityProfileMaxBits 比特。
+// bits: 检测序列
+// m: 统计跳变次数的窗口长度
+// return:
+//
+//	p1: 跳变高度检测 P-value
+//	p2: 跳变次数检测 P-value
+func LinearComplexityProfileProto(bits []bool, m int) (p1 float64, p2 float64, q1 float64, q2 float64) {
+	r := LinearComplexityProfileAnalyze(func(i int) bool { return bits[i] }, len(bits), m)
+	return r.HeightP, r.JumpP, r.HeightP, r.JumpP
+}
+
+// LinearComplexityProfileAnalyze 线性复杂度曲线分析
+// 使用 Berlekamp-Massey 算法计算整个序列的线性复杂度曲线 L_1, L_2, ..., L_n（Rueppel）。
+// 曲线在 L_N <= N/2 且下一比特与当前最短LFSR的输出不一致时跳变为 N+1-L_N。
+//
+// 对随机序列，每一比特的不一致概率为 1/2 且相互独立，可推出：
+//   - 跳变高度相互独立且服从参数为 1/2 的几何分布，P(h=k)=2^-k，使用卡方检验跳变高度分布；
+//   - 长度为 m 的窗口内跳变次数近似服从均值 m/4、方差 m/8 的正态分布，
+//     对 n/m 个窗口的跳变次数使用自由度为 n/m 的卡方检验。
+//
+// LFSR 生成的序列或种子不足的发生器会使曲线在 L 处停止增长，跳变次数检测随即失败，
+// 此时 Polynomial 给出可生成该序列的最短LFSR连接多项式。
+//
+// 只分析序列的前 LinearComplexityProfileMaxBits 比特，结果中的 N 为实际分析的长度。
+//
+// bit: 获取序列第 i 比特
+// n: 序列长度
+// m: 统计跳变次数的窗口长度
+func LinearComplexityProfileAnalyze(bit func(i int) bool, n, m int) *LinearComplexityProfileResult {
+	if n > LinearComplexityProfileMaxBits {
+		n = LinearComplexityProfileMaxBits
+	}
+	if m <= 0 || n < m {
+		panic("please provide valid test bits")
+	}
+	K := n / m
+	r := &LinearComplexityProfileResult{N: n, Window: m, WindowJumps: make([]int, K)}
+	var heights []int
+	L, C := berlekampMassey(bit, n, func(N, h int) {
+		heights = append(heights, h)
+		if N/m < K {
+			r.WindowJumps[N/m]++
+		}
+	})
+	r.L = L
+	r.Jumps = len(heights)
+
+	// 跳变次数
+	var V float64 = 0
+	mean, variance := float64(m)/4, float64(m)/8
+	for _, j := range r.WindowJumps {
+		d := float64(j) - mean
+		V += d * d / variance
+	}
+	r.JumpP = igamc(float64(K)/2, V/2)
+
+	// 跳变高度，合并期望频数小于5的尾部类别
+	k := 2
+	for k < 16 && float64(r.Jumps)*math.Pow(2, -float64(k)) >= 5 {
+		k++
+	}
+	r.Heights = make([]int, k)
+	for _, h := range heights {
+		if h > k {
+			h = k
+		}
+		r.Heights[h-1]++
+	}
+	V = 0
+	J := float64(r.Jumps)
+	if J > 0 {
+		for i, c := range r.Heights {
+			pi := math.Pow(2, -float64(i+1))
+			if i == k-1 {
+				pi *= 2
+			}
+			d := float64(c) - J*pi
+			V += d * d / (J * pi)
+		}
+		r.HeightP = igamc(float64(k-1)/2, V/2)
+	}
+
+	if 4*L <= n {
+		for i := L; i >= 0; i-- {
+			if C[i>>6]&(1<<uint(i&63)) != 0 {
+				r.Polynomial = append(r.Polynomial, i)
+			}
+		}
+	}
+	return r
+}
+
+// berlekampMassey 比特打包的 Berlekamp-Massey 算法
+// 返回序列的线性复杂度 L 与最短LFSR连接多项式 C(x) = 1 + c_1 x + ... + c_L x^L，
+// C 的第 i 比特为 c_i。每次线性复杂度跳变时调用 onJump(N, h)，N 为引起跳变的比特序号，h 为跳变高度。
+//
+// bit: 获取序列第 i 比特
+// n: 序列长度
+func berlekampMassey(bit func(i int) bool, n int, onJump func(N, h int)) (int, []uint64) {
+	return newBMBuffers(n).run(bit, n, onJump)
+}
+
+// bmBuffers Berlekamp-Massey 算法的工作区，逐块计算线性复杂度时重复使用，避免每块分配
+type bmBuffers struct {
+	shifted [][]uint64 // shifted[b] 为逆序序列右移 b 比特的副本，shifted[0] 为逆序序列
+	C, B, T []uint64
+}
+
+// newBMBuffers 创建长度不超过 n 的序列使用的工作区
+func newBMBuffers(n int) *bmBuffers {
+	words := n/64 + 2
+	w := &bmBuffers{shifted: make([][]uint64, 64), C: make([]uint64, words), B: make([]uint64, words), T: make([]uint64, words)}
+	for b := range w.shifted {
+		w.shifted[b] = make([]uint64, words)
+	}
+	return w
+}
+
+// run 对长度为 n 的序列执行 Berlekamp-Massey 算法，返回的 C 在下次调用前有效
+func (w *bmBuffers) run(bit func(i int) bool, n int, onJump func(N, h int)) (int, []uint64) {
+	words := n/64 + 2
+	// 逆序存放的序列，rev 第 j 比特为 s_{n-1-j}，
+	// 第 N 步的差值 d = Σ c_i s_{N-i} 即 C 与 rev 从 n-1-N 开始的比特按位与的奇偶性
+	rev := w.shifted[0][:words]
+	for k := range rev {
+		rev[k] = 0
+	}
+	for i := 0; i < n; i++ {
+		if bit(i) {
+			j := n - 1 - i
+			rev[j>>6] |= 1 << uint(j&63)
+		}
+	}
+	// 预先计算 rev 右移 0..63 比特的副本，避免每一步拼接非对齐的字
+	var shifted [64][]uint64
+	shifted[0] = rev
+	for b := uint(1); b < 64; b++ {
+		s := w.shifted[b][:words]
+		for k := 0; k+1 < words; k++ {
+			s[k] = rev[k]>>b | rev[k+1]<<(64-b)
+		}
+		s[words-1] = 0
+		shifted[b] = s
+	}
+	C, B, T := w.C[:words], w.B[:words], w.T[:words]
+	for k := range C {
+		C[k], B[k] = 0, 0
+	}
+	C[0], B[0] = 1, 1
+	// B 为第 m 步更新前的 C，次数不超过 lB
+	L, m, lB := 0, -1, 0
+
+	for N := 0; N < n; N++ {
+		o := n - 1 - N
+		r := shifted[o&63][o>>6:]
+		var acc uint64
+		for k, c := range C[:L>>6+1] {
+			acc ^= c & r[k]
+		}
+		if bits.OnesCount64(acc)&1 == 0 {
+			continue
+		}
+		jump := 2*L <= N
+		if jump {
+			copy(T[:L>>6+1], C[:L>>6+1])
+		}
+		// C = C + x^(N-m) B
+		shift := N - m
+		dst := C[shift>>6:]
+		src := B[:lB>>6+1]
+		if bs := uint(shift & 63); bs == 0 {
+			for k, v := range src {
+				dst[k] ^= v
+			}
+		} else {
+			dst = dst[:len(src)+1]
+			for k, v := range src {
+				dst[k] ^= v << bs
+				dst[k+1] ^= v >> (64 - bs)
+			}
+		}
+		if jump {
+			onJump(N, N+1-2*L)
+			// 由 T 覆盖 B，先清除 B 的旧的高位
+			for k := range src {
+				src[k] = 0
+			}
+			copy(B[:L>>6+1], T[:L>>6+1])
+			lB = L
+			L = N + 1 - L
+			m = N
+		}
+	}
+	return L, C
+}
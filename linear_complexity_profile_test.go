@@ -0,0 +1,78 @@
+package randomness
+
+import (
+	"fmt"
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+func TestBerlekampMasseyMatchLinearComplexity(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for _, n := range []int{1, 2, 63, 64, 65, 130, 500} {
+		for i := 0; i < 20; i++ {
+			bits := make([]bool, n)
+			for j := range bits {
+				bits[j] = r.Intn(4) == 0
+			}
+			L, _ := berlekampMassey(func(i int) bool { return bits[i] }, n, func(N, h int) {})
+			if want := linearComplexity(bits, n); L != want {
+				t.Fatalf("n=%d L=%d, want %d", n, L, want)
+			}
+		}
+	}
+}
+
+func TestLinearComplexityProfileLFSR(t *testing.T) {
+	// s_N = s_{N-28} ⊕ s_{N-31}
+	n := 100000
+	bits := make([]bool, n)
+	var state uint32 = 0x1234567
+	for i := range bits {
+		b := (state>>30 ^ state>>27) & 1
+		bits[i] = state>>30&1 == 1
+		state = state<<1 | b
+	}
+	res := LinearComplexityProfileAnalyze(func(i int) bool { return bits[i] }, n, 10000)
+	fmt.Printf("L: %d, jumps: %d, polynomial: %v, P: %f\n", res.L, res.Jumps, res.Polynomial, res.JumpP)
+	if res.L != 31 || !reflect.DeepEqual(res.Polynomial, []int{31, 28, 0}) {
+		t.Fatalf("L=%d polynomial=%v", res.L, res.Polynomial)
+	}
+	if res.JumpP >= Alpha {
+		t.Fatalf("LFSR sequence passed jump count test")
+	}
+}
+
+func TestLinearComplexityProfileRandom(t *testing.T) {
+	data := make([]byte, 25000)
+	rand.New(rand.NewSource(3)).Read(data)
+	res := LinearComplexityProfile(data)
+	fmt.Printf("P1: %f, P2: %f\n", res.P, res.P2)
+	if !res.Pass {
+		t.FailNow()
+	}
+	r := LinearComplexityProfileAnalyze(func(i int) bool { return data[i>>3]&(0x80>>uint(i&7)) != 0 }, 100000, 10000)
+	fmt.Printf("L: %d, jumps: %d, heights: %v\n", r.L, r.Jumps, r.Heights)
+	if r.L < 49990 || r.L > 50010 || r.Polynomial != nil {
+		t.FailNow()
+	}
+}
+
+func TestLinearComplexityProfileMaxBits(t *testing.T) {
+	defer func(old int) { LinearComplexityProfileMaxBits = old }(LinearComplexityProfileMaxBits)
+	LinearComplexityProfileMaxBits = 40000
+	data := make([]byte, 25000)
+	rand.New(rand.NewSource(4)).Read(data)
+	// 所有入口只分析前 LinearComplexityProfileMaxBits 比特
+	p1, p2, _, _ := LinearComplexityProfileTestBytes(data, 10000)
+	w1, w2, _, _ := LinearComplexityProfileTestBytes(data[:5000], 10000)
+	if p1 != w1 || p2 != w2 {
+		t.Fatalf("TestBytes: %f/%f, want %f/%f", p1, p2, w1, w2)
+	}
+	if p1, p2, _, _ = LinearComplexityProfileProto(B2bitArr(data), 10000); p1 != w1 || p2 != w2 {
+		t.Fatalf("Proto: %f/%f, want %f/%f", p1, p2, w1, w2)
+	}
+	if r := LinearComplexityProfileAnalyze(func(i int) bool { return data[i>>3]&(0x80>>uint(i&7)) != 0 }, len(data)*8, 10000); r.N != 40000 || len(r.WindowJumps) != 4 {
+		t.Fatalf("N=%d windows=%d", r.N, len(r.WindowJumps))
+	}
+}
@@ -18,6 +18,18 @@ func getEConstantBits() []bool {
 	return eConstantBits
 }
 
+// getEConstantBytes e 常数比特按字节内高位在前打包的字节序列
+func getEConstantBytes() []byte {
+	bits := getEConstantBits()
+	data := make([]byte, len(bits)/8)
+	for i, b := range bits[:len(data)*8] {
+		if b {
+			data[i>>3] |= 0x80 >> uint(i&7)
+		}
+	}
+	return data
+}
+
 func TestLinearComplexityTestSample(t *testing.T) {
 	bits := getEConstantBits()
 	p, q := LinearComplexityProto(bits, 1000)
@@ -73,7 +73,8 @@ func LongestRunOfOnesInABlockProto(bits []bool, checkOne bool) (float64, float64
 		panic("please provide valid test bits")
 	}
 
-	param := parameters[selectParameters(n)]
+	idx := selectParameters(n)
+	param := parameters[idx]
 
 	// Step 1
 	N := n / param.m
@@ -104,14 +105,30 @@ func LongestRunOfOnesInABlockProto(bits []bool, checkOne bool) (float64, float64
 				}
 			}
 		}
-		if mlr1 < param.startV {
-			mlr1 = param.startV
-		} else if mlr1 > param.startV+param.k {
-			mlr1 = param.startV + param.k
-		}
-		v[mlr1-param.startV]++
+		v[longestRunOfOnesClass(mlr1, idx)]++
 	}
 
+	P := longestRunOfOnesP(v, N, idx)
+	return P, P
+}
+
+// longestRunOfOnesClass 块内最大游程长度 mlr 所属的类别
+// idx: 参数组序号，见 selectParameters
+func longestRunOfOnesClass(mlr, idx int) int {
+	param := parameters[idx]
+	if mlr < param.startV {
+		mlr = param.startV
+	} else if mlr > param.startV+param.k {
+		mlr = param.startV + param.k
+	}
+	return mlr - param.startV
+}
+
+// longestRunOfOnesP 由 N 个块的最大游程类别频数 v 计算块内最大游程检测P值
+// idx: 参数组序号，见 selectParameters
+func longestRunOfOnesP(v []float64, N, idx int) float64 {
+	param := parameters[idx]
+
 	// Step 3
 	var V float64 = 0
 	for i := 0; i < param.k+1; i++ {
@@ -119,6 +136,5 @@ func LongestRunOfOnesInABlockProto(bits []bool, checkOne bool) (float64, float64
 	}
 
 	// Step 4
-	P := igamc(float64(param.k)/2.0, V/2.0)
-	return P, P
+	return igamc(float64(param.k)/2.0, V/2.0)
 }
@@ -47,7 +47,7 @@ func MatrixRankProto(bits []bool, M, Q int) (float64, float64) {
 	for i := 0; i < 32; i++ {
 		matrix[i] = make([]int, 32)
 	}
-	var V, P float64
+	var P float64
 	var r int
 	var b bool
 
@@ -72,12 +72,16 @@ func MatrixRankProto(bits []bool, M, Q int) (float64, float64) {
 			Fr++
 		}
 	}
+	P = matrixRankP(Fm, Fm1, Fr, N)
+	return P, P
+}
+
+// matrixRankP 由满秩、秩为满秩减1及其他秩的矩阵个数计算矩阵秩检测P值
+func matrixRankP(Fm, Fm1, Fr, N int) float64 {
 	_N := float64(N)
-	V = math.Pow(float64(Fm)-0.2888*_N, 2.0)/(0.2888*_N) +
+	V := math.Pow(float64(Fm)-0.2888*_N, 2.0)/(0.2888*_N) +
 		math.Pow(float64(Fm1)-0.5776*_N, 2.0)/(0.5776*_N) +
 		math.Pow(float64(Fr)-0.1336*_N, 2.0)/(0.1336*_N)
 
-	P = igamc(1, V/2.0)
-
-	return P, P
+	return igamc(1, V/2.0)
 }
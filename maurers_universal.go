@@ -47,14 +47,6 @@ func MaurerUniversalTest(bits []bool) (float64, float64) {
 	var K int = n/L - Q
 	//var  n_disc int = n % L;
 	var sum float64 = 0.0
-	var V float64 = 0
-	var P float64 = 0
-	var sigma float64 = 0
-	expected_value := []float64{0, 0, 0, 0, 0, 0, 5.2177052, 6.1962507, 7.1836656,
-		8.1764248, 9.1723243, 10.170032, 11.168765,
-		12.168070, 13.167693, 14.167488, 15.167379}
-	variance := []float64{0, 0, 0, 0, 0, 0, 2.954, 3.125, 3.238, 3.311, 3.356, 3.384,
-		3.401, 3.410, 3.416, 3.419, 3.421}
 
 	var tmp int = 0
 	var b bool
@@ -81,9 +73,20 @@ func MaurerUniversalTest(bits []bool) (float64, float64) {
 		T[tmp&mask] = i
 	}
 
-	sigma = math.Sqrt(variance[L]/float64(K)) * mutFactorC(L, K)
-	V = (sum/float64(K) - expected_value[L]) / (sigma * math.Sqrt(2.0)) // 避免求p q时V再除以math.Sqrt(2.0)
-	P = math.Erfc(math.Abs(V))
+	return maurerP(sum, K, L)
+}
+
+// maurerP 由 K 个检测块的对数距离之和 sum 计算Maurer通用统计检测P值与Q值
+func maurerP(sum float64, K, L int) (float64, float64) {
+	expected_value := []float64{0, 0, 0, 0, 0, 0, 5.2177052, 6.1962507, 7.1836656,
+		8.1764248, 9.1723243, 10.170032, 11.168765,
+		12.168070, 13.167693, 14.167488, 15.167379}
+	variance := []float64{0, 0, 0, 0, 0, 0, 2.954, 3.125, 3.238, 3.311, 3.356, 3.384,
+		3.401, 3.410, 3.416, 3.419, 3.421}
+
+	sigma := math.Sqrt(variance[L]/float64(K)) * mutFactorC(L, K)
+	V := (sum/float64(K) - expected_value[L]) / (sigma * math.Sqrt(2.0)) // 避免求p q时V再除以math.Sqrt(2.0)
+	P := math.Erfc(math.Abs(V))
 	q := math.Erfc(V) / 2
 
 	return P, q
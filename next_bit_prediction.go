@@ -0,0 +1,254 @@
+// Copyright (c) 2021 Quan guanyu
+// randomness is licensed under Mulan PSL v2.
+// You can use this software according to the terms and conditions of the Mulan PSL v2.
+// You may obtain a copy of Mulan PSL v2 at:
+//          http://license.coscl.org.cn/MulanPSL2
+// THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND,
+// EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT,
+// MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+// See the Mulan PSL v2 for more details.
+
+package randomness
+
+import "math"
+
+// NextBitPredictorResult 单个预测器在评估部分的预测结果
+type NextBitPredictorResult struct {
+	Name     string  // 预测器名称
+	Correct  int     // 预测正确的比特数
+	Total    int     // 评估的比特数
+	Accuracy float64 // 预测准确率
+	P        float64 // 准确率高于1/2的单侧二项检验P值
+}
+
+// nextBitPredictor 在线下一比特预测器
+type nextBitPredictor interface {
+	// predict 由最近 k 个比特（ctx 的低 k 位，最低位为最近的比特）预测下一比特
+	predict(ctx uint32) bool
+	// update 观察到实际的下一比特后更新模型
+	update(ctx uint32, bit bool)
+}
+
+// NextBitPrediction 下一比特预测检测
+func NextBitPrediction(data []byte) *TestResult {
+	p, q := NextBitPredictionTestBytes(data, 16)
+	return &TestResult{Name: "下一比特预测检测", P: p, Q: q, Pass: p >= Alpha}
+}
+
+// NextBitPredictionTest 下一比特预测检测，k=16
+func NextBitPredictionTest(bits []bool) (float64, float64) {
+	return NextBitPredictionProto(bits, 16)
+}
+
+// NextBitPredictionTestBytes 下一比特预测检测
+// data: 检测序列
+// k: 预测使用的历史比特数
+func NextBitPredictionTestBytes(data []byte, k int) (float64, float64) {
+	return nextBitPrediction(func(i int) bool { return data[i>>3]&(0x80>>uint(i&7)) != 0 }, len(data)*8, k)
+}
+
+// NextBitPredictionProto 下一比特预测检测
+// 以最近 k 个比特为输入训练3个在线预测器：k 阶上下文模型（按上下文统计0/1次数，预测多数）、
+// 逻辑回归（随机梯度下降）与感知机。序列前一半用于训练，后一半为评估部分，
+// 评估部分的每个比特都在观察到它之前做出预测（预测后模型继续在线更新）。
+// 检测统计量来自在线选择的综合预测：每个比特采用此前预测正确次数最多的预测器的预测，
+// 选择只依赖已观察的比特，随机序列下每次预测正确的概率均为1/2且相互独立，正确次数服从二项分布 B(n,1/2)，
+// P 为准确率高于1/2的单侧P值，在原假设下服从均匀分布。
+// 检测只关心准确率偏高（准确率低于1/2的预测取反即可预测），为单侧检测，P=Q。
+//
+// bits: 检测序列
+// k: 预测使用的历史比特数，1<=k<=24
+func NextBitPredictionProto(bits []bool, k int) (float64, float64) {
+	return nextBitPrediction(func(i int) bool { return bits[i] }, len(bits), k)
+}
+
+// NextBitPredictionAnalyze 下一比特预测检测，返回各预测器的预测结果，最后一项为在线选择的综合预测
+// bit: 第 i 个比特
+// n: 序列长度
+// k: 预测使用的历史比特数，1<=k<=24
+func NextBitPredictionAnalyze(bit func(i int) bool, n, k int) []NextBitPredictorResult {
+	if k < 1 || k > 24 {
+		panic("history length k must be in [1, 24]")
+	}
+	if n < 2*k+200 {
+		panic("please provide valid test bits")
+	}
+	names := []string{"上下文模型", "逻辑回归", "感知机", "在线选择"}
+	predictors := []nextBitPredictor{
+		newContextPredictor(k),
+		newLogisticPredictor(k),
+		newPerceptronPredictor(k),
+	}
+	res := make([]NextBitPredictorResult, len(predictors)+1)
+	for i := range res {
+		res[i].Name = names[i]
+	}
+	pooled := &res[len(predictors)]
+	// score 各预测器自开始以来预测正确的次数，综合预测采用其中最大者（相同时取靠前者）的预测
+	score := make([]int, len(predictors))
+	guess := make([]bool, len(predictors))
+
+	mask := uint32(1)<<uint(k) - 1
+	var ctx uint32
+	for i := 0; i < k; i++ {
+		ctx = ctx<<1 | uint32(b2i(bit(i)))
+	}
+	start := n / 2
+	for i := k; i < n; i++ {
+		b := bit(i)
+		best := 0
+		for j, p := range predictors {
+			guess[j] = p.predict(ctx)
+			if score[j] > score[best] {
+				best = j
+			}
+		}
+		if i >= start {
+			pooled.Total++
+			if guess[best] == b {
+				pooled.Correct++
+			}
+		}
+		for j, p := range predictors {
+			if guess[j] == b {
+				score[j]++
+				if i >= start {
+					res[j].Correct++
+				}
+			}
+			if i >= start {
+				res[j].Total++
+			}
+			p.update(ctx, b)
+		}
+		ctx = (ctx<<1 | uint32(b2i(b))) & mask
+	}
+
+	for i := range res {
+		r := &res[i]
+		r.Accuracy = float64(r.Correct) / float64(r.Total)
+		// 二项分布上尾概率的正态近似（含连续性校正）
+		z := (float64(r.Correct) - 0.5 - float64(r.Total)/2) / math.Sqrt(float64(r.Total)/4)
+		r.P = math.Erfc(z/math.Sqrt2) / 2
+	}
+	return res
+}
+
+// nextBitPrediction 在线选择的综合预测的P值
+func nextBitPrediction(bit func(i int) bool, n, k int) (float64, float64) {
+	res := NextBitPredictionAnalyze(bit, n, k)
+	P := res[len(res)-1].P
+	return P, P
+}
+
+// contextPredictor k 阶上下文模型
+type contextPredictor struct {
+	counts []int32 // 上下文 ctx 后出现1的次数减去出现0的次数
+}
+
+func newContextPredictor(k int) *contextPredictor {
+	return &contextPredictor{counts: make([]int32, 1<<uint(k))}
+}
+
+func (p *contextPredictor) predict(ctx uint32) bool {
+	return p.counts[ctx] > 0
+}
+
+func (p *contextPredictor) update(ctx uint32, bit bool) {
+	if bit {
+		p.counts[ctx]++
+	} else {
+		p.counts[ctx]--
+	}
+}
+
+// logisticPredictor 以最近 k 个比特（映射为 ±1）为特征的逻辑回归
+type logisticPredictor struct {
+	w    []float64 // w[0] 为偏置
+	rate float64
+}
+
+func newLogisticPredictor(k int) *logisticPredictor {
+	return &logisticPredictor{w: make([]float64, k+1), rate: 0.01}
+}
+
+func (p *logisticPredictor) z(ctx uint32) float64 {
+	z := p.w[0]
+	for i := 1; i < len(p.w); i++ {
+		if ctx>>uint(i-1)&1 == 1 {
+			z += p.w[i]
+		} else {
+			z -= p.w[i]
+		}
+	}
+	return z
+}
+
+func (p *logisticPredictor) predict(ctx uint32) bool {
+	return p.z(ctx) > 0
+}
+
+func (p *logisticPredictor) update(ctx uint32, bit bool) {
+	g := float64(b2i(bit)) - 1/(1+math.Exp(-p.z(ctx)))
+	g *= p.rate
+	p.w[0] += g
+	for i := 1; i < len(p.w); i++ {
+		if ctx>>uint(i-1)&1 == 1 {
+			p.w[i] += g
+		} else {
+			p.w[i] -= g
+		}
+	}
+}
+
+// perceptronPredictor 以最近 k 个比特（映射为 ±1）为输入的感知机
+// 采用 Jiménez 与 Lin 分支预测器的形式：预测错误或输出绝对值不超过阈值 θ=⌊1.93k+14⌋ 时训练，
+// 权值饱和于 [-128,127]，避免噪声序列上权值的无界漂移。
+type perceptronPredictor struct {
+	w     []int // w[0] 为偏置
+	theta int
+}
+
+func newPerceptronPredictor(k int) *perceptronPredictor {
+	return &perceptronPredictor{w: make([]int, k+1), theta: int(1.93*float64(k) + 14)}
+}
+
+func (p *perceptronPredictor) sum(ctx uint32) int {
+	s := p.w[0]
+	for i := 1; i < len(p.w); i++ {
+		if ctx>>uint(i-1)&1 == 1 {
+			s += p.w[i]
+		} else {
+			s -= p.w[i]
+		}
+	}
+	return s
+}
+
+func (p *perceptronPredictor) predict(ctx uint32) bool {
+	return p.sum(ctx) > 0
+}
+
+func (p *perceptronPredictor) update(ctx uint32, bit bool) {
+	s := p.sum(ctx)
+	if (s > 0) == bit && (s > p.theta || s < -p.theta) {
+		return
+	}
+	d := -1
+	if bit {
+		d = 1
+	}
+	p.w[0] = perceptronSaturate(p.w[0] + d)
+	for i := 1; i < len(p.w); i++ {
+		if ctx>>uint(i-1)&1 == 1 {
+			p.w[i] = perceptronSaturate(p.w[i] + d)
+		} else {
+			p.w[i] = perceptronSaturate(p.w[i] - d)
+		}
+	}
+}
+
+// perceptronSaturate 将权值限制在 [-128,127]
+func perceptronSaturate(w int) int {
+	return max(-128, min(127, w))
+}
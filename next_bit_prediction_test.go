@@ -0,0 +1,73 @@
+package randomness
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+)
+
+func TestNextBitPredictionE(t *testing.T) {
+	bits := getEConstantBits()[:100000]
+	p, q := NextBitPredictionTest(bits)
+	fmt.Printf("n: %v, P-value: %f, Q-value: %f\n", len(bits), p, q)
+	if fmt.Sprintf("%.6f", p) != "0.132734" || fmt.Sprintf("%.6f", q) != "0.132734" {
+		t.FailNow()
+	}
+	// 各预测器在评估部分（后50000比特）预测正确的比特数
+	for i, r := range NextBitPredictionAnalyze(func(i int) bool { return bits[i] }, len(bits), 16) {
+		fmt.Printf("%s: accuracy: %f, P-value: %f\n", r.Name, r.Accuracy, r.P)
+		if want := []int{25099, 24978, 25131, 25125}[i]; r.Correct != want || r.Total != 50000 {
+			t.Fatalf("%s: %d/%d, want %d/50000", r.Name, r.Correct, r.Total, want)
+		}
+	}
+}
+
+func TestNextBitPredictionNonlinear(t *testing.T) {
+	// 每10个比特中有一个由 b[i-3] xor b[i-7] 决定，线性预测器无法学习，上下文模型可以
+	bits := append([]bool(nil), getEConstantBits()...)
+	for i := 10; i < len(bits); i += 10 {
+		bits[i] = bits[i-3] != bits[i-7]
+	}
+	res := NextBitPredictionAnalyze(func(i int) bool { return bits[i] }, len(bits), 16)
+	for i, r := range res {
+		fmt.Printf("%s: accuracy: %f, P-value: %g\n", r.Name, r.Accuracy, r.P)
+		if want := []int{256023, 249501, 249420, 256023}[i]; r.Correct != want {
+			t.Fatalf("%s: %d correct, want %d", r.Name, r.Correct, want)
+		}
+	}
+	// 在线选择的综合预测跟随上下文模型
+	if res[0].P >= Alpha || res[len(res)-1].P >= Alpha {
+		t.Fatalf("failed to predict nonlinear dependency, P = %f, %f", res[0].P, res[len(res)-1].P)
+	}
+}
+
+func TestNextBitPredictionLinear(t *testing.T) {
+	// 每10个比特中有一个重复前一比特（重复概率55%），线性预测器可以学习（2^16 个上下文的上下文模型在该长度下尚未充分训练）
+	bits := append([]bool(nil), getEConstantBits()[:100000]...)
+	for i := 10; i < len(bits); i += 10 {
+		bits[i] = bits[i-1]
+	}
+	res := NextBitPredictionAnalyze(func(i int) bool { return bits[i] }, len(bits), 16)
+	for i, r := range res {
+		fmt.Printf("%s: accuracy: %f, P-value: %g\n", r.Name, r.Accuracy, r.P)
+		if want := []int{25359, 26072, 25785, 26072}[i]; r.Correct != want {
+			t.Fatalf("%s: %d correct, want %d", r.Name, r.Correct, want)
+		}
+		if i > 0 && r.P >= Alpha {
+			t.Fatalf("%s failed to predict correlated bits, P = %f", r.Name, r.P)
+		}
+	}
+}
+
+func TestNextBitPredictionUniform(t *testing.T) {
+	// 原假设下在线选择的综合预测P值服从均匀分布
+	data := make([]byte, 2500)
+	ps := make([]float64, 300)
+	for i := range ps {
+		rand.New(rand.NewSource(int64(i))).Read(data)
+		ps[i], _ = NextBitPredictionTestBytes(data, 8)
+	}
+	if p := KolmogorovSmirnov(ps); p < 0.001 {
+		t.Fatalf("P-values not uniform, KS P = %f", p)
+	}
+}
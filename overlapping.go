@@ -61,8 +61,6 @@ func OverlappingTemplateMatchingProto(bits []bool, m int) (p1 float64, p2 float6
 	patterns1 := make([]int, 1<<uint(m))
 	patterns2 := make([]int, 1<<uint(m-1))
 	patterns3 := make([]int, 1<<uint(m-2))
-	var Phi1, Phi2, Phi3 float64 = 0, 0, 0
-	var DPhi2, D2Phi2 float64 = 0, 0
 
 	var mask1 int = (1 << uint(m)) - 1
 	var mask2 int = (1 << uint(m-1)) - 1
@@ -84,23 +82,38 @@ func OverlappingTemplateMatchingProto(bits []bool, m int) (p1 float64, p2 float6
 		patterns3[tmp&mask3]++
 	}
 
+	// Step 3, 4, 5
+	p1, p2 = overlappingP(patterns1, patterns2, patterns3, n)
+
+	// Step 6
+	q1 = p1
+	q2 = p2
+
+	return
+}
+
+// overlappingP 由 m、m-1、m-2 比特重叠模式的频数计算重叠子序列检测的两个P值
+func overlappingP(patterns1, patterns2, patterns3 []int, n int) (p1 float64, p2 float64) {
+	var Phi1, Phi2, Phi3 float64 = 0, 0, 0
+	var DPhi2, D2Phi2 float64 = 0, 0
+
 	// Step 3
-	for i := 0; i <= mask1; i++ {
+	for i := range patterns1 {
 		Phi1 += float64(patterns1[i]) * float64(patterns1[i])
 	}
-	Phi1 *= float64(mask1 + 1)
+	Phi1 *= float64(len(patterns1))
 	Phi1 /= float64(n)
 	Phi1 -= float64(n)
-	for i := 0; i <= mask2; i++ {
+	for i := range patterns2 {
 		Phi2 += float64(patterns2[i]) * float64(patterns2[i])
 	}
-	Phi2 *= float64(mask2 + 1)
+	Phi2 *= float64(len(patterns2))
 	Phi2 /= float64(n)
 	Phi2 -= float64(n)
-	for i := 0; i <= mask3; i++ {
+	for i := range patterns3 {
 		Phi3 += float64(patterns3[i]) * float64(patterns3[i])
 	}
-	Phi3 *= float64(mask3 + 1)
+	Phi3 *= float64(len(patterns3))
 	Phi3 /= float64(n)
 	Phi3 -= float64(n)
 
@@ -111,10 +124,5 @@ func OverlappingTemplateMatchingProto(bits []bool, m int) (p1 float64, p2 float6
 	// Step 5
 	p1 = igamc(float64(len(patterns3)), DPhi2/2.0)
 	p2 = igamc(float64(len(patterns3))/2.0, D2Phi2/2.0)
-
-	// Step 6
-	q1 = p1
-	q2 = p2
-
 	return
 }
@@ -38,8 +38,6 @@ func PokerTestBytes(data []byte, m int) (float64, float64) {
 
 	patterns := make([]int, _2m)
 	N := (len(data) * 8) / m
-	var V float64 = 0
-	var P float64 = 0
 
 	if m == 8 {
 		for i := 0; i < N; i++ {
@@ -52,15 +50,7 @@ func PokerTestBytes(data []byte, m int) (float64, float64) {
 		}
 	}
 
-	for i := 0; i < _2m; i++ {
-		V += float64(patterns[i]) * float64(patterns[i])
-	}
-
-	V *= float64(_2m)
-	V /= float64(N)
-	V -= float64(N)
-
-	P = igamc(float64(_2m-1)/2, V/2)
+	P := pokerP(patterns, N)
 	return P, P
 }
 
@@ -78,21 +68,25 @@ func PokerProto(bits []bool, m int) (float64, float64) {
 
 	patterns := make([]int, _2m)
 	N := n / m
-	var V float64 = 0
-	var P float64 = 0
 
 	for i := 0; i < N; i++ {
 		patterns[subsequencepattern(bits[i*m:], m)]++
 	}
 
-	for i := 0; i < _2m; i++ {
+	P := pokerP(patterns, N)
+	return P, P
+}
+
+// pokerP 由 N 个 m 比特模式的频数计算扑克检测P值
+func pokerP(patterns []int, N int) float64 {
+	var V float64 = 0
+	for i := range patterns {
 		V += float64(patterns[i]) * float64(patterns[i])
 	}
 
-	V *= float64(_2m)
+	V *= float64(len(patterns))
 	V /= float64(N)
 	V -= float64(N)
 
-	P = igamc(float64(_2m-1)/2, V/2)
-	return P, P
+	return igamc(float64(len(patterns)-1)/2, V/2)
 }
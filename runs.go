@@ -34,7 +34,6 @@ func RunsTest(bits []bool) (float64, float64) {
 
 	var Pi float64 = 0
 	var V_obs int = 1
-	var P, Q float64 = 0, 0
 
 	// Step 1, 2
 	for i := 0; i < n-1; i++ {
@@ -48,15 +47,20 @@ func RunsTest(bits []bool) (float64, float64) {
 	if bits[n-1] {
 		Pi++
 	}
-	Pi /= float64(n)
+	return runsPQ(V_obs, Pi, n)
+}
+
+// runsPQ 由游程总数 V_obs 与"1"的个数计算游程总数检测的P值与Q值
+func runsPQ(V_obs int, ones float64, n int) (float64, float64) {
+	Pi := ones / float64(n)
 
 	// Step 3, 第四、五步的除math.Sqrt(2)，放到这里提前处理，减少math.Sqrt的调用。
 	V := (float64(V_obs) - 2.0*float64(n)*Pi*(1.0-Pi)) / (2.0 * math.Sqrt(float64(2*n)) * Pi * (1.0 - Pi))
 
 	// Step 4
-	P = math.Erfc(math.Abs(V))
+	P := math.Erfc(math.Abs(V))
 
 	// Step 5
-	Q = math.Erfc(V) / 2.0
+	Q := math.Erfc(V) / 2.0
 	return P, Q
 }
@@ -29,21 +29,11 @@ func RunsDistributionTest(bits []bool) (float64, float64) {
 	}
 
 	// Step 1, calculate k
-	k := 0
-	for {
-		k++
-		_2k2 := 1 << uint(k+2)
-		if float64(n-k+3)/float64(_2k2) < 5.0 {
-			break
-		}
-	}
-	k--
+	k := runsDistributionK(n)
 
 	// Step 2
-	e := make([]float64, k)
 	b := make([]float64, k)
 	g := make([]float64, k)
-	var V float64 = 0
 	var cur bool = bits[0]
 	cnt := 0
 
@@ -73,6 +63,30 @@ func RunsDistributionTest(bits []bool) (float64, float64) {
 		g[cnt-1]++
 	}
 
+	P := runsDistributionP(b, g)
+	return P, P
+}
+
+// runsDistributionK 游程分布检测统计的最大游程长度 k
+func runsDistributionK(n int) int {
+	k := 0
+	for {
+		k++
+		_2k2 := 1 << uint(k+2)
+		if float64(n-k+3)/float64(_2k2) < 5.0 {
+			break
+		}
+	}
+	k--
+	return k
+}
+
+// runsDistributionP 由长度为 1、2、...、k（最后一项为不小于k）的"1"游程数 b 与"0"游程数 g 计算游程分布检测P值
+func runsDistributionP(b, g []float64) float64 {
+	k := len(b)
+	e := make([]float64, k)
+	var V float64 = 0
+
 	// Step 3
 	var T float64 = 0
 	for i := 0; i < k; i++ {
@@ -95,7 +109,5 @@ func RunsDistributionTest(bits []bool) (float64, float64) {
 	}
 
 	// Step 6
-	P := igamc(float64(k-1), V/2.0)
-
-	return P, P
+	return igamc(float64(k-1), V/2.0)
 }
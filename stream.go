@@ -0,0 +1,791 @@
+// Copyright (c) 2021 Quan guanyu
+// randomness is licensed under Mulan PSL v2.
+// You can use this software according to the terms and conditions of the Mulan PSL v2.
+// You may obtain a copy of Mulan PSL v2 at:
+//          http://license.coscl.org.cn/MulanPSL2
+// THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND,
+// EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT,
+// MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+// See the Mulan PSL v2 for more details.
+
+package randomness
+
+import (
+	"math"
+	"math/bits"
+	"runtime"
+	"sync"
+)
+
+// 流式检测
+//
+// 以下 XxxWriter 实现 io.Writer，以字节流的方式（字节内高位在前）累计检测统计量，
+// 内存消耗与序列长度无关，可对大文件单次读取完成多项检测，例如：
+//
+//	mono := randomness.NewMonoBitFrequencyWriter()
+//	runs := randomness.NewRunsWriter()
+//	_, _ = io.Copy(io.MultiWriter(mono, runs), f)
+//	p, q := mono.Result()
+//
+// Write 总是返回 len(p), nil；Result 不改变已累计的状态，可在继续写入后再次调用，
+// 其结果与对已写入的全部数据调用对应的批量检测函数相同。
+
+// forEachBit 按字节内高位在前的顺序依次处理 p 中的比特
+func forEachBit(p []byte, f func(b bool)) {
+	for _, v := range p {
+		for j := uint(0); j < 8; j++ {
+			f(v&(0x80>>j) != 0)
+		}
+	}
+}
+
+// MonoBitFrequencyWriter 单比特频数检测的流式累计器，结果同 MonoBitFrequencyTest
+type MonoBitFrequencyWriter struct {
+	n, S int
+}
+
+// NewMonoBitFrequencyWriter 创建单比特频数检测累计器
+func NewMonoBitFrequencyWriter() *MonoBitFrequencyWriter {
+	return &MonoBitFrequencyWriter{}
+}
+
+// Write 累计字节，总是返回 len(p), nil
+func (w *MonoBitFrequencyWriter) Write(p []byte) (int, error) {
+	for _, v := range p {
+		w.S += 2*bits.OnesCount8(v) - 8
+	}
+	w.n += 8 * len(p)
+	return len(p), nil
+}
+
+// Result 单比特频数检测P值与Q值
+func (w *MonoBitFrequencyWriter) Result() (float64, float64) {
+	if w.n == 0 {
+		panic("please provide test bits")
+	}
+	V := float64(w.S) / math.Sqrt(float64(2*w.n))
+	P := math.Erfc(math.Abs(V))
+	Q := math.Erfc(V) / 2
+	return P, Q
+}
+
+// FrequencyWithinBlockWriter 块内频数检测的流式累计器，结果同 FrequencyWithinBlockProto
+type FrequencyWithinBlockWriter struct {
+	m    int
+	N    int // 已完成的块数
+	j    int // 当前块已读取的比特数
+	ones int // 当前块中"1"的个数
+	V    float64
+}
+
+// NewFrequencyWithinBlockWriter 创建块内频数检测累计器
+// m: 块长度
+func NewFrequencyWithinBlockWriter(m int) *FrequencyWithinBlockWriter {
+	if m <= 0 {
+		panic("please provide valid test bits")
+	}
+	return &FrequencyWithinBlockWriter{m: m}
+}
+
+// Write 累计字节，总是返回 len(p), nil
+func (w *FrequencyWithinBlockWriter) Write(p []byte) (int, error) {
+	forEachBit(p, w.writeBit)
+	return len(p), nil
+}
+
+func (w *FrequencyWithinBlockWriter) writeBit(b bool) {
+	if b {
+		w.ones++
+	}
+	w.j++
+	if w.j == w.m {
+		Pi := float64(w.ones)/float64(w.m) - 0.5
+		w.V += Pi * Pi
+		w.N++
+		w.j, w.ones = 0, 0
+	}
+}
+
+// Result 块内频数检测P值与Q值
+func (w *FrequencyWithinBlockWriter) Result() (float64, float64) {
+	if w.N == 0 {
+		panic("please provide test bits")
+	}
+	V := w.V * 2.0 * float64(w.m)
+	P := igamc(float64(w.N)/2.0, V)
+	return P, P
+}
+
+// PokerWriter 扑克检测的流式累计器，结果同 PokerProto
+type PokerWriter struct {
+	m        int
+	n        int
+	tmp      int
+	patterns []int
+}
+
+// NewPokerWriter 创建扑克检测累计器
+// m: m长度，m=4,8
+func NewPokerWriter(m int) *PokerWriter {
+	if m <= 0 || m > 24 {
+		panic("please provide valid test bits")
+	}
+	return &PokerWriter{m: m, patterns: make([]int, 1<<uint(m))}
+}
+
+// Write 累计字节，总是返回 len(p), nil
+func (w *PokerWriter) Write(p []byte) (int, error) {
+	switch w.m {
+	case 8:
+		for _, v := range p {
+			w.patterns[v]++
+		}
+		w.n += 8 * len(p)
+	case 4:
+		for _, v := range p {
+			w.patterns[v>>4]++
+			w.patterns[v&0x0f]++
+		}
+		w.n += 8 * len(p)
+	default:
+		forEachBit(p, w.writeBit)
+	}
+	return len(p), nil
+}
+
+func (w *PokerWriter) writeBit(b bool) {
+	w.tmp = w.tmp<<1 | b2i(b)
+	w.n++
+	if w.n%w.m == 0 {
+		w.patterns[w.tmp]++
+		w.tmp = 0
+	}
+}
+
+// Result 扑克检测P值与Q值
+func (w *PokerWriter) Result() (float64, float64) {
+	if w.n < 8 {
+		panic("please provide valid test bits")
+	}
+	P := pokerP(w.patterns, w.n/w.m)
+	return P, P
+}
+
+// OverlappingTemplateMatchingWriter 重叠子序列检测的流式累计器，结果同 OverlappingTemplateMatchingProto
+// 序列首尾相接，前 m-1 比特在 Result 时补到结尾。
+type OverlappingTemplateMatchingWriter struct {
+	m        int
+	n        int
+	head     int // 前 m-1 比特
+	tmp      int
+	patterns []int // m 比特重叠模式的频数，m-1、m-2 比特的频数由其汇总得到
+}
+
+// NewOverlappingTemplateMatchingWriter 创建重叠子序列检测累计器
+// m: m长度
+func NewOverlappingTemplateMatchingWriter(m int) *OverlappingTemplateMatchingWriter {
+	if m < 2 || m > 24 {
+		panic("please provide valid test bits")
+	}
+	return &OverlappingTemplateMatchingWriter{m: m, patterns: make([]int, 1<<uint(m))}
+}
+
+// Write 累计字节，总是返回 len(p), nil
+func (w *OverlappingTemplateMatchingWriter) Write(p []byte) (int, error) {
+	forEachBit(p, w.writeBit)
+	return len(p), nil
+}
+
+func (w *OverlappingTemplateMatchingWriter) writeBit(b bool) {
+	mask := 1<<uint(w.m) - 1
+	w.tmp = (w.tmp<<1 | b2i(b)) & mask
+	w.n++
+	if w.n < w.m {
+		w.head = w.tmp
+		return
+	}
+	w.patterns[w.tmp]++
+}
+
+// Result 重叠子序列检测 P1、P2 与 Q1、Q2
+func (w *OverlappingTemplateMatchingWriter) Result() (p1 float64, p2 float64, q1 float64, q2 float64) {
+	if w.n < 5 || w.n < w.m {
+		panic("please provide valid test bits")
+	}
+	m := w.m
+	patterns1 := make([]int, len(w.patterns))
+	copy(patterns1, w.patterns)
+	// 首尾相接：补上前 m-1 比特
+	mask1 := 1<<uint(m) - 1
+	tmp := w.tmp
+	for i := m - 2; i >= 0; i-- {
+		tmp = (tmp<<1 | w.head>>uint(i)&1) & mask1
+		patterns1[tmp]++
+	}
+
+	patterns2 := make([]int, 1<<uint(m-1))
+	patterns3 := make([]int, 1<<uint(m-2))
+	mask2 := len(patterns2) - 1
+	mask3 := len(patterns3) - 1
+	for i, c := range patterns1 {
+		patterns2[i&mask2] += c
+		patterns3[i&mask3] += c
+	}
+
+	p1, p2 = overlappingP(patterns1, patterns2, patterns3, w.n)
+	q1, q2 = p1, p2
+	return
+}
+
+// RunsWriter 游程总数检测的流式累计器，结果同 RunsTest
+type RunsWriter struct {
+	n     int
+	ones  int
+	V_obs int
+	last  bool
+}
+
+// NewRunsWriter 创建游程总数检测累计器
+func NewRunsWriter() *RunsWriter {
+	return &RunsWriter{V_obs: 1}
+}
+
+// Write 累计字节，总是返回 len(p), nil
+func (w *RunsWriter) Write(p []byte) (int, error) {
+	forEachBit(p, w.writeBit)
+	return len(p), nil
+}
+
+func (w *RunsWriter) writeBit(b bool) {
+	if w.n > 0 && b != w.last {
+		w.V_obs++
+	}
+	if b {
+		w.ones++
+	}
+	w.last = b
+	w.n++
+}
+
+// Result 游程总数检测P值与Q值
+func (w *RunsWriter) Result() (float64, float64) {
+	if w.n == 0 {
+		panic("please provide test bits")
+	}
+	return runsPQ(w.V_obs, float64(w.ones), w.n)
+}
+
+// runsDistributionMaxK 流式游程分布检测分别统计的最大游程长度，更长的游程合并计数
+// 序列长度不超过 2^66 时游程分布检测的 k 均小于该值。
+const runsDistributionMaxK = 64
+
+// RunsDistributionWriter 游程分布检测的流式累计器，结果同 RunsDistributionTest
+// 游程长度的分组数 k 由序列长度决定，累计时按长度 1..64 分别计数，Result 时再合并。
+type RunsDistributionWriter struct {
+	n    int
+	cur  bool
+	cnt  int
+	b, g [runsDistributionMaxK]float64
+}
+
+// NewRunsDistributionWriter 创建游程分布检测累计器
+func NewRunsDistributionWriter() *RunsDistributionWriter {
+	return &RunsDistributionWriter{}
+}
+
+// Write 累计字节，总是返回 len(p), nil
+func (w *RunsDistributionWriter) Write(p []byte) (int, error) {
+	forEachBit(p, w.writeBit)
+	return len(p), nil
+}
+
+func (w *RunsDistributionWriter) writeBit(b bool) {
+	w.n++
+	if w.n > 1 && b != w.cur {
+		w.count(w.cur, w.cnt)
+		w.cnt = 0
+	}
+	w.cur = b
+	w.cnt++
+}
+
+func (w *RunsDistributionWriter) count(cur bool, cnt int) {
+	if cnt > runsDistributionMaxK {
+		cnt = runsDistributionMaxK
+	}
+	if cur {
+		w.b[cnt-1]++
+	} else {
+		w.g[cnt-1]++
+	}
+}
+
+// Result 游程分布检测P值与Q值
+func (w *RunsDistributionWriter) Result() (float64, float64) {
+	if w.n < 100 {
+		panic("please provide valid test bits")
+	}
+	k := runsDistributionK(w.n)
+	// 在副本上计入结尾的游程
+	c := *w
+	c.count(c.cur, c.cnt)
+	// 长度不小于 k 的游程合并到最后一组
+	for i := k; i < runsDistributionMaxK; i++ {
+		c.b[k-1] += c.b[i]
+		c.g[k-1] += c.g[i]
+	}
+
+	P := runsDistributionP(c.b[:k], c.g[:k])
+	return P, P
+}
+
+// LongestRunOfOnesInABlockWriter 块内最大游程检测的流式累计器，结果同 LongestRunOfOnesInABlockProto
+// 块长度由序列长度决定，累计时对每组参数分别统计，Result 时按序列长度选择。
+type LongestRunOfOnesInABlockWriter struct {
+	checkOne bool
+	n        int
+	lr       int
+	mlr      [3]int
+	v        [3][]float64
+}
+
+// NewLongestRunOfOnesInABlockWriter 创建块内最大游程检测累计器
+// checkOne: 为 true 时检测"1"游程，否则检测"0"游程
+func NewLongestRunOfOnesInABlockWriter(checkOne bool) *LongestRunOfOnesInABlockWriter {
+	w := &LongestRunOfOnesInABlockWriter{checkOne: checkOne}
+	for i := range w.v {
+		w.v[i] = make([]float64, parameters[i].k+1)
+	}
+	return w
+}
+
+// Write 累计字节，总是返回 len(p), nil
+func (w *LongestRunOfOnesInABlockWriter) Write(p []byte) (int, error) {
+	forEachBit(p, w.writeBit)
+	return len(p), nil
+}
+
+func (w *LongestRunOfOnesInABlockWriter) writeBit(b bool) {
+	w.n++
+	if b == w.checkOne {
+		w.lr++
+	} else {
+		w.lr = 0
+	}
+	for i := range w.mlr {
+		param := &parameters[i]
+		// 块内游程不超过块内已读取的比特数
+		j := (w.n-1)%param.m + 1
+		if j == 1 {
+			w.mlr[i] = 0
+		}
+		w.mlr[i] = max(w.mlr[i], min(w.lr, j))
+		if w.n%param.m == 0 {
+			w.v[i][longestRunOfOnesClass(w.mlr[i], i)]++
+		}
+	}
+}
+
+// Result 块内最大游程检测P值与Q值
+func (w *LongestRunOfOnesInABlockWriter) Result() (float64, float64) {
+	if w.n < 128 {
+		panic("please provide valid test bits")
+	}
+	idx := selectParameters(w.n)
+	P := longestRunOfOnesP(w.v[idx], w.n/parameters[idx].m, idx)
+	return P, P
+}
+
+// BinaryDerivativeWriter 二元推导检测的流式累计器，结果同 BinaryDerivativeProto
+// 第 k 次推导序列的第 i 比特为 ε_{i+j} 的异或，j 取 C(k,j) 为奇数的值，即 j&k == j。
+type BinaryDerivativeWriter struct {
+	k      int
+	n      int
+	S      int
+	window uint64 // 最近 k+1 个比特，最新的比特在最低位
+	taps   uint64
+}
+
+// NewBinaryDerivativeWriter 创建二元推导检测累计器
+// k: 重复次数，0 <= k <= 63
+func NewBinaryDerivativeWriter(k int) *BinaryDerivativeWriter {
+	if k < 0 || k > 63 {
+		panic("please provide valid test bits")
+	}
+	w := &BinaryDerivativeWriter{k: k}
+	for j := 0; j <= k; j++ {
+		if j&k == j {
+			w.taps |= 1 << uint(k-j)
+		}
+	}
+	return w
+}
+
+// Write 累计字节，总是返回 len(p), nil
+func (w *BinaryDerivativeWriter) Write(p []byte) (int, error) {
+	forEachBit(p, w.writeBit)
+	return len(p), nil
+}
+
+func (w *BinaryDerivativeWriter) writeBit(b bool) {
+	w.window = w.window<<1 | uint64(b2i(b))
+	w.n++
+	if w.n > w.k {
+		w.S += 2*(bits.OnesCount64(w.window&w.taps)&1) - 1
+	}
+}
+
+// Result 二元推导检测P值与Q值
+func (w *BinaryDerivativeWriter) Result() (float64, float64) {
+	if w.n < 7 {
+		panic("please provide valid test bits")
+	}
+	V := float64(w.S) / math.Sqrt(2*float64(w.n-w.k))
+	P := math.Erfc(math.Abs(V))
+	Q := math.Erfc(V) / 2
+	return P, Q
+}
+
+// AutocorrelationWriter 自相关检测的流式累计器，结果同 AutocorrelationProto
+type AutocorrelationWriter struct {
+	d    int
+	n    int
+	Ad   int
+	ring []bool // 最近 d 个比特
+}
+
+// NewAutocorrelationWriter 创建自相关检测累计器
+// d: 左移位数
+func NewAutocorrelationWriter(d int) *AutocorrelationWriter {
+	if d <= 0 {
+		panic("please provide valid test bits")
+	}
+	return &AutocorrelationWriter{d: d, ring: make([]bool, d)}
+}
+
+// Write 累计字节，总是返回 len(p), nil
+func (w *AutocorrelationWriter) Write(p []byte) (int, error) {
+	forEachBit(p, w.writeBit)
+	return len(p), nil
+}
+
+func (w *AutocorrelationWriter) writeBit(b bool) {
+	i := w.n % w.d
+	if w.n >= w.d && xor(w.ring[i], b) {
+		w.Ad++
+	}
+	w.ring[i] = b
+	w.n++
+}
+
+// Result 自相关检测P值与Q值
+func (w *AutocorrelationWriter) Result() (float64, float64) {
+	if w.n < 16 {
+		panic("please provide valid test bits")
+	}
+	n, d := w.n, w.d
+	V := 2.0 * (float64(w.Ad) - (float64(n-d) / 2.0)) / math.Sqrt(2*float64(n-d))
+	P := math.Erfc(math.Abs(V))
+	Q := math.Erfc(V) / 2
+	return P, Q
+}
+
+// MatrixRankWriter 矩阵秩检测（32×32矩阵）的流式累计器，结果同 MatrixRankTest
+type MatrixRankWriter struct {
+	n           int
+	matrix      [][]int
+	Fm, Fm1, Fr int
+}
+
+// NewMatrixRankWriter 创建矩阵秩检测累计器
+func NewMatrixRankWriter() *MatrixRankWriter {
+	w := &MatrixRankWriter{matrix: make([][]int, 32)}
+	for i := range w.matrix {
+		w.matrix[i] = make([]int, 32)
+	}
+	return w
+}
+
+// Write 累计字节，总是返回 len(p), nil
+func (w *MatrixRankWriter) Write(p []byte) (int, error) {
+	forEachBit(p, w.writeBit)
+	return len(p), nil
+}
+
+func (w *MatrixRankWriter) writeBit(b bool) {
+	i := w.n % (32 * 32)
+	w.matrix[i/32][i%32] = b2i(b)
+	w.n++
+	if i == 32*32-1 {
+		switch rank(w.matrix, 32) {
+		case 32:
+			w.Fm++
+		case 31:
+			w.Fm1++
+		default:
+			w.Fr++
+		}
+	}
+}
+
+// Result 矩阵秩检测P值与Q值
+func (w *MatrixRankWriter) Result() (float64, float64) {
+	N := w.n / (32 * 32)
+	if N == 0 {
+		panic("please provide valid test bits")
+	}
+	P := matrixRankP(w.Fm, w.Fm1, w.Fr, N)
+	return P, P
+}
+
+// CumulativeWriter 累加和检测的流式累计器，结果同 CumulativeTest
+// 后向累加和 S_n - S_k 的绝对值最大值由前向累加和 S_k（0 <= k < n）的最小值与最大值得到，
+// 一次累计即可同时给出前向与后向检测结果。
+type CumulativeWriter struct {
+	n          int
+	S          int
+	Z          int // 前向累加和绝对值的最大值
+	minS, maxS int // S_0..S_{n-1} 的最小值与最大值
+}
+
+// NewCumulativeWriter 创建累加和检测累计器
+func NewCumulativeWriter() *CumulativeWriter {
+	return &CumulativeWriter{}
+}
+
+// Write 累计字节，总是返回 len(p), nil
+func (w *CumulativeWriter) Write(p []byte) (int, error) {
+	forEachBit(p, w.writeBit)
+	return len(p), nil
+}
+
+func (w *CumulativeWriter) writeBit(b bool) {
+	w.minS = min(w.minS, w.S)
+	w.maxS = max(w.maxS, w.S)
+	if b {
+		w.S++
+	} else {
+		w.S--
+	}
+	w.Z = max(w.Z, abs(w.S))
+	w.n++
+}
+
+// Result 累加和检测P值与Q值
+// forward: 为 true 时为前向检测，否则为后向检测
+func (w *CumulativeWriter) Result(forward bool) (float64, float64) {
+	if w.n == 0 {
+		panic("please provide test bits")
+	}
+	Z := w.Z
+	if !forward {
+		Z = max(w.S-w.minS, w.maxS-w.S)
+	}
+	P := cumulativeP(Z, w.n)
+	return P, P
+}
+
+// ApproximateEntropyWriter 近似熵检测的流式累计器，结果同 ApproximateEntropyProto
+// 累计 m+1 比特循环重叠模式的频数，m 比特模式的频数由其汇总得到，前 m 比特在 Result 时补到结尾。
+type ApproximateEntropyWriter struct {
+	m        int
+	n        int
+	head     int // 前 m 比特
+	tmp      int
+	patterns []int
+}
+
+// NewApproximateEntropyWriter 创建近似熵检测累计器
+// m: m长度
+func NewApproximateEntropyWriter(m int) *ApproximateEntropyWriter {
+	if m <= 0 || m > 24 {
+		panic("please provide valid test bits")
+	}
+	return &ApproximateEntropyWriter{m: m, patterns: make([]int, 1<<uint(m+1))}
+}
+
+// Write 累计字节，总是返回 len(p), nil
+func (w *ApproximateEntropyWriter) Write(p []byte) (int, error) {
+	forEachBit(p, w.writeBit)
+	return len(p), nil
+}
+
+func (w *ApproximateEntropyWriter) writeBit(b bool) {
+	w.tmp = (w.tmp<<1 | b2i(b)) & (len(w.patterns) - 1)
+	w.n++
+	if w.n <= w.m {
+		w.head = w.tmp
+		return
+	}
+	w.patterns[w.tmp]++
+}
+
+// Result 近似熵检测P值与Q值
+func (w *ApproximateEntropyWriter) Result() (float64, float64) {
+	if w.n == 0 {
+		panic("please provide test bits")
+	}
+	if w.m >= w.n {
+		panic("block size m must be less than sequence length")
+	}
+	m := w.m
+	pm1 := make([]int, len(w.patterns))
+	copy(pm1, w.patterns)
+	// 首尾相接：补上前 m 比特
+	tmp := w.tmp
+	for i := m - 1; i >= 0; i-- {
+		tmp = (tmp<<1 | w.head>>uint(i)&1) & (len(pm1) - 1)
+		pm1[tmp]++
+	}
+	// 循环序列中第 i 个 m 比特模式为第 i 个 m+1 比特模式的高 m 比特
+	pm := make([]int, len(pm1)/2)
+	for i, c := range pm1 {
+		pm[i>>1] += c
+	}
+
+	P := approximateEntropyP(pm, pm1, w.n, m)
+	return P, P
+}
+
+// LinearComplexityWriter 线型复杂度检测的流式累计器，结果同 LinearComplexityProto
+// 已完成的块按批交给工作协程并行计算线性复杂度，同时计算的批次数不超过 CPU 核数，
+// 内存消耗为 O(CPU核数·lcBatchBlocks·m)。
+type LinearComplexityWriter struct {
+	m    int
+	j    int      // 当前批次已写入的比特数
+	cur  *lcBatch // 当前批次，nil 表示尚未取得
+	free chan *lcBatch
+	wg   sync.WaitGroup
+	mu   sync.Mutex // 保护 N、v
+	N    int
+	v    [7]float64
+	_1_m float64
+	miu  float64
+}
+
+// lcBatchBlocks 每批交给工作协程的块数
+const lcBatchBlocks = 64
+
+// lcBatch 一批块的数据及计算使用的 Berlekamp-Massey 工作区
+type lcBatch struct {
+	bits []bool
+	bm   *bmBuffers
+}
+
+// NewLinearComplexityWriter 创建线型复杂度检测累计器
+// m: m长度
+func NewLinearComplexityWriter(m int) *LinearComplexityWriter {
+	if m <= 0 {
+		panic("please provide valid test bits")
+	}
+	w := &LinearComplexityWriter{m: m, free: make(chan *lcBatch, runtime.NumCPU())}
+	w._1_m, w.miu = linearComplexityMiu(m)
+	for i := 0; i < cap(w.free); i++ {
+		w.free <- &lcBatch{bits: make([]bool, lcBatchBlocks*m), bm: newBMBuffers(m)}
+	}
+	return w
+}
+
+// Write 累计字节，总是返回 len(p), nil
+func (w *LinearComplexityWriter) Write(p []byte) (int, error) {
+	forEachBit(p, w.writeBit)
+	return len(p), nil
+}
+
+func (w *LinearComplexityWriter) writeBit(b bool) {
+	if w.cur == nil {
+		// 所有批次都在计算中时等待其中之一完成
+		w.cur = <-w.free
+	}
+	w.cur.bits[w.j] = b
+	w.j++
+	if w.j == len(w.cur.bits) {
+		w.wg.Add(1)
+		go func(batch *lcBatch) {
+			defer w.wg.Done()
+			v := w.classify(batch, lcBatchBlocks)
+			w.mu.Lock()
+			for i := range w.v {
+				w.v[i] += v[i]
+			}
+			w.N += lcBatchBlocks
+			w.mu.Unlock()
+			w.free <- batch
+		}(w.cur)
+		w.cur, w.j = nil, 0
+	}
+}
+
+// classify 批次中前 blocks 个块的线性复杂度类别频数
+func (w *LinearComplexityWriter) classify(batch *lcBatch, blocks int) [7]float64 {
+	var v [7]float64
+	for k := 0; k < blocks; k++ {
+		block := batch.bits[k*w.m : (k+1)*w.m]
+		complexity, _ := batch.bm.run(func(i int) bool { return block[i] }, w.m, func(N, h int) {})
+		T := w._1_m*(float64(complexity)-w.miu) + 2.0/9.0
+		v[linearComplexityClass(T)]++
+	}
+	return v
+}
+
+// Result 线型复杂度检测P值与Q值
+func (w *LinearComplexityWriter) Result() (float64, float64) {
+	w.wg.Wait()
+	v, N := w.v, w.N
+	// 当前批次中已完成的块
+	if blocks := w.j / w.m; blocks > 0 {
+		pv := w.classify(w.cur, blocks)
+		for i := range v {
+			v[i] += pv[i]
+		}
+		N += blocks
+	}
+	if N == 0 {
+		panic("please provide valid test bits")
+	}
+	P := linearComplexityP(v, N)
+	return P, P
+}
+
+// MaurerUniversalWriter Maurer通用统计检测（L=7，Q=1280）的流式累计器，结果同 MaurerUniversalTest
+type MaurerUniversalWriter struct {
+	n   int
+	i   int // 已完成的块数
+	tmp int
+	T   [1 << 7]int
+	sum float64
+}
+
+// NewMaurerUniversalWriter 创建Maurer通用统计检测累计器
+func NewMaurerUniversalWriter() *MaurerUniversalWriter {
+	return &MaurerUniversalWriter{}
+}
+
+// Write 累计字节，总是返回 len(p), nil
+func (w *MaurerUniversalWriter) Write(p []byte) (int, error) {
+	forEachBit(p, w.writeBit)
+	return len(p), nil
+}
+
+func (w *MaurerUniversalWriter) writeBit(b bool) {
+	const L, Q = 7, 1280
+	w.tmp = (w.tmp<<1 | b2i(b)) & (1<<L - 1)
+	w.n++
+	if w.n%L != 0 {
+		return
+	}
+	w.i++
+	if w.i > Q {
+		w.sum += math.Log(float64(w.i)-float64(w.T[w.tmp])) / math.Log(2.0)
+	}
+	w.T[w.tmp] = w.i
+}
+
+// Result Maurer通用统计检测P值与Q值
+func (w *MaurerUniversalWriter) Result() (float64, float64) {
+	const L, Q = 7, 1280
+	if w.n == 0 {
+		panic("please provide test bits")
+	}
+	return maurerP(w.sum, w.i-Q, L)
+}
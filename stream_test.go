@@ -0,0 +1,173 @@
+package randomness
+
+import (
+	"io"
+	"math/rand"
+	"testing"
+)
+
+// writeChunks 以奇数长度的分片写入，覆盖块与字节边界不对齐的情况
+func writeChunks(w io.Writer, data []byte) {
+	for i := 0; i < len(data); i += 997 {
+		_, _ = w.Write(data[i:min(i+997, len(data))])
+	}
+}
+
+func TestStreamWriters(t *testing.T) {
+	rnd := rand.New(rand.NewSource(2021))
+	random := make([]byte, 125000+13)
+	rnd.Read(random)
+	// 带有长游程与偏差的序列
+	biased := make([]byte, 2500+7)
+	for i := range biased {
+		biased[i] = byte(rnd.Intn(256)) | byte(rnd.Intn(256))
+		if i%100 < 10 {
+			biased[i] = 0xff
+		}
+	}
+	small := random[:150]
+
+	type pq struct{ p, q float64 }
+	check := func(name string, data []byte, got, want pq) {
+		if got != want {
+			t.Errorf("%s (%d bytes): stream %v, batch %v", name, len(data), got, want)
+		}
+	}
+	for _, data := range [][]byte{random, biased, small} {
+		bits := B2bitArr(data)
+
+		mono := NewMonoBitFrequencyWriter()
+		writeChunks(mono, data)
+		p, q := mono.Result()
+		p0, q0 := MonoBitFrequencyTest(bits)
+		check("MonoBitFrequency", data, pq{p, q}, pq{p0, q0})
+
+		for _, m := range []int{100, 1000} {
+			w := NewFrequencyWithinBlockWriter(m)
+			writeChunks(w, data)
+			p, q := w.Result()
+			p0, q0 := FrequencyWithinBlockProto(bits, m)
+			check("FrequencyWithinBlock", data, pq{p, q}, pq{p0, q0})
+		}
+
+		for _, m := range []int{2, 4, 5, 8} {
+			w := NewPokerWriter(m)
+			writeChunks(w, data)
+			p, q := w.Result()
+			p0, q0 := PokerProto(bits, m)
+			check("Poker", data, pq{p, q}, pq{p0, q0})
+		}
+
+		for _, m := range []int{3, 5, 7} {
+			w := NewOverlappingTemplateMatchingWriter(m)
+			writeChunks(w, data)
+			p1, p2, q1, q2 := w.Result()
+			p10, p20, q10, q20 := OverlappingTemplateMatchingProto(bits, m)
+			check("OverlappingTemplateMatching P1", data, pq{p1, q1}, pq{p10, q10})
+			check("OverlappingTemplateMatching P2", data, pq{p2, q2}, pq{p20, q20})
+		}
+
+		runs := NewRunsWriter()
+		writeChunks(runs, data)
+		p, q = runs.Result()
+		p0, q0 = RunsTest(bits)
+		check("Runs", data, pq{p, q}, pq{p0, q0})
+
+		rd := NewRunsDistributionWriter()
+		writeChunks(rd, data)
+		p, q = rd.Result()
+		p0, q0 = RunsDistributionTest(bits)
+		check("RunsDistribution", data, pq{p, q}, pq{p0, q0})
+
+		for _, checkOne := range []bool{true, false} {
+			w := NewLongestRunOfOnesInABlockWriter(checkOne)
+			writeChunks(w, data)
+			p, q := w.Result()
+			p0, q0 := LongestRunOfOnesInABlockProto(bits, checkOne)
+			check("LongestRunOfOnesInABlock", data, pq{p, q}, pq{p0, q0})
+		}
+
+		for _, k := range []int{3, 7, 15} {
+			w := NewBinaryDerivativeWriter(k)
+			writeChunks(w, data)
+			p, q := w.Result()
+			p0, q0 := BinaryDerivativeProto(bits, k)
+			check("BinaryDerivative", data, pq{p, q}, pq{p0, q0})
+		}
+
+		for _, d := range []int{1, 2, 8, 16} {
+			w := NewAutocorrelationWriter(d)
+			writeChunks(w, data)
+			p, q := w.Result()
+			p0, q0 := AutocorrelationProto(bits, d)
+			check("Autocorrelation", data, pq{p, q}, pq{p0, q0})
+		}
+
+		cusum := NewCumulativeWriter()
+		writeChunks(cusum, data)
+		for _, forward := range []bool{true, false} {
+			p, q := cusum.Result(forward)
+			p0, q0 := CumulativeTest(bits, forward)
+			check("Cumulative", data, pq{p, q}, pq{p0, q0})
+		}
+
+		for _, m := range []int{2, 5} {
+			w := NewApproximateEntropyWriter(m)
+			writeChunks(w, data)
+			p, q := w.Result()
+			p0, q0 := ApproximateEntropyProto(bits, m)
+			check("ApproximateEntropy", data, pq{p, q}, pq{p0, q0})
+		}
+
+		// 以下检测需要较长的序列
+		if len(data) < 2000 {
+			continue
+		}
+		rank := NewMatrixRankWriter()
+		writeChunks(rank, data)
+		p, q = rank.Result()
+		p0, q0 = MatrixRankTest(bits)
+		check("MatrixRank", data, pq{p, q}, pq{p0, q0})
+
+		lc := NewLinearComplexityWriter(500)
+		writeChunks(lc, data)
+		p, q = lc.Result()
+		p0, q0 = LinearComplexityProto(bits, 500)
+		check("LinearComplexity", data, pq{p, q}, pq{p0, q0})
+
+		maurer := NewMaurerUniversalWriter()
+		writeChunks(maurer, data)
+		p, q = maurer.Result()
+		p0, q0 = MaurerUniversalTest(bits)
+		check("MaurerUniversal", data, pq{p, q}, pq{p0, q0})
+	}
+}
+
+func TestStreamWriterResultRepeatable(t *testing.T) {
+	data := make([]byte, 20000)
+	rand.New(rand.NewSource(7)).Read(data)
+	w := NewApproximateEntropyWriter(2)
+	_, _ = w.Write(data[:10000])
+	p1, _ := w.Result()
+	if p, _ := w.Result(); p != p1 {
+		t.Fatalf("Result changed the writer state")
+	}
+	_, _ = w.Write(data[10000:])
+	p, _ := w.Result()
+	if p0, _ := ApproximateEntropyTestBytes(data, 2); p != p0 {
+		t.Fatalf("stream %f, batch %f", p, p0)
+	}
+
+	// 线型复杂度累计器的当前批次尚未交给工作协程
+	lc := NewLinearComplexityWriter(500)
+	_, _ = lc.Write(data[:10000])
+	p1, _ = lc.Result()
+	if p, _ := lc.Result(); p != p1 {
+		t.Fatalf("Result changed the linear complexity writer state")
+	}
+	_, _ = lc.Write(data[10000:])
+	p, _ = lc.Result()
+	if p0, _ := LinearComplexityTestBytes(data, 500); p != p0 {
+		t.Fatalf("linear complexity stream %f, batch %f", p, p0)
+	}
+}
@@ -0,0 +1,264 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"text/tabwriter"
+)
+
+// diffOptions diff 子命令参数
+type diffOptions struct {
+	threshold   float64 // 通过判定阈值
+	shift       float64 // 列出P值变化超过该值的检测项
+	maxShift    float64 // P值变化容差，负数表示不检查
+	maxFlips    int     // 由通过变为未通过的检测项数量容差，负数表示不检查
+	maxRateDrop float64 // 通过率下降容差，负数表示不检查
+}
+
+// itemDiff 同一样本同一检测项目在两份报告中的结果
+type itemDiff struct {
+	File     string
+	TestName string
+	Old, New TestItem
+}
+
+// Shift P值变化
+func (d itemDiff) Shift() float64 {
+	return d.New.PValue - d.Old.PValue
+}
+
+// rateDiff 同一检测项目在两份报告中的通过率
+type rateDiff struct {
+	TestName string
+	Old, New *AnalysisResult // 只在一份报告中出现时另一个为 nil
+}
+
+// reportDiff 两份检测报告的差异
+type reportDiff struct {
+	OnlyOld, OnlyNew []string   // 只在旧、新报告中出现的样本
+	Common           int        // 两份报告共有的样本数
+	Rates            []rateDiff // 各检测项目的通过率
+	Regressed        []itemDiff // 由通过变为未通过
+	Fixed            []itemDiff // 由未通过变为通过
+	Shifted          []itemDiff // P值变化超过 -shift 的检测项，按变化幅度由大到小排列
+	MaxShift         *itemDiff  // P值变化最大的检测项
+}
+
+func diffUsage(fs *flag.FlagSet) func() {
+	return func() {
+		_, _ = fmt.Fprintf(os.Stderr, `rddetector diff 比较两份检测报告
+
+rddetector diff [-t 通过阈值] [-shift 列出阈值] [-max-shift 容差] [-max-flips 容差] [-max-rate-drop 容差] 旧报告 新报告
+
+	示例: rddetector diff v1.2/RandomnessTestReport.csv v1.3/RandomnessTestReport.csv
+	示例: rddetector diff -max-flips 0 -max-rate-drop 0.01 old.json new.json
+
+	报告为 -f csv 或 -f json 生成的检测报告，按样本名称与检测项目对齐比较，报告中的样本名称重复时视为输入错误
+	退出码: 0 没有超出容差的回退，1 存在超出容差的回退，2 输入错误
+
+`)
+		fs.PrintDefaults()
+	}
+}
+
+// diffMain diff 子命令，返回退出码
+func diffMain(args []string) int {
+	var opt diffOptions
+	fs := flag.NewFlagSet("diff", flag.ExitOnError)
+	fs.Float64Var(&opt.threshold, "t", 0.981, "通过判定阈值（默认98.1%）")
+	fs.Float64Var(&opt.shift, "shift", 0.1, "列出P值变化超过该值的检测项")
+	fs.Float64Var(&opt.maxShift, "max-shift", -1, "P值变化容差，任一检测项的P值变化超过该值时视为回退，负数表示不检查")
+	fs.IntVar(&opt.maxFlips, "max-flips", -1, "由通过变为未通过的检测项数量容差，超过时视为回退，负数表示不检查")
+	fs.Float64Var(&opt.maxRateDrop, "max-rate-drop", -1, "通过率下降容差，任一检测项目的通过率下降超过该值时视为回退，负数表示不检查")
+	fs.Usage = diffUsage(fs)
+	_ = fs.Parse(args)
+	if fs.NArg() != 2 {
+		fs.Usage()
+		return ExitInput
+	}
+
+	oldResults, err := loadReport(fs.Arg(0))
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "%v\n", err)
+		return ExitInput
+	}
+	newResults, err := loadReport(fs.Arg(1))
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "%v\n", err)
+		return ExitInput
+	}
+
+	for i, results := range [][]*R{oldResults, newResults} {
+		if err := uniqueNames(results); err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "%s: %v\n", fs.Arg(i), err)
+			return ExitInput
+		}
+	}
+
+	d := diffReports(oldResults, newResults, &opt)
+	d.print(os.Stdout, fs.Arg(0), fs.Arg(1), &opt)
+	if regressions := d.regressions(&opt); len(regressions) > 0 {
+		_, _ = fmt.Fprintf(os.Stdout, "\n回退:\n")
+		for _, r := range regressions {
+			_, _ = fmt.Fprintf(os.Stdout, "  %s\n", r)
+		}
+		return ExitFail
+	}
+	return ExitPass
+}
+
+// uniqueNames 检查报告中的样本名称没有重复，重复的样本无法对齐比较
+func uniqueNames(results []*R) error {
+	seen := make(map[string]bool, len(results))
+	for _, r := range results {
+		if seen[r.Name] {
+			return fmt.Errorf("样本名称 %s 重复，无法与另一份报告对齐", r.Name)
+		}
+		seen[r.Name] = true
+	}
+	return nil
+}
+
+// diffReports 按样本名称与检测项目对齐比较两份检测报告，两份报告中的样本名称均不能重复
+func diffReports(oldResults, newResults []*R, opt *diffOptions) *reportDiff {
+	d := &reportDiff{}
+
+	newByName := make(map[string]*R, len(newResults))
+	for _, r := range newResults {
+		newByName[r.Name] = r
+	}
+	oldNames := make(map[string]bool, len(oldResults))
+	for _, o := range oldResults {
+		oldNames[o.Name] = true
+		n, ok := newByName[o.Name]
+		if !ok {
+			d.OnlyOld = append(d.OnlyOld, o.Name)
+			continue
+		}
+		d.Common++
+		newItems := make(map[string]TestItem, len(n.TestItems))
+		for _, item := range n.TestItems {
+			newItems[item.TestName] = item
+		}
+		for _, item := range o.TestItems {
+			ni, ok := newItems[item.TestName]
+			if !ok {
+				continue
+			}
+			id := itemDiff{File: o.Name, TestName: item.TestName, Old: item, New: ni}
+			switch oldPass, newPass := itemPassed(item), itemPassed(ni); {
+			case oldPass && !newPass:
+				d.Regressed = append(d.Regressed, id)
+			case !oldPass && newPass:
+				d.Fixed = append(d.Fixed, id)
+			}
+			shift := math.Abs(id.Shift())
+			if shift > opt.shift {
+				d.Shifted = append(d.Shifted, id)
+			}
+			if d.MaxShift == nil || shift > math.Abs(d.MaxShift.Shift()) {
+				max := id
+				d.MaxShift = &max
+			}
+		}
+	}
+	for _, n := range newResults {
+		if !oldNames[n.Name] {
+			d.OnlyNew = append(d.OnlyNew, n.Name)
+		}
+	}
+	sort.SliceStable(d.Shifted, func(i, j int) bool {
+		return math.Abs(d.Shifted[i].Shift()) > math.Abs(d.Shifted[j].Shift())
+	})
+
+	// 通过率按各自报告中的全部样本统计，检测项目按旧报告的顺序排列，新增的项目在后
+	oldRates := analyze(oldResults, opt.threshold)
+	newRates := analyze(newResults, opt.threshold)
+	index := make(map[string]int)
+	for i := range oldRates {
+		index[oldRates[i].TestName] = len(d.Rates)
+		d.Rates = append(d.Rates, rateDiff{TestName: oldRates[i].TestName, Old: &oldRates[i]})
+	}
+	for i := range newRates {
+		if k, ok := index[newRates[i].TestName]; ok {
+			d.Rates[k].New = &newRates[i]
+		} else {
+			d.Rates = append(d.Rates, rateDiff{TestName: newRates[i].TestName, New: &newRates[i]})
+		}
+	}
+	return d
+}
+
+// regressions 超出容差的回退说明
+func (d *reportDiff) regressions(opt *diffOptions) []string {
+	var res []string
+	if opt.maxFlips >= 0 && len(d.Regressed) > opt.maxFlips {
+		res = append(res, fmt.Sprintf("%d 个检测项由通过变为未通过，容差 %d", len(d.Regressed), opt.maxFlips))
+	}
+	if opt.maxShift >= 0 && d.MaxShift != nil && math.Abs(d.MaxShift.Shift()) > opt.maxShift {
+		res = append(res, fmt.Sprintf("%s %s P值变化 %+.6f，容差 %g", d.MaxShift.File, d.MaxShift.TestName, d.MaxShift.Shift(), opt.maxShift))
+	}
+	if opt.maxRateDrop >= 0 {
+		for _, r := range d.Rates {
+			if r.Old != nil && r.New != nil && r.Old.PassRate-r.New.PassRate > opt.maxRateDrop {
+				res = append(res, fmt.Sprintf("%s 通过率 %.4f -> %.4f，下降超过容差 %g", r.TestName, r.Old.PassRate, r.New.PassRate, opt.maxRateDrop))
+			}
+		}
+	}
+	return res
+}
+
+// print 输出差异报告
+func (d *reportDiff) print(w io.Writer, oldPath, newPath string, opt *diffOptions) {
+	_, _ = fmt.Fprintf(w, "旧报告: %s\n新报告: %s\n", oldPath, newPath)
+	_, _ = fmt.Fprintf(w, "样本: 共有 %d，仅旧报告 %d，仅新报告 %d\n", d.Common, len(d.OnlyOld), len(d.OnlyNew))
+	for _, name := range d.OnlyOld {
+		_, _ = fmt.Fprintf(w, "  - %s\n", name)
+	}
+	for _, name := range d.OnlyNew {
+		_, _ = fmt.Fprintf(w, "  + %s\n", name)
+	}
+
+	_, _ = fmt.Fprintf(w, "\n通过率（通过判定阈值 %.3f）:\n", opt.threshold)
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	_, _ = fmt.Fprintf(tw, "  检测项目\t旧通过率\t新通过率\t变化\t\n")
+	for _, r := range d.Rates {
+		switch {
+		case r.Old == nil:
+			_, _ = fmt.Fprintf(tw, "  %s\t-\t%.4f%s\t新增\t\n", r.TestName, r.New.PassRate, passMark(r.New))
+		case r.New == nil:
+			_, _ = fmt.Fprintf(tw, "  %s\t%.4f%s\t-\t移除\t\n", r.TestName, r.Old.PassRate, passMark(r.Old))
+		default:
+			_, _ = fmt.Fprintf(tw, "  %s\t%.4f%s\t%.4f%s\t%+.4f\t\n", r.TestName, r.Old.PassRate, passMark(r.Old), r.New.PassRate, passMark(r.New), r.New.PassRate-r.Old.PassRate)
+		}
+	}
+	_ = tw.Flush()
+
+	printItems := func(title string, items []itemDiff) {
+		_, _ = fmt.Fprintf(w, "\n%s (%d):\n", title, len(items))
+		tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+		for _, id := range items {
+			_, _ = fmt.Fprintf(tw, "  %s\t%s\tP %.6f -> %.6f\tQ %.6f -> %.6f\t%+.6f\t\n",
+				id.File, id.TestName, id.Old.PValue, id.New.PValue, id.Old.QValue, id.New.QValue, id.Shift())
+		}
+		_ = tw.Flush()
+	}
+	printItems("由通过变为未通过", d.Regressed)
+	printItems("由未通过变为通过", d.Fixed)
+	printItems(fmt.Sprintf("P值变化超过 %g", opt.shift), d.Shifted)
+	if d.MaxShift != nil {
+		_, _ = fmt.Fprintf(w, "\n最大P值变化: %+.6f（%s %s）\n", d.MaxShift.Shift(), d.MaxShift.File, d.MaxShift.TestName)
+	}
+}
+
+// passMark 未达到通过判定阈值的通过率标记
+func passMark(a *AnalysisResult) string {
+	if a.IsPassed {
+		return ""
+	}
+	return " ✗"
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func diffResult(name string, ps ...float64) *R {
+	r := &R{Name: name}
+	for i, p := range ps {
+		r.TestItems = append(r.TestItems, TestItem{PValue: p, QValue: p, TestName: []string{"A", "B", "C"}[i]})
+	}
+	return r
+}
+
+func TestDiffReports(t *testing.T) {
+	oldResults := []*R{
+		diffResult("x", 0.5, 0.5),
+		diffResult("y", 0.5, 0.005),
+		diffResult("old", 0.5, 0.5),
+		diffResult("z", 0.001, 0.001),
+	}
+	newResults := []*R{
+		diffResult("new", 0.5, 0.5, 0.5),
+		diffResult("y", 0.6, 0.02, 0.5),
+		diffResult("x", 0.005, 0.2, 0.5),
+	}
+	opt := &diffOptions{threshold: 0.981, shift: 0.1, maxShift: -1, maxFlips: -1, maxRateDrop: -1}
+	d := diffReports(oldResults, newResults, opt)
+
+	if d.Common != 2 || !reflect.DeepEqual(d.OnlyOld, []string{"old", "z"}) || !reflect.DeepEqual(d.OnlyNew, []string{"new"}) {
+		t.Fatalf("samples: common %d, only old %v, only new %v", d.Common, d.OnlyOld, d.OnlyNew)
+	}
+	if len(d.Regressed) != 1 || d.Regressed[0].File != "x" || d.Regressed[0].TestName != "A" {
+		t.Fatalf("regressed: %+v", d.Regressed)
+	}
+	if len(d.Fixed) != 1 || d.Fixed[0].File != "y" || d.Fixed[0].TestName != "B" {
+		t.Fatalf("fixed: %+v", d.Fixed)
+	}
+	// P值变化: x/A -0.495, x/B -0.3, y/A +0.1, y/B +0.015
+	if len(d.Shifted) != 2 || d.Shifted[0].TestName != "A" || d.Shifted[1].TestName != "B" || d.Shifted[1].File != "x" {
+		t.Fatalf("shifted: %+v", d.Shifted)
+	}
+	if d.MaxShift == nil || d.MaxShift.File != "x" || d.MaxShift.TestName != "A" {
+		t.Fatalf("max shift: %+v", d.MaxShift)
+	}
+
+	// 通过率按旧报告的检测项目顺序排列，新增的项目在后
+	if len(d.Rates) != 3 || d.Rates[0].TestName != "A" || d.Rates[2].TestName != "C" || d.Rates[2].Old != nil {
+		t.Fatalf("rates: %+v", d.Rates)
+	}
+	if a := d.Rates[0]; a.Old.PassCount != 3 || a.Old.TotalCount != 4 || a.New.PassCount != 2 || a.New.TotalCount != 3 {
+		t.Fatalf("rate A: %+v %+v", a.Old, a.New)
+	}
+
+	if r := d.regressions(opt); len(r) != 0 {
+		t.Fatalf("unexpected regressions: %v", r)
+	}
+	for _, c := range []struct {
+		opt  diffOptions
+		want int
+	}{
+		{diffOptions{maxShift: -1, maxFlips: 0, maxRateDrop: -1}, 1},
+		{diffOptions{maxShift: -1, maxFlips: 1, maxRateDrop: -1}, 0},
+		{diffOptions{maxShift: 0.4, maxFlips: -1, maxRateDrop: -1}, 1},
+		{diffOptions{maxShift: 0.5, maxFlips: -1, maxRateDrop: -1}, 0},
+		// A: 0.75 -> 0.6667，B: 0.5 -> 0.6667，C 只在新报告中出现
+		{diffOptions{maxShift: -1, maxFlips: -1, maxRateDrop: 0.05}, 1},
+		{diffOptions{maxShift: -1, maxFlips: -1, maxRateDrop: 0.1}, 0},
+		{diffOptions{maxShift: 0, maxFlips: 0, maxRateDrop: 0}, 3},
+	} {
+		if r := d.regressions(&c.opt); len(r) != c.want {
+			t.Errorf("%+v: regressions %v, want %d", c.opt, r, c.want)
+		}
+	}
+}
+
+func TestUniqueNames(t *testing.T) {
+	// 不同子目录中的同名文件以相对路径命名，不会重复
+	if err := uniqueNames([]*R{diffResult("a/x.bin", 0.5), diffResult("b/x.bin", 0.5)}); err != nil {
+		t.Fatal(err)
+	}
+	if err := uniqueNames([]*R{diffResult("x.bin", 0.5), diffResult("y.bin", 0.5), diffResult("x.bin", 0.5)}); err == nil {
+		t.Fatal("expected error on duplicate names")
+	}
+}
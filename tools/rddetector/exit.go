@@ -0,0 +1,28 @@
+package main
+
+import (
+	"log"
+	"os"
+	"runtime/debug"
+)
+
+// 进程退出码，供 CI 等自动化流程判断检测结果
+const (
+	ExitPass     = 0 // 所有检测项目的通过率均达到通过判定阈值
+	ExitFail     = 1 // 存在通过率未达到通过判定阈值的检测项目
+	ExitInput    = 2 // 输入错误：参数错误、待检测数据无法读取或无法识别
+	ExitInternal = 3 // 内部错误：生成报告失败或程序异常
+)
+
+// fatalf 输出错误信息并以退出码 code 退出
+func fatalf(code int, format string, v ...interface{}) {
+	log.Printf(format, v...)
+	os.Exit(code)
+}
+
+// exitOnPanic 捕获 panic 并以内部错误退出，避免运行时以退出码 2 退出与输入错误混淆，需在各协程中 defer 调用
+func exitOnPanic() {
+	if r := recover(); r != nil {
+		fatalf(ExitInternal, "内部错误: %v\n%s", r, debug.Stack())
+	}
+}
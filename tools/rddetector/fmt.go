@@ -1,13 +1,16 @@
 package main
 
 import (
+	"bytes"
 	"encoding/csv"
 	"encoding/json"
+	"encoding/xml"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
 	"strconv"
+	"strings"
 	"sync"
 )
 
@@ -25,18 +28,26 @@ type ReportFormatter interface {
 	FormatAnalysisReport(results []AnalysisResult, w io.Writer) error
 }
 
+// ReportParser 报告解析接口，读取对应 ReportFormatter 写出的检测报告
+type ReportParser interface {
+	// ParseTestReport 解析检测报告
+	// - r: 输入流
+	// - 返回值: 检测结果, 错误信息
+	ParseTestReport(r io.Reader) ([]*R, error)
+}
+
 // ReportCollector 统一数据收集器
 type ReportCollector struct {
 	results      []*R       // 检测结果
 	mu           sync.Mutex // 互斥锁
-	format       string     // 输出格式 (csv/json)
+	format       string     // 输出格式 (csv/json/xml/html/xlsx/junit/sts)
 	reportPath   string     // 报告输出路径
 	analysisPath string     // 分析报告输出路径
 	threshold    float64    // 通过判定阈值
 }
 
 // NewReportCollector 创建新的数据收集器
-// - format: 输出格式 (csv/json/xml)
+// - format: 输出格式 (csv/json/xml/html/xlsx/junit/sts)
 // - reportPath: 报告输出路径
 // - analysisPath: 分析报告输出路径
 // - threshold: 通过判定阈值 (0.0-1.0)
@@ -85,6 +96,16 @@ func (c *ReportCollector) GenerateReports() error {
 	return nil
 }
 
+// Passed 所有检测项目的通过率是否均达到通过判定阈值
+func (c *ReportCollector) Passed() bool {
+	for _, a := range analyze(c.GetResults(), c.threshold) {
+		if !a.IsPassed {
+			return false
+		}
+	}
+	return true
+}
+
 // generateTestReport 生成检测报告
 func (c *ReportCollector) generateTestReport(results []*R, outputPath string) error {
 	// 创建输出文件
@@ -95,13 +116,17 @@ func (c *ReportCollector) generateTestReport(results []*R, outputPath string) er
 	}
 	defer file.Close()
 
-	formatter := getFormatter(c.format)
+	formatter := getFormatter(c.format, c.threshold)
 	return formatter.FormatTestReport(results, file)
 }
 
-// generateAnalysisReport 生成分析报告
-func (c *ReportCollector) generateAnalysisReport(results []*R, outputPath string) error {
-	// 统计每个检测项目的通过情况
+// itemPassed 单个检测项目是否通过，根据GM/T 0005-2021规范：P >= 0.01 且 Q >= 0.0001
+func itemPassed(item TestItem) bool {
+	return item.PValue >= 0.01 && item.QValue >= 0.0001
+}
+
+// analyze 统计每个检测项目的通过情况
+func analyze(results []*R, threshold float64) []AnalysisResult {
 	var analysisResults []AnalysisResult
 	totalFiles := len(results)
 
@@ -114,26 +139,32 @@ func (c *ReportCollector) generateAnalysisReport(results []*R, outputPath string
 		passCount := 0
 
 		for _, result := range results {
-			if i < len(result.TestItems) {
-				// 根据GM/T 0005-2021规范：P >= 0.01 且 Q >= 0.0001
-				if result.TestItems[i].PValue >= 0.01 && result.TestItems[i].QValue >= 0.0001 {
-					passCount++
-				}
+			if i < len(result.TestItems) && itemPassed(result.TestItems[i]) {
+				passCount++
 			}
 		}
 
 		passRate := float64(passCount) / float64(totalFiles)
-		isPassed := passRate >= c.threshold
+		isPassed := passRate >= threshold
 
 		analysisResults = append(analysisResults, AnalysisResult{
 			TestName:    testItem.TestName,
 			PassCount:   passCount,
 			TotalCount:  totalFiles,
 			PassRate:    passRate,
-			Requirement: c.threshold,
+			Requirement: threshold,
 			IsPassed:    isPassed,
 		})
 	}
+	return analysisResults
+}
+
+// generateAnalysisReport 生成分析报告
+func (c *ReportCollector) generateAnalysisReport(results []*R, outputPath string) error {
+	analysisResults := analyze(results, c.threshold)
+	if len(analysisResults) == 0 {
+		return nil
+	}
 
 	// 创建输出文件
 	_ = os.MkdirAll(filepath.Dir(outputPath), os.FileMode(0600))
@@ -143,7 +174,7 @@ func (c *ReportCollector) generateAnalysisReport(results []*R, outputPath string
 	}
 	defer file.Close()
 
-	formatter := getFormatter(c.format)
+	formatter := getFormatter(c.format, c.threshold)
 	return formatter.FormatAnalysisReport(analysisResults, file)
 }
 
@@ -162,9 +193,26 @@ func (f *JSONFormatter) FormatAnalysisReport(results []AnalysisResult, w io.Writ
 	return encoder.Encode(results)
 }
 
+func (f *JSONFormatter) ParseTestReport(r io.Reader) ([]*R, error) {
+	var results []*R
+	if err := json.NewDecoder(r).Decode(&results); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
 // CSVFormatter CSV格式输出
 type CSVFormatter struct{}
 
+// formatPQ 检测报告中的P值、Q值，输出可精确还原的最短十进制形式。
+// 读回的检测报告（-from、diff）按 Alpha、AlphaT 重新判定，四舍五入会使临界值附近的判定结果翻转。
+func formatPQ(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// byteStatsHeaders 字节统计的CSV表头
+var byteStatsHeaders = []string{"熵(比特/字节)", "卡方", "卡方P值", "算术平均值", "蒙特卡洛π", "π误差(%)", "序列相关系数"}
+
 func (f *CSVFormatter) FormatTestReport(results []*R, w io.Writer) error {
 	writer := csv.NewWriter(w)
 	defer writer.Flush()
@@ -175,6 +223,9 @@ func (f *CSVFormatter) FormatTestReport(results []*R, w io.Writer) error {
 		for _, item := range results[0].TestItems {
 			headers = append(headers, item.TestName+" P值", item.TestName+" Q值")
 		}
+		if results[0].ByteStats != nil {
+			headers = append(headers, byteStatsHeaders...)
+		}
 	}
 	if err := writer.Write(headers); err != nil {
 		return err
@@ -185,8 +236,18 @@ func (f *CSVFormatter) FormatTestReport(results []*R, w io.Writer) error {
 		record := []string{result.Name}
 		for _, item := range result.TestItems {
 			record = append(record,
-				fmt.Sprintf("%.6f", item.PValue),
-				fmt.Sprintf("%.6f", item.QValue))
+				formatPQ(item.PValue),
+				formatPQ(item.QValue))
+		}
+		if st := result.ByteStats; st != nil {
+			record = append(record,
+				fmt.Sprintf("%.6f", st.Entropy),
+				fmt.Sprintf("%.2f", st.ChiSquare),
+				fmt.Sprintf("%.6f", st.ChiSquareP),
+				fmt.Sprintf("%.4f", st.Mean),
+				fmt.Sprintf("%.9f", st.MonteCarloPi),
+				fmt.Sprintf("%.2f", st.MonteCarloPiError),
+				fmt.Sprintf("%.6f", st.SerialCorrelation))
 		}
 		if err := writer.Write(record); err != nil {
 			return err
@@ -231,6 +292,66 @@ func (f *CSVFormatter) FormatAnalysisReport(results []AnalysisResult, w io.Write
 	return nil
 }
 
+func (f *CSVFormatter) ParseTestReport(r io.Reader) ([]*R, error) {
+	reader := csv.NewReader(r)
+	headers, err := reader.Read()
+	if err != nil {
+		return nil, err
+	}
+	if len(headers) == 0 || headers[0] != "文件名" {
+		return nil, fmt.Errorf("不是检测报告，首列应为 \"文件名\"")
+	}
+
+	// 表头依次为各检测项目的 "名称 P值"、"名称 Q值"，开启字节统计时末尾为字节统计各列
+	var names []string
+	i := 1
+	for ; i+1 < len(headers) && strings.HasSuffix(headers[i], " P值"); i += 2 {
+		name := strings.TrimSuffix(headers[i], " P值")
+		if headers[i+1] != name+" Q值" {
+			return nil, fmt.Errorf("第 %d 列 %q 与P值列 %q 不对应", i+2, headers[i+1], headers[i])
+		}
+		names = append(names, name)
+	}
+	hasStats := len(headers)-i == len(byteStatsHeaders) && headers[i] == byteStatsHeaders[0]
+	if i != len(headers) && !hasStats {
+		return nil, fmt.Errorf("无法识别第 %d 列 %q", i+1, headers[i])
+	}
+
+	var results []*R
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return results, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		values := make([]float64, len(record)-1)
+		for j := range values {
+			if values[j], err = strconv.ParseFloat(record[j+1], 64); err != nil {
+				return nil, fmt.Errorf("%s 第 %d 列: %v", record[0], j+2, err)
+			}
+		}
+		result := &R{Name: record[0], TestItems: make([]TestItem, len(names))}
+		for k, name := range names {
+			result.TestItems[k] = TestItem{PValue: values[2*k], QValue: values[2*k+1], TestName: name}
+		}
+		if hasStats {
+			v := values[2*len(names):]
+			result.ByteStats = &ByteStats{
+				Entropy:           v[0],
+				ChiSquare:         v[1],
+				ChiSquareP:        v[2],
+				Mean:              v[3],
+				MonteCarloPi:      v[4],
+				MonteCarloPiError: v[5],
+				SerialCorrelation: v[6],
+			}
+		}
+		results = append(results, result)
+	}
+}
+
 // XMLFormatter XML格式输出
 type XMLFormatter struct{}
 
@@ -241,14 +362,22 @@ func (f *XMLFormatter) FormatTestReport(results []*R, w io.Writer) error {
 	}
 
 	for _, result := range results {
-		_, err = w.Write([]byte(fmt.Sprintf("  <File name=\"%s\">\n", result.Name)))
+		_, err = w.Write([]byte(fmt.Sprintf("  <File name=\"%s\">\n", xmlEscape(result.Name))))
 		if err != nil {
 			return err
 		}
 
 		for _, item := range result.TestItems {
-			_, err = w.Write([]byte(fmt.Sprintf("    <Test name=\"%s\" p=\"%.6f\" q=\"%.6f\"/>\n",
-				item.TestName, item.PValue, item.QValue)))
+			_, err = w.Write([]byte(fmt.Sprintf("    <Test name=\"%s\" p=\"%s\" q=\"%s\"/>\n",
+				xmlEscape(item.TestName), formatPQ(item.PValue), formatPQ(item.QValue))))
+			if err != nil {
+				return err
+			}
+		}
+
+		if st := result.ByteStats; st != nil {
+			_, err = w.Write([]byte(fmt.Sprintf("    <ByteStatistics entropy=\"%.6f\" chiSquare=\"%.2f\" chiSquareP=\"%.6f\" mean=\"%.4f\" monteCarloPi=\"%.9f\" monteCarloPiError=\"%.2f\" serialCorrelation=\"%.6f\"/>\n",
+				st.Entropy, st.ChiSquare, st.ChiSquareP, st.Mean, st.MonteCarloPi, st.MonteCarloPiError, st.SerialCorrelation)))
 			if err != nil {
 				return err
 			}
@@ -277,7 +406,7 @@ func (f *XMLFormatter) FormatAnalysisReport(results []AnalysisResult, w io.Write
 		}
 
 		_, err = w.Write([]byte(fmt.Sprintf("  <Test name=\"%s\" passCount=\"%d\" totalCount=\"%d\" passRate=\"%.4f\" requirement=\"%.3f\" isPassed=\"%s\"/>\n",
-			result.TestName, result.PassCount, result.TotalCount, result.PassRate, result.Requirement, isPassedStr)))
+			xmlEscape(result.TestName), result.PassCount, result.TotalCount, result.PassRate, result.Requirement, isPassedStr)))
 		if err != nil {
 			return err
 		}
@@ -287,16 +416,111 @@ func (f *XMLFormatter) FormatAnalysisReport(results []AnalysisResult, w io.Write
 	return err
 }
 
-// getFormatter 根据格式名称获取格式化器
-func getFormatter(format string) ReportFormatter {
+// xmlTestReport XML 检测报告的解析结构，与 FormatTestReport 的输出对应
+type xmlTestReport struct {
+	XMLName xml.Name `xml:"RandomnessTestReport"`
+	Files   []struct {
+		Name  string `xml:"name,attr"`
+		Tests []struct {
+			Name string  `xml:"name,attr"`
+			P    float64 `xml:"p,attr"`
+			Q    float64 `xml:"q,attr"`
+		} `xml:"Test"`
+		Stats *struct {
+			Entropy           float64 `xml:"entropy,attr"`
+			ChiSquare         float64 `xml:"chiSquare,attr"`
+			ChiSquareP        float64 `xml:"chiSquareP,attr"`
+			Mean              float64 `xml:"mean,attr"`
+			MonteCarloPi      float64 `xml:"monteCarloPi,attr"`
+			MonteCarloPiError float64 `xml:"monteCarloPiError,attr"`
+			SerialCorrelation float64 `xml:"serialCorrelation,attr"`
+		} `xml:"ByteStatistics"`
+	} `xml:"File"`
+}
+
+func (f *XMLFormatter) ParseTestReport(r io.Reader) ([]*R, error) {
+	var report xmlTestReport
+	if err := xml.NewDecoder(r).Decode(&report); err != nil {
+		return nil, err
+	}
+	results := make([]*R, 0, len(report.Files))
+	for _, file := range report.Files {
+		result := &R{Name: file.Name, TestItems: make([]TestItem, len(file.Tests))}
+		for i, t := range file.Tests {
+			result.TestItems[i] = TestItem{PValue: t.P, QValue: t.Q, TestName: t.Name}
+		}
+		if st := file.Stats; st != nil {
+			result.ByteStats = &ByteStats{
+				Entropy:           st.Entropy,
+				ChiSquare:         st.ChiSquare,
+				ChiSquareP:        st.ChiSquareP,
+				Mean:              st.Mean,
+				MonteCarloPi:      st.MonteCarloPi,
+				MonteCarloPiError: st.MonteCarloPiError,
+				SerialCorrelation: st.SerialCorrelation,
+			}
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// xmlEscape 转义 XML 属性值，检测项目名称中含有引号
+func xmlEscape(s string) string {
+	var buf bytes.Buffer
+	_ = xml.EscapeText(&buf, []byte(s))
+	return buf.String()
+}
+
+// getFormatter 根据格式名称获取格式化器，threshold 为通过判定阈值
+func getFormatter(format string, threshold float64) ReportFormatter {
 	switch format {
 	case "json":
 		return &JSONFormatter{}
 	case "xml":
 		return &XMLFormatter{}
+	case "html":
+		return &HTMLFormatter{Threshold: threshold}
+	case "xlsx":
+		return &XLSXFormatter{Threshold: threshold}
+	case "junit":
+		return &JUnitFormatter{Threshold: threshold}
+	case "sts":
+		return &STSFormatter{}
 	case "csv":
 		fallthrough
 	default:
 		return &CSVFormatter{}
 	}
 }
+
+// getParser 根据报告文件扩展名获取解析器
+func getParser(p string) (ReportParser, error) {
+	switch strings.ToLower(filepath.Ext(p)) {
+	case ".json":
+		return &JSONFormatter{}, nil
+	case ".csv":
+		return &CSVFormatter{}, nil
+	case ".xml":
+		return &XMLFormatter{}, nil
+	}
+	return nil, fmt.Errorf("无法识别报告 %s 的格式，支持 .json、.csv、.xml", p)
+}
+
+// loadReport 读取检测报告
+func loadReport(p string) ([]*R, error) {
+	parser, err := getParser(p)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(p)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	results, err := parser.ParseTestReport(f)
+	if err != nil {
+		return nil, fmt.Errorf("解析报告 %s 失败: %v", p, err)
+	}
+	return results, nil
+}
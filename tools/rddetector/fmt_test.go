@@ -0,0 +1,78 @@
+package main
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// testResults 检测结果样例，含 Alpha、AlphaT 临界值附近的P值、Q值
+func testResults() []*R {
+	return []*R{
+		{Name: "a.bin", TestItems: []TestItem{
+			{PValue: 0.5, QValue: 0.25, TestName: "单比特频数检测"},
+			{PValue: 0.0099999999, QValue: 0.3, TestName: `块内最大"1"游程检测 m=10000`},
+		}},
+		{Name: "b&c.bin", TestItems: []TestItem{
+			{PValue: 0.7203433135483956, QValue: 0.0000995, TestName: "单比特频数检测"},
+			{PValue: 1e-300, QValue: 1, TestName: `块内最大"1"游程检测 m=10000`},
+		}},
+	}
+}
+
+func TestParseTestReportRoundTrip(t *testing.T) {
+	for _, c := range []struct {
+		format string
+		parser ReportParser
+	}{
+		{"csv", &CSVFormatter{}},
+		{"json", &JSONFormatter{}},
+		{"xml", &XMLFormatter{}},
+	} {
+		results := testResults()
+		var buf bytes.Buffer
+		if err := getFormatter(c.format, 0.981).FormatTestReport(results, &buf); err != nil {
+			t.Fatalf("%s: %v", c.format, err)
+		}
+		parsed, err := c.parser.ParseTestReport(&buf)
+		if err != nil {
+			t.Fatalf("%s: %v", c.format, err)
+		}
+		if !reflect.DeepEqual(parsed, results) {
+			t.Fatalf("%s: round trip mismatch\n got: %+v\nwant: %+v", c.format, parsed[1], results[1])
+		}
+	}
+}
+
+func TestParseTestReportByteStats(t *testing.T) {
+	results := testResults()
+	for _, r := range results {
+		r.ByteStats = &ByteStats{Entropy: 7.999, ChiSquare: 250.5, ChiSquareP: 0.5, Mean: 127.5, MonteCarloPi: 3.141592654, MonteCarloPiError: 0.01, SerialCorrelation: -0.001}
+	}
+	for _, f := range []ReportParser{&CSVFormatter{}, &JSONFormatter{}, &XMLFormatter{}} {
+		var buf bytes.Buffer
+		if err := f.(ReportFormatter).FormatTestReport(results, &buf); err != nil {
+			t.Fatal(err)
+		}
+		parsed, err := f.ParseTestReport(&buf)
+		if err != nil {
+			t.Fatalf("%T: %v", f, err)
+		}
+		if !reflect.DeepEqual(parsed[1].ByteStats, results[1].ByteStats) || !reflect.DeepEqual(parsed[0].TestItems, results[0].TestItems) {
+			t.Fatalf("%T: byte statistics mismatch: %+v", f, parsed[1].ByteStats)
+		}
+	}
+}
+
+func TestParseCSVReportErrors(t *testing.T) {
+	for _, in := range []string{
+		"文件名,单比特频数检测 P值,扑克检测 Q值\na,0.1,0.1\n",
+		"文件名,单比特频数检测 P值,单比特频数检测 Q值,未知列\na,0.1,0.1,1\n",
+		"文件名,单比特频数检测 P值,单比特频数检测 Q值\na,0.1,x\n",
+	} {
+		if _, err := (&CSVFormatter{}).ParseTestReport(strings.NewReader(in)); err == nil {
+			t.Fatalf("expected error for %q", in)
+		}
+	}
+}
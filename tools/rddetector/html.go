@@ -0,0 +1,263 @@
+package main
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"math"
+	"time"
+
+	"github.com/Trisia/randomness"
+	"github.com/Trisia/randomness/detect"
+)
+
+// HTMLFormatter HTML格式输出，生成不依赖外部资源的单文件报告
+//
+// 检测报告包含各检测项目的通过率汇总、Q值10区间分布直方图与分布均匀性P值、
+// 各样本P值热力图以及未通过样本的链接；分析报告为通过率汇总表。
+type HTMLFormatter struct {
+	Threshold float64 // 通过判定阈值
+}
+
+// htmlTest HTML报告中的检测项目
+type htmlTest struct {
+	AnalysisResult
+	ID         int
+	Uniformity float64     // Q值分布均匀性P值
+	Uniform    bool        // Q值分布均匀性P值 >= AlphaT
+	Bins       []htmlBin   // Q值分布直方图
+	Expected   float64     // 直方图每个区间的期望样本数对应的纵坐标
+	Failed     []*htmlFile // 该项目未通过的样本
+}
+
+// htmlBin Q值分布直方图的一个区间
+type htmlBin struct {
+	Label  string
+	Count  int
+	X, Y   float64
+	Height float64
+}
+
+// htmlFile HTML报告中的样本
+type htmlFile struct {
+	ID     int
+	Name   string
+	Failed int        // 未通过的检测项目数
+	Cells  []htmlCell // 各检测项目的P值
+}
+
+// htmlCell P值热力图的一个单元格
+type htmlCell struct {
+	Color  string
+	Title  string
+	Failed bool
+}
+
+// 直方图尺寸
+const (
+	histWidth  = 320
+	histHeight = 120
+	histBar    = histWidth / 10
+)
+
+func (f *HTMLFormatter) FormatTestReport(results []*R, w io.Writer) error {
+	var files []*htmlFile
+	var failedFiles []*htmlFile
+	for i, result := range results {
+		file := &htmlFile{ID: i, Name: result.Name}
+		for _, item := range result.TestItems {
+			cell := htmlCell{
+				Color:  heatColor(item.PValue),
+				Title:  fmt.Sprintf("%s\nP=%.6f Q=%.6f", item.TestName, item.PValue, item.QValue),
+				Failed: !itemPassed(item),
+			}
+			if cell.Failed {
+				file.Failed++
+			}
+			file.Cells = append(file.Cells, cell)
+		}
+		files = append(files, file)
+		if file.Failed > 0 {
+			failedFiles = append(failedFiles, file)
+		}
+	}
+
+	var tests []*htmlTest
+	for i, a := range analyze(results, f.Threshold) {
+		qValues := make([]float64, 0, len(results))
+		t := &htmlTest{AnalysisResult: a, ID: i}
+		for j, result := range results {
+			if i >= len(result.TestItems) {
+				continue
+			}
+			qValues = append(qValues, result.TestItems[i].QValue)
+			if files[j].Cells[i].Failed {
+				t.Failed = append(t.Failed, files[j])
+			}
+		}
+		t.Uniformity = detect.ThresholdQ(qValues)
+		t.Uniform = t.Uniformity >= randomness.AlphaT
+		t.Bins, t.Expected = qHistogram(qValues)
+		tests = append(tests, t)
+	}
+
+	return htmlTestTemplate.Execute(w, map[string]interface{}{
+		"Time":      time.Now().Format("2006-01-02 15:04:05"),
+		"Version":   Version,
+		"Threshold": f.Threshold,
+		"Total":     len(results),
+		"Tests":     tests,
+		"Files":     files,
+		"Failed":    failedFiles,
+		"Width":     histWidth,
+		"Height":    histHeight,
+		"Bar":       histBar,
+	})
+}
+
+func (f *HTMLFormatter) FormatAnalysisReport(results []AnalysisResult, w io.Writer) error {
+	return htmlAnalysisTemplate.Execute(w, map[string]interface{}{
+		"Time":      time.Now().Format("2006-01-02 15:04:05"),
+		"Version":   Version,
+		"Threshold": f.Threshold,
+		"Results":   results,
+	})
+}
+
+// qHistogram Q值按 [0,0.1)、[0.1,0.2)、…、[0.9,1] 统计的直方图，与 detect.ThresholdQ 的区间一致
+func qHistogram(qValues []float64) ([]htmlBin, float64) {
+	var dist [10]int
+	for _, q := range qValues {
+		k := int(q * 10)
+		if k < 0 {
+			k = 0
+		} else if k > 9 {
+			k = 9
+		}
+		dist[k]++
+	}
+	expected := float64(len(qValues)) / 10
+	max := expected
+	for _, c := range dist {
+		if float64(c) > max {
+			max = float64(c)
+		}
+	}
+	if max == 0 {
+		max = 1
+	}
+	bins := make([]htmlBin, 10)
+	for i, c := range dist {
+		h := float64(c) / max * histHeight
+		bins[i] = htmlBin{
+			Label:  fmt.Sprintf("[%.1f, %.1f)", float64(i)/10, float64(i+1)/10),
+			Count:  c,
+			X:      float64(i * histBar),
+			Y:      histHeight - h,
+			Height: h,
+		}
+	}
+	return bins, histHeight - expected/max*histHeight
+}
+
+// heatColor P值热力图颜色，未通过显著性水平的P值为红色，其余由黄（P接近α）渐变到绿（P接近1）
+func heatColor(p float64) string {
+	if p < randomness.Alpha {
+		return "#e05d5d"
+	}
+	t := math.Min(math.Max(p, 0), 1)
+	r := int(250 - 150*t)
+	g := int(210 - 10*t)
+	b := int(110 + 10*t)
+	return fmt.Sprintf("#%02x%02x%02x", r, g, b)
+}
+
+const htmlStyle = `
+body { font-family: -apple-system, "Segoe UI", "PingFang SC", "Microsoft YaHei", sans-serif; margin: 24px; color: #222; }
+h1 { font-size: 22px; } h2 { font-size: 18px; margin-top: 32px; border-bottom: 1px solid #ddd; padding-bottom: 4px; } h3 { font-size: 15px; }
+table { border-collapse: collapse; font-size: 13px; }
+th, td { border: 1px solid #ccc; padding: 4px 8px; text-align: left; }
+th { background: #f3f3f3; }
+td.num { text-align: right; font-variant-numeric: tabular-nums; }
+.pass { color: #2a7a2a; } .fail { color: #c0392b; font-weight: bold; }
+.meta { color: #666; font-size: 13px; }
+.heat td { padding: 0; width: 14px; height: 14px; border: 1px solid #fff; }
+.heat td.name { width: auto; padding: 0 8px; white-space: nowrap; border: 0; }
+.heat td.bad { outline: 1px solid #900; }
+.heat th { padding: 0 2px; font-size: 10px; font-weight: normal; }
+.test { display: inline-block; vertical-align: top; margin: 0 24px 24px 0; }
+`
+
+var htmlTestTemplate = template.Must(template.New("test").Parse(`<!DOCTYPE html>
+<html lang="zh-CN">
+<head>
+<meta charset="UTF-8">
+<title>随机性检测报告</title>
+<style>` + htmlStyle + `</style>
+</head>
+<body>
+<h1>随机性检测报告</h1>
+<p class="meta">rddetector v{{.Version}} · 生成时间 {{.Time}} · 样本数 {{.Total}} · 通过判定阈值 {{printf "%.3f" .Threshold}}</p>
+
+<h2>检测结果汇总</h2>
+<table>
+<tr><th>#</th><th>检测项目（含参数）</th><th>通过数</th><th>检测数</th><th>通过率</th><th>满足随机性要求</th><th>是否通过</th><th>Q值分布均匀性P值</th></tr>
+{{range .Tests}}<tr>
+<td class="num">{{.ID}}</td><td><a href="#test-{{.ID}}">{{.TestName}}</a></td>
+<td class="num">{{.PassCount}}</td><td class="num">{{.TotalCount}}</td>
+<td class="num">{{printf "%.4f" .PassRate}}</td><td class="num">{{printf "%.3f" .Requirement}}</td>
+<td>{{if .IsPassed}}<span class="pass">是</span>{{else}}<span class="fail">否</span>{{end}}</td>
+<td class="num {{if .Uniform}}pass{{else}}fail{{end}}">{{printf "%.6f" .Uniformity}}</td>
+</tr>
+{{end}}</table>
+
+<h2>未通过样本</h2>
+{{if .Failed}}<ul>
+{{range .Failed}}<li><a href="#file-{{.ID}}">{{.Name}}</a>：{{.Failed}} 项未通过</li>
+{{end}}</ul>{{else}}<p>所有样本的各检测项目均通过。</p>{{end}}
+
+<h2>P值热力图</h2>
+<p class="meta">每行为一个样本，每列为一个检测项目（列号同汇总表）。红色为 P &lt; 0.01，黄色到绿色表示P值由小到大；悬停查看P值与Q值。</p>
+<table class="heat">
+<tr><th></th>{{range .Tests}}<th><a href="#test-{{.ID}}" title="{{.TestName}}">{{.ID}}</a></th>{{end}}</tr>
+{{range .Files}}<tr id="file-{{.ID}}"><td class="name">{{.Name}}</td>{{range .Cells}}<td{{if .Failed}} class="bad"{{end}} style="background: {{.Color}}" title="{{.Title}}"></td>{{end}}</tr>
+{{end}}</table>
+
+<h2>各检测项目Q值分布</h2>
+<p class="meta">Q值按10个等长区间统计，虚线为每个区间的期望样本数；Q值分布均匀性P值 &lt; 0.0001 时分布不均匀。</p>
+{{$w := .Width}}{{$h := .Height}}{{$bar := .Bar}}
+{{range .Tests}}<div class="test" id="test-{{.ID}}">
+<h3>{{.ID}}. {{.TestName}}</h3>
+<p class="meta">通过率 {{printf "%.4f" .PassRate}} · Q值分布均匀性P值 <span class="{{if .Uniform}}pass{{else}}fail{{end}}">{{printf "%.6f" .Uniformity}}</span></p>
+<svg width="{{$w}}" height="{{$h}}" viewBox="0 0 {{$w}} {{$h}}" style="border-bottom: 1px solid #999">
+{{range .Bins}}<rect x="{{.X}}" y="{{.Y}}" width="{{$bar}}" height="{{.Height}}" fill="#6a9fd4" stroke="#fff"><title>{{.Label}}: {{.Count}}</title></rect>
+{{end}}<line x1="0" x2="{{$w}}" y1="{{.Expected}}" y2="{{.Expected}}" stroke="#c0392b" stroke-dasharray="4 3"/>
+</svg>
+{{if .Failed}}<p class="meta">未通过：{{range $i, $f := .Failed}}{{if $i}}、{{end}}<a href="#file-{{$f.ID}}">{{$f.Name}}</a>{{end}}</p>{{end}}
+</div>
+{{end}}
+</body>
+</html>
+`))
+
+var htmlAnalysisTemplate = template.Must(template.New("analysis").Parse(`<!DOCTYPE html>
+<html lang="zh-CN">
+<head>
+<meta charset="UTF-8">
+<title>随机性检测分析报告</title>
+<style>` + htmlStyle + `</style>
+</head>
+<body>
+<h1>随机性检测分析报告</h1>
+<p class="meta">rddetector v{{.Version}} · 生成时间 {{.Time}} · 通过判定阈值 {{printf "%.3f" .Threshold}}</p>
+<table>
+<tr><th>检测项目（含参数）</th><th>通过数</th><th>检测数</th><th>通过率</th><th>满足随机性要求</th><th>是否通过</th></tr>
+{{range .Results}}<tr>
+<td>{{.TestName}}</td><td class="num">{{.PassCount}}</td><td class="num">{{.TotalCount}}</td>
+<td class="num">{{printf "%.4f" .PassRate}}</td><td class="num">{{printf "%.3f" .Requirement}}</td>
+<td>{{if .IsPassed}}<span class="pass">是</span>{{else}}<span class="fail">否</span>{{end}}</td>
+</tr>
+{{end}}</table>
+</body>
+</html>
+`))
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// journalEntry 检测日志中的一行，记录一个已完成检测的样本
+type journalEntry struct {
+	Path   string `json:"path"`   // 样本文件的绝对路径，标准输入为 "-"
+	Offset int64  `json:"offset"` // 样本在文件中的字节偏移
+	Hash   string `json:"hash"`   // 样本数据（解码后）的 SHA-256
+	Result *R     `json:"result"` // 检测结果
+}
+
+// key 样本在检测日志中的标识：路径、偏移与数据摘要
+func (e *journalEntry) key() string {
+	return fmt.Sprintf("%s\x00%d\x00%s", e.Path, e.Offset, e.Hash)
+}
+
+// newJournalEntry 样本的检测日志记录，sample.hash 须已计算
+func newJournalEntry(sample *Sample, r *R) *journalEntry {
+	p := sample.path
+	if p != "-" {
+		if abs, err := filepath.Abs(p); err == nil {
+			p = abs
+		}
+	}
+	return &journalEntry{Path: p, Offset: sample.offset, Hash: sample.hash, Result: r}
+}
+
+// Journal 检测日志，每个样本检测完成后追加一行并落盘，
+// 进程中断后使用 -resume 跳过日志中已完成的样本（按样本文件路径、偏移与数据摘要匹配）
+type Journal struct {
+	mu   sync.Mutex
+	f    *os.File
+	path string
+	done map[string]*R // 日志中已完成的样本，键为 journalEntry.key
+}
+
+// openJournal 打开检测日志，resume 为 true 时读取已有的日志并在其后追加，
+// 否则日志已存在时返回错误，避免覆盖上次中断的检测
+func openJournal(p string, resume bool) (*Journal, error) {
+	j := &Journal{path: p, done: make(map[string]*R)}
+	data, err := ioutil.ReadFile(p)
+	switch {
+	case os.IsNotExist(err):
+		data = nil
+	case err != nil:
+		return nil, err
+	case !resume:
+		return nil, fmt.Errorf("检测日志 %s 已存在，使用 -resume 继续上次的检测，或删除该文件重新检测", p)
+	}
+
+	// 进程中断时最后一行可能不完整，截断到最后一个完整的行
+	good := 0
+	for good < len(data) {
+		n := bytes.IndexByte(data[good:], '\n')
+		if n < 0 {
+			break
+		}
+		var entry journalEntry
+		if err := json.Unmarshal(data[good:good+n], &entry); err != nil || entry.Result == nil {
+			return nil, fmt.Errorf("检测日志 %s 第 %d 字节处的记录无法解析", p, good)
+		}
+		j.done[entry.key()] = entry.Result
+		good += n + 1
+	}
+
+	_ = os.MkdirAll(filepath.Dir(p), os.FileMode(0700))
+	j.f, err = os.OpenFile(p, os.O_WRONLY|os.O_CREATE, os.FileMode(0600))
+	if err != nil {
+		return nil, err
+	}
+	if err = j.f.Truncate(int64(good)); err == nil {
+		_, err = j.f.Seek(int64(good), io.SeekStart)
+	}
+	if err != nil {
+		_ = j.f.Close()
+		return nil, err
+	}
+	return j, nil
+}
+
+// Len 日志中已完成的样本数
+func (j *Journal) Len() int {
+	return len(j.done)
+}
+
+// Lookup 计算样本数据摘要，样本已在日志中时返回其检测结果
+func (j *Journal) Lookup(sample *Sample) (*R, bool, error) {
+	hash, err := sampleHash(sample)
+	if err != nil {
+		return nil, false, err
+	}
+	sample.hash = hash
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	r, ok := j.done[newJournalEntry(sample, nil).key()]
+	return r, ok, nil
+}
+
+// Append 追加样本的检测结果并落盘，r.sample 须已经过 Lookup
+func (j *Journal) Append(r *R) error {
+	if r.sample == nil || r.sample.hash == "" {
+		return fmt.Errorf("样本 %s 未计算数据摘要", r.Name)
+	}
+	line, err := json.Marshal(newJournalEntry(r.sample, r))
+	if err != nil {
+		return err
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if _, err = j.f.Write(append(line, '\n')); err != nil {
+		return err
+	}
+	return j.f.Sync()
+}
+
+// Remove 关闭并删除检测日志，在报告生成后调用
+func (j *Journal) Remove() error {
+	_ = j.f.Close()
+	return os.Remove(j.path)
+}
+
+// sampleHash 样本数据（解码后）的 SHA-256
+func sampleHash(sample *Sample) (string, error) {
+	r, err := sample.Open()
+	if err != nil {
+		return "", err
+	}
+	defer r.Close()
+	h := sha256.New()
+	n, err := io.Copy(h, r)
+	if err != nil {
+		return "", err
+	}
+	if n != sample.Size() {
+		return "", io.ErrUnexpectedEOF
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
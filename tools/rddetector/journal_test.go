@@ -0,0 +1,152 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeSample 写出样本文件，返回该文件对应的样本
+func writeSample(t *testing.T, p string, data []byte) *Sample {
+	_ = os.MkdirAll(filepath.Dir(p), 0700)
+	if err := ioutil.WriteFile(p, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+	return &Sample{Name: filepath.Base(p), path: p, size: int64(len(data))}
+}
+
+func TestJournal(t *testing.T) {
+	dir, err := ioutil.TempDir("", "rddetector")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	jp := filepath.Join(dir, "journal.jsonl")
+
+	// 不同目录中的同名样本
+	a := writeSample(t, filepath.Join(dir, "a", "x.bin"), []byte("sample a"))
+	b := writeSample(t, filepath.Join(dir, "b", "x.bin"), []byte("sample b"))
+
+	j, err := openJournal(jp, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok, err := j.Lookup(a); ok || err != nil {
+		t.Fatalf("empty journal: %v %v", ok, err)
+	}
+	if err := j.Append(&R{Name: b.Name}); err == nil {
+		t.Fatal("append without lookup should fail")
+	}
+	want := &R{Name: a.Name, TestItems: []TestItem{{PValue: 0.123456789, QValue: 0.0000995, TestName: "单比特频数检测"}}, sample: a}
+	if err := j.Append(want); err != nil {
+		t.Fatal(err)
+	}
+	_ = j.f.Close()
+
+	if _, err := openJournal(jp, false); err == nil {
+		t.Fatal("existing journal should not be overwritten without resume")
+	}
+
+	// 进程中断时写了一半的最后一行
+	f, err := os.OpenFile(jp, os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = f.WriteString(`{"path":"` + b.path + `","offset":0,"ha`)
+	_ = f.Close()
+	full, _ := os.Stat(jp)
+
+	j, err = openJournal(jp, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if j.Len() != 1 {
+		t.Fatalf("resumed journal has %d entries", j.Len())
+	}
+	if fi, _ := os.Stat(jp); fi.Size() >= full.Size() {
+		t.Fatalf("truncated line not removed: %d bytes", fi.Size())
+	}
+	got, ok, err := j.Lookup(a)
+	if err != nil || !ok {
+		t.Fatalf("lookup a: %v %v", ok, err)
+	}
+	if got.Name != want.Name || got.TestItems[0] != want.TestItems[0] {
+		t.Fatalf("lookup a: %+v", got)
+	}
+	if _, ok, _ := j.Lookup(b); ok {
+		t.Fatal("sample with the same name in another directory matched")
+	}
+	if err := j.Append(&R{Name: b.Name, sample: b}); err != nil {
+		t.Fatal(err)
+	}
+	_ = j.f.Close()
+
+	// 样本内容变化后不再匹配
+	a = writeSample(t, a.path, []byte("sample A"))
+	j, err = openJournal(jp, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if j.Len() != 2 {
+		t.Fatalf("resumed journal has %d entries", j.Len())
+	}
+	if _, ok, _ := j.Lookup(a); ok {
+		t.Fatal("modified sample matched")
+	}
+	if _, ok, _ := j.Lookup(b); !ok {
+		t.Fatal("sample b not found")
+	}
+	if err := j.Remove(); err != nil {
+		t.Fatal(err)
+	}
+
+	// 中间的行损坏时不继续
+	if err := ioutil.WriteFile(jp, []byte("{}\n{\"path\":\"x\"}\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := openJournal(jp, true); err == nil {
+		t.Fatal("corrupt journal should be rejected")
+	}
+}
+
+func TestJournalSplitSamples(t *testing.T) {
+	dir, err := ioutil.TempDir("", "rddetector")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// 内容相同的样本按偏移区分
+	writeSample(t, filepath.Join(dir, "big.bin"), make([]byte, 4))
+	_, produce, err := splitSamples(filepath.Join(dir, "big.bin"), 16, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var samples []*Sample
+	produce(func(s *Sample) { samples = append(samples, s) })
+
+	jp := filepath.Join(dir, "journal.jsonl")
+	j, err := openJournal(jp, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok, _ := j.Lookup(samples[0]); ok {
+		t.Fatal("empty journal matched")
+	}
+	if err := j.Append(&R{Name: samples[0].Name, sample: samples[0]}); err != nil {
+		t.Fatal(err)
+	}
+	_ = j.f.Close()
+
+	if j, err = openJournal(jp, true); err != nil {
+		t.Fatal(err)
+	}
+	defer j.Remove()
+	if _, ok, _ := j.Lookup(samples[1]); ok {
+		t.Fatal("sample at another offset matched")
+	}
+	if r, ok, _ := j.Lookup(samples[0]); !ok || r.Name != "big.bin@0" {
+		t.Fatalf("lookup: %v %v", r, ok)
+	}
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Trisia/randomness"
+)
+
+// JUnitFormatter JUnit XML 格式输出，供 CI 系统直接展示检测结果
+//
+// 每个检测项目为一个 testcase，通过率未达到通过判定阈值的项目为 failure，
+// failure 中给出通过率与通过判定阈值；检测报告的 failure 还列出该项目未通过的样本。
+type JUnitFormatter struct {
+	Threshold float64 // 通过判定阈值
+}
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name       string          `xml:"name,attr"`
+	Tests      int             `xml:"tests,attr"`
+	Failures   int             `xml:"failures,attr"`
+	Errors     int             `xml:"errors,attr"`
+	Timestamp  string          `xml:"timestamp,attr"`
+	Properties []junitProperty `xml:"properties>property,omitempty"`
+	Cases      []junitTestCase `xml:"testcase"`
+}
+
+type junitProperty struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",cdata"`
+}
+
+func (f *JUnitFormatter) FormatTestReport(results []*R, w io.Writer) error {
+	// 各检测项目未通过的样本
+	failed := make(map[int][]string)
+	for _, result := range results {
+		for i, item := range result.TestItems {
+			if !itemPassed(item) {
+				failed[i] = append(failed[i], fmt.Sprintf("%s P=%.6f Q=%.6f", result.Name, item.PValue, item.QValue))
+			}
+		}
+	}
+	return f.write(analyze(results, f.Threshold), failed, w)
+}
+
+func (f *JUnitFormatter) FormatAnalysisReport(results []AnalysisResult, w io.Writer) error {
+	return f.write(results, nil, w)
+}
+
+// write 写出 JUnit XML，failed 为各检测项目未通过的样本，为 nil 时不列出
+func (f *JUnitFormatter) write(results []AnalysisResult, failed map[int][]string, w io.Writer) error {
+	total := 0
+	if len(results) > 0 {
+		total = results[0].TotalCount
+	}
+	suite := junitTestSuite{
+		Name:      "GM/T 0005-2021 随机性检测",
+		Tests:     len(results),
+		Timestamp: time.Now().Format("2006-01-02T15:04:05"),
+		Properties: []junitProperty{
+			{"version", "rddetector v" + Version},
+			{"input", inputPath},
+			{"samples", strconv.Itoa(total)},
+			{"alpha", strconv.FormatFloat(randomness.Alpha, 'g', -1, 64)},
+			{"alphaT", strconv.FormatFloat(randomness.AlphaT, 'g', -1, 64)},
+			{"threshold", strconv.FormatFloat(f.Threshold, 'g', -1, 64)},
+		},
+	}
+	for i, result := range results {
+		summary := fmt.Sprintf("通过率 %.4f (%d/%d)，通过判定阈值 %.3f", result.PassRate, result.PassCount, result.TotalCount, result.Requirement)
+		tc := junitTestCase{Name: result.TestName, ClassName: "rddetector"}
+		if result.IsPassed {
+			tc.SystemOut = summary
+		} else {
+			suite.Failures++
+			text := summary
+			if files := failed[i]; len(files) > 0 {
+				text += "\n未通过的样本:\n" + strings.Join(files, "\n")
+			}
+			tc.Failure = &junitFailure{
+				Message: fmt.Sprintf("通过率 %.4f 低于通过判定阈值 %.3f", result.PassRate, result.Requirement),
+				Type:    "PassRate",
+				Text:    text,
+			}
+		}
+		suite.Cases = append(suite.Cases, tc)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	err := encoder.Encode(junitTestSuites{
+		Name:     "rddetector",
+		Tests:    suite.Tests,
+		Failures: suite.Failures,
+		Suites:   []junitTestSuite{suite},
+	})
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, "\n")
+	return err
+}
@@ -10,6 +10,8 @@ import (
 	"strings"
 	"sync"
 	"time"
+
+	"github.com/Trisia/randomness"
 )
 
 // TestItem 检测项目结果
@@ -23,6 +25,19 @@ type TestItem struct {
 type R struct {
 	Name      string     `json:"文件名"`
 	TestItems []TestItem `json:"检测项目结果"`
+	ByteStats *ByteStats `json:"字节统计,omitempty"`
+	sample    *Sample    // 检测的样本，供检测日志记录样本标识
+}
+
+// ByteStats 字节统计结果，各项定义与 ent 工具一致
+type ByteStats struct {
+	Entropy           float64 `json:"熵"`
+	ChiSquare         float64 `json:"卡方"`
+	ChiSquareP        float64 `json:"卡方P值"`
+	Mean              float64 `json:"算术平均值"`
+	MonteCarloPi      float64 `json:"蒙特卡洛π"`
+	MonteCarloPiError float64 `json:"π误差百分比"`
+	SerialCorrelation float64 `json:"序列相关系数"`
 }
 
 // AnalysisResult 分析报告结果
@@ -35,9 +50,14 @@ type AnalysisResult struct {
 	IsPassed    bool    `json:"是否通过"`
 }
 
-// 结果集写入文件工作器
-func resultWriter(in <-chan *R, collector *ReportCollector, wg *sync.WaitGroup) {
+// 结果集写入文件工作器，journal 不为 nil 时同时追加到检测日志
+func resultWriter(in <-chan *R, collector *ReportCollector, journal *Journal, wg *sync.WaitGroup) {
 	for r := range in {
+		if journal != nil {
+			if err := journal.Append(r); err != nil {
+				fatalf(ExitInternal, "[%s] 写入检测日志失败: %v\n", r.Name, err)
+			}
+		}
 		collector.AddResult(r)
 		wg.Done()
 	}
@@ -52,18 +72,44 @@ var (
 	NumWorkers    int     // 工作线程数
 	VersionFlag   bool    // 版本号
 	analysisPath  string  // 分析报告路径
-	outputFormat  string  // 输出格式 (csv/json)
+	outputFormat  string  // 输出格式 (csv/json/xml/html/xlsx/junit/sts)
 	passThreshold float64 // 通过判定阈值
+	entStats      bool    // 输出字节统计
+	memBudget     int     // 内存预算（MB）
+	sampleBits    int64   // 单文件切分模式的样本长度（比特）
+	sampleCount   int     // 单文件切分模式的样本数量
+	encName       string  // 输入编码名称
+	lsbFirst      bool    // 原始二进制、十六进制、Base64 解码后的字节低位在前
+	stsRefPath    string  // NIST STS 参考报告路径
+	fromPath      string  // 重新分析的检测报告路径
+	journalPath   string  // 检测日志路径
+	resume        bool    // 从检测日志继续中断的检测
 )
 
+// inputEncoding -enc 指定的输入编码，未指定时为 nil，按扩展名识别
+var inputEncoding *randomness.Encoding
+
+// memLimit 内存预算调度，未设置 -mem 时为 nil
+var memLimit *memLimiter
+
 func init() {
 	flag.BoolVar(&VersionFlag, "v", false, "检测工具版本")
-	flag.StringVar(&inputPath, "i", "", "待检测随机数文件位置")
+	flag.StringVar(&inputPath, "i", "", "待检测随机数文件位置，切分模式下为单个文件，\"-\" 表示标准输入")
 	flag.StringVar(&reportPath, "o", "RandomnessTestReport.csv", "生成的检测报告位置")
 	flag.StringVar(&analysisPath, "a", "", "生成的分析报告位置（可选）")
-	flag.StringVar(&outputFormat, "f", "csv", "输出格式 (csv/json/xml)")
+	flag.StringVar(&outputFormat, "f", "csv", "输出格式 (csv/json/xml/html/xlsx/junit/sts)")
 	flag.Float64Var(&passThreshold, "t", 0.981, "通过判定阈值（默认98.1%）")
+	flag.BoolVar(&entStats, "ent", false, "在检测报告中输出字节统计（熵、卡方、均值、蒙特卡洛π、序列相关系数，与 ent 工具一致）")
 	flag.IntVar(&NumWorkers, "n", runtime.NumCPU(), "工作线程数 (在大数据检测时通过该参数控制并行数量防止内存不足问题)")
+	flag.Int64Var(&sampleBits, "size", 0, "切分模式：将单个文件或标准输入按该长度（比特，20000/1000000/100000000）切分为连续的样本")
+	flag.IntVar(&sampleCount, "count", 0, "切分模式的样本数量，0 表示尽可能多地切分")
+	flag.IntVar(&memBudget, "mem", 0, "内存预算（MB），按预算调度工作线程使进程内存不超过该值，0 表示不限制")
+	flag.StringVar(&encName, "enc", "", "输入编码 (bin/ascii/hex/base64/nist-bin/nist-ascii)，默认按扩展名识别")
+	flag.BoolVar(&lsbFirst, "lsb", false, "原始二进制、十六进制、Base64 数据的每个字节按低位在前读取比特")
+	flag.StringVar(&stsRefPath, "sts-ref", "", "NIST STS 的 finalAnalysisReport.txt，检测完成后与本次结果对照（可选）")
+	flag.StringVar(&journalPath, "journal", "", "检测日志位置，每个样本检测完成后追加写入，报告生成后删除，默认为检测报告位置加 .journal")
+	flag.BoolVar(&resume, "resume", false, "从检测日志继续中断的检测，跳过日志中已完成的样本（按样本文件路径、字节偏移与数据摘要匹配）")
+	flag.StringVar(&fromPath, "from", "", "读取已有的检测报告 (csv/json/xml)，不重新检测，按 -t 重新生成分析报告及 -f 格式的报告")
 	flag.Usage = usage
 
 	log.SetPrefix("[rddetector] ")
@@ -72,71 +118,209 @@ func init() {
 func usage() {
 	_, _ = fmt.Fprintf(os.Stderr, `randomness 随机性检测 rddetector v%s 使用说明
 
-rddetector -i 待检测数据目录 [-o 生成报告位置] [-a 分析报告位置] [-f 输出格式] [-t 通过阈值]
+rddetector -i 待检测数据目录 [-o 生成报告位置] [-a 分析报告位置] [-f 输出格式] [-t 通过阈值] [-ent] [-n 工作线程数] [-mem 内存预算MB] [-enc 输入编码] [-lsb] [-journal 检测日志位置] [-resume]
+rddetector -from 检测报告 [-o 生成报告位置] [-a 分析报告位置] [-f 输出格式] [-t 通过阈值]
 
 	示例: rddetector -i /data/target/ -o RandomnessTestReport.csv -a AnalysisReport.csv -f csv -t 0.981
 	示例: rddetector -i /data/target/ -o RandomnessTestReport.json -a AnalysisReport.json -f json
+	示例: rddetector -i /data/target/ -n 16 -mem 8192
+	示例: rddetector -i capture.bin -size 1000000 -count 1000
+	示例: cat capture.bin | rddetector -i - -size 1000000
+	示例: rddetector -i data.pi -enc nist-ascii -size 1000000
+	示例: rddetector -i /data/target/ -o RandomnessTestReport.csv -resume
+	示例: rddetector -from RandomnessTestReport.json -o report.html -a AnalysisReport.csv -f html -t 0.99
 
 	数据规模将由程序自动推断，支持单文件规模 [20 000 bit, 1 000 000 bit, 100 000 000 bit]
+	目录中包含多种规模的文件时按规模分组检测，各组报告文件名附加规模，如 RandomnessTestReport_1000000bit.csv
+	使用 -size 时不再推断规模，单个文件或标准输入中的连续数据按 -size 切分为样本，报告以 "文件名@字节偏移" 命名样本
+	支持的输入编码及扩展名: bin (.bin/.dat)、ascii (.txt/.asc)、hex (.hex)、base64 (.b64/.base64)
+	检测过程中结果追加写入检测日志，进程中断后以相同参数加 -resume 继续，已完成的样本不再检测
+	退出码: 0 全部通过，1 存在未达到通过判定阈值的检测项目，2 输入错误，3 内部错误
+	比较两份检测报告: rddetector diff 旧报告 新报告，详见 rddetector diff -h
 
 `, Version)
 	flag.PrintDefaults()
 }
 
 func main() {
+	defer exitOnPanic()
+	if len(os.Args) > 1 && os.Args[1] == "diff" {
+		os.Exit(diffMain(os.Args[2:]))
+	}
 	flag.Parse()
 	if VersionFlag {
 		_, _ = fmt.Fprintf(os.Stderr, "rddetector v%s\n", Version)
 		return
 	}
 
-	if inputPath == "" {
+	if inputPath == "" && fromPath == "" {
 		_, _ = fmt.Fprintf(os.Stderr, "	-i 参数缺失\n\n")
 		flag.Usage()
-		return
+		os.Exit(ExitInput)
 	}
 
-	n := NumWorkers
-	out := make(chan *R)
-	jobs := make(chan string)
+	if encName != "" {
+		enc, err := randomness.ParseEncoding(encName)
+		if err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "-enc 参数错误: %v\n\n", err)
+			os.Exit(ExitInput)
+		}
+		inputEncoding = &enc
+	}
 
-	var wg sync.WaitGroup
-	s, sbit := toBeTestFileNum(inputPath)
-	log.Printf("启动 随机性检测，待检测样本总数 s = %d 样本数据规模 bits = %d\n", s, sbit)
-	wg.Add(s)
+	var stsRef *STSReport
+	if stsRefPath != "" {
+		var err error
+		if stsRef, err = loadSTSReport(stsRefPath); err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "读取 STS 参考报告 %s 失败: %v\n\n", stsRefPath, err)
+			os.Exit(ExitInput)
+		}
+	}
 
-	var worker func(jobs <-chan string, out chan<- *R) = nil
-	switch sbit {
-	case 2e4:
-		worker = worker_2E4
-	case 1e6:
-		worker = worker_1E6
-	case 1e8:
-		worker = worker_1E8
-	default:
-		_, _ = fmt.Fprintf(os.Stderr, "无法识别待检测数据规模 %d 程序退出, 支持单文件规模 [20 000, 1 000 000, 100 000 000]\n\n", sbit)
-		return
+	if fromPath != "" {
+		os.Exit(reanalyze(fromPath, stsRef))
+	}
+
+	var groups []*SampleGroup
+	var skipped []Skipped
+	if sampleBits > 0 {
+		s, produce, err := splitSamples(inputPath, sampleBits, sampleCount)
+		if err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "%v\n\n", err)
+			os.Exit(ExitInput)
+		}
+		if scaleWorker(sampleBits) == nil {
+			_, _ = fmt.Fprintf(os.Stderr, "无法识别待检测数据规模 %d 程序退出, 支持单文件规模 [20 000, 1 000 000, 100 000 000]\n\n", sampleBits)
+			os.Exit(ExitInput)
+		}
+		groups = []*SampleGroup{{Bits: sampleBits, Count: s, produce: produce}}
+	} else {
+		var samples []*Sample
+		samples, skipped = dirSamples(inputPath)
+		groups, skipped = groupSamples(samples, skipped)
+	}
+	if len(groups) == 0 {
+		logSkipped(skipped)
+		_, _ = fmt.Fprintf(os.Stderr, "%s 中没有可检测的样本 程序退出, 支持单文件规模 [20 000, 1 000 000, 100 000 000]\n\n", inputPath)
+		os.Exit(ExitInput)
+	}
+
+	// 各工作线程的离散傅里叶检测分摊CPU核心，避免 -n 个样本各自以全部核心并行
+	if NumWorkers < 1 {
+		NumWorkers = 1
+	}
+	randomness.DFTWorkers = runtime.NumCPU() / NumWorkers
+	if randomness.DFTWorkers < 1 {
+		randomness.DFTWorkers = 1
+	}
+
+	if memBudget > 0 {
+		sbit := groups[len(groups)-1].Bits
+		if need := memBase + memScale(sbit); memBudget < need {
+			_, _ = fmt.Fprintf(os.Stderr, "内存预算 -mem %d MB 不足，检测 %d bit 规模的样本至少需要约 %d MB\n\n", memBudget, sbit, need)
+			os.Exit(ExitInput)
+		}
+		memLimit = newMemLimiter(memBudget - memBase)
+		log.Printf("内存预算 %d MB\n", memBudget)
+	}
+
+	if journalPath == "" && reportPath != "" {
+		journalPath = reportPath + ".journal"
 	}
 
 	start := time.Now()
+	passed := true
+	for _, g := range groups {
+		// 多种规模混合时各规模分别生成报告，文件名附加规模
+		report, analysis, journal := reportPath, analysisPath, journalPath
+		if len(groups) > 1 {
+			report, analysis, journal = groupPath(reportPath, g.Bits), groupPath(analysisPath, g.Bits), groupPath(journalPath, g.Bits)
+		}
+		if g.Count < 0 {
+			log.Printf("启动 随机性检测，待检测样本总数 s = 未知（顺序读取） 样本数据规模 bits = %d\n", g.Bits)
+		} else {
+			log.Printf("启动 随机性检测，待检测样本总数 s = %d 样本数据规模 bits = %d\n", g.Count, g.Bits)
+		}
+		collector := runGroup(g, report, analysis, journal)
+		if len(collector.GetResults()) == 0 {
+			fatalf(ExitInput, "%s 中没有读取到可检测的样本\n", inputPath)
+		}
+		if !collector.Passed() {
+			passed = false
+		}
+		if stsRef != nil {
+			compareSTS(collector.GetResults(), stsRef)
+		}
+		if report != "" {
+			log.Printf("检测报告: %s\n", report)
+		}
+		if analysis != "" {
+			log.Printf("分析报告: %s\n", analysis)
+		}
+	}
+	log.Printf("检测完成 耗时 %s\n", time.Since(start))
+	logSkipped(skipped)
+	if !passed {
+		log.Printf("存在通过率未达到通过判定阈值 %.3f 的检测项目\n", passThreshold)
+		os.Exit(ExitFail)
+	}
+}
+
+// runGroup 检测同一规模的一组样本并生成报告，返回该组的数据收集器
+// - journalPath: 检测日志位置，为空时不记录检测日志
+func runGroup(g *SampleGroup, report, analysis, journalPath string) *ReportCollector {
+	worker := scaleWorker(g.Bits)
+	out := make(chan *R)
+	jobs := make(chan *Sample)
+	var wg sync.WaitGroup
 
 	// 创建统一数据收集器
-	collector := NewReportCollector(outputFormat, reportPath, analysisPath, passThreshold)
+	collector := NewReportCollector(outputFormat, report, analysis, passThreshold)
+
+	var journal *Journal
+	if journalPath != "" {
+		var err error
+		if journal, err = openJournal(journalPath, resume); err != nil {
+			fatalf(ExitInput, "打开检测日志失败: %v\n", err)
+		}
+		if journal.Len() > 0 {
+			log.Printf("读取检测日志 %s，已完成 %d 个样本\n", journalPath, journal.Len())
+		}
+	}
 
 	// 启动数据写入消费者
-	go resultWriter(out, collector, &wg)
+	go resultWriter(out, collector, journal, &wg)
 
 	// 检测工作器
-	for i := 0; i < n; i++ {
-		go worker(jobs, out)
+	for i := 0; i < NumWorkers; i++ {
+		go func() {
+			defer exitOnPanic()
+			worker(jobs, out)
+		}()
 	}
-	// 结果工作器
-	go filepath.Walk(inputPath, func(p string, _ os.FileInfo, _ error) error {
-		if strings.HasSuffix(p, ".bin") || strings.HasSuffix(p, ".dat") {
-			jobs <- p
-		}
-		return nil
-	})
+	// 样本分发，样本数量可能事先未知（标准输入），每分发一个样本计数一次
+	wg.Add(1)
+	go func() {
+		defer exitOnPanic()
+		defer wg.Done()
+		g.produce(func(sample *Sample) {
+			if journal != nil {
+				r, ok, err := journal.Lookup(sample)
+				if err != nil {
+					fatalf(ExitInput, "[%s] 读取失败: %v\n", sample.Name, err)
+				}
+				// 检测日志中已完成的样本不再检测
+				if ok {
+					log.Printf("[%s] 已在检测日志中，跳过\n", sample.Name)
+					collector.AddResult(r)
+					return
+				}
+			}
+			wg.Add(1)
+			jobs <- sample
+		})
+		close(jobs)
+	}()
 
 	wg.Wait()
 	close(out)
@@ -144,32 +328,131 @@ func main() {
 	// 生成所有报告
 	err := collector.GenerateReports()
 	if err != nil {
-		log.Fatalf("生成报告失败: %v\n", err)
+		fatalf(ExitInternal, "生成报告失败: %v\n", err)
+	}
+	// 报告已生成，检测日志不再需要
+	if journal != nil {
+		if err = journal.Remove(); err != nil {
+			log.Printf("删除检测日志 %s 失败: %v\n", journalPath, err)
+		}
+	}
+	return collector
+}
+
+// reanalyze 读取已有的检测报告，不重新检测，按当前参数重新生成报告，返回退出码
+func reanalyze(p string, stsRef *STSReport) int {
+	results, err := loadReport(p)
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "%v\n\n", err)
+		return ExitInput
+	}
+	if len(results) == 0 {
+		_, _ = fmt.Fprintf(os.Stderr, "检测报告 %s 中没有检测结果\n\n", p)
+		return ExitInput
+	}
+	// 未指定 -o 时只生成分析报告，避免以默认报告位置覆盖来源报告
+	reportSet := false
+	flag.Visit(func(f *flag.Flag) {
+		reportSet = reportSet || f.Name == "o"
+	})
+	if !reportSet {
+		reportPath = ""
+	}
+	if reportPath != "" {
+		if src, err := os.Stat(p); err == nil {
+			if dst, err := os.Stat(reportPath); err == nil && os.SameFile(src, dst) {
+				_, _ = fmt.Fprintf(os.Stderr, "-o %s 与来源报告相同\n\n", reportPath)
+				return ExitInput
+			}
+		}
+	}
+	// 报告中记录的待检测数据位置（JUnit、Excel、STS 报告）为来源报告
+	if inputPath == "" {
+		inputPath = p
+	}
+	log.Printf("读取检测报告 %s，样本总数 s = %d\n", p, len(results))
+
+	collector := NewReportCollector(outputFormat, reportPath, analysisPath, passThreshold)
+	for _, r := range results {
+		collector.AddResult(r)
+	}
+	if err := collector.GenerateReports(); err != nil {
+		fatalf(ExitInternal, "生成报告失败: %v\n", err)
+	}
+	if stsRef != nil {
+		compareSTS(results, stsRef)
 	}
-	log.Printf("检测完成 耗时 %s\n", time.Since(start))
 	if reportPath != "" {
 		log.Printf("检测报告: %s\n", reportPath)
 	}
 	if analysisPath != "" {
 		log.Printf("分析报告: %s\n", analysisPath)
 	}
+	if !collector.Passed() {
+		log.Printf("存在通过率未达到通过判定阈值 %.3f 的检测项目\n", passThreshold)
+		return ExitFail
+	}
+	return ExitPass
 }
 
-// 获取待检测文件数量和规模
-func toBeTestFileNum(p string) (samples int, bits int64) {
-	// 结果工作器
-	_ = filepath.Walk(p, func(p string, fInfo os.FileInfo, _ error) error {
-		if fInfo == nil || fInfo.IsDir() {
-			return nil
-		}
+// scaleWorker 数据规模对应的检测工作器，不支持的规模返回 nil
+func scaleWorker(sbit int64) func(jobs <-chan *Sample, out chan<- *R) {
+	switch sbit {
+	case 2e4:
+		return worker_2E4
+	case 1e6:
+		return worker_1E6
+	case 1e8:
+		return worker_1E8
+	}
+	return nil
+}
 
-		if strings.HasSuffix(p, ".bin") || strings.HasSuffix(p, ".dat") {
-			samples++
-			if fInfo.Size()*8 > bits {
-				bits = fInfo.Size() * 8
-			}
-		}
+// groupPath 在报告文件名的扩展名前附加规模，如 RandomnessTestReport_1000000bit.csv
+func groupPath(p string, sbit int64) string {
+	if p == "" {
+		return ""
+	}
+	ext := filepath.Ext(p)
+	return fmt.Sprintf("%s_%dbit%s", strings.TrimSuffix(p, ext), sbit, ext)
+}
+
+// logSkipped 输出未检测文件及原因的汇总
+func logSkipped(skipped []Skipped) {
+	if len(skipped) == 0 {
+		return
+	}
+	log.Printf("跳过 %d 个文件:\n", len(skipped))
+	for _, s := range skipped {
+		log.Printf("  %s: %s\n", s.Path, s.Reason)
+	}
+}
+
+// byteStats 计算字节统计，未开启 -ent 时返回 nil
+func byteStats(buf []byte) *ByteStats {
+	if !entStats {
 		return nil
-	})
-	return samples, bits
+	}
+	return toByteStats(randomness.ByteStats(buf))
+}
+
+// toByteStats 转换为报告中的字节统计结果
+func toByteStats(s *randomness.ByteStatistics) *ByteStats {
+	return &ByteStats{
+		Entropy:           s.Entropy,
+		ChiSquare:         s.ChiSquare,
+		ChiSquareP:        s.ChiSquareP,
+		Mean:              s.Mean,
+		MonteCarloPi:      s.MonteCarloPi,
+		MonteCarloPiError: s.MonteCarloPiError,
+		SerialCorrelation: s.SerialCorrelation,
+	}
+}
+
+// bitOrder 解码后字节的比特序
+func bitOrder() randomness.BitOrder {
+	if lsbFirst {
+		return randomness.LSBFirst
+	}
+	return randomness.MSBFirst
 }
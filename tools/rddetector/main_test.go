@@ -0,0 +1,68 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestReanalyze -from 读取检测报告生成的分析报告与检测时生成的分析报告一致
+func TestReanalyze(t *testing.T) {
+	dir, err := ioutil.TempDir("", "rddetector")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// Q值位于 AlphaT 临界值附近的样本决定通过率
+	var results []*R
+	for i := 0; i < 50; i++ {
+		for _, r := range testResults() {
+			r.Name = filepath.Join("data", string(rune('a'+i%26)), r.Name)
+			results = append(results, r)
+		}
+	}
+
+	for _, format := range []string{"csv", "json", "xml"} {
+		report := filepath.Join(dir, "report."+format)
+		fresh := filepath.Join(dir, "fresh-"+format+".csv")
+		c := NewReportCollector(format, report, "", 0.981)
+		for _, r := range results {
+			c.AddResult(r)
+		}
+		if err := c.GenerateReports(); err != nil {
+			t.Fatal(err)
+		}
+		c = NewReportCollector("csv", "", fresh, 0.981)
+		for _, r := range results {
+			c.AddResult(r)
+		}
+		if err := c.GenerateReports(); err != nil {
+			t.Fatal(err)
+		}
+
+		from := filepath.Join(dir, "from-"+format+".csv")
+		inputPath, reportPath, analysisPath, outputFormat, passThreshold = "", "", from, "csv", 0.981
+		want := ExitPass
+		if !c.Passed() {
+			want = ExitFail
+		}
+		if code := reanalyze(report, nil); code != want {
+			t.Errorf("%s: exit code %d, want %d", format, code, want)
+		}
+
+		a, err := ioutil.ReadFile(fresh)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := ioutil.ReadFile(from)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(a, b) {
+			t.Errorf("%s: analysis report differs\nfresh:\n%s\nfrom:\n%s", format, a, b)
+		}
+	}
+}
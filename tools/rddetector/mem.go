@@ -0,0 +1,80 @@
+package main
+
+import (
+	"runtime/debug"
+	"sync"
+)
+
+// 各规模单个样本检测的内存估计（MB），用于按 -mem 预算调度工作器
+const (
+	mem2E4       = 4  // 20 000 bit
+	mem1E6       = 48 // 1 000 000 bit，比特数组、线性复杂度并行缓冲与傅里叶变换
+	mem1E8Stream = 32 // 100 000 000 bit 流式检测阶段，读缓冲与各累计器
+	memBase      = 32 // 运行时与报告收集器
+)
+
+// memDFT 补零模式离散傅里叶检测的内存估计（MB）：样本本身与 ceilPow2(n)*8 字节的变换缓冲区
+func memDFT(n int64) int {
+	N := int64(1)
+	for N < n {
+		N <<= 1
+	}
+	return int((n/8+N*8)>>20) + 64
+}
+
+// memScale 数据规模对应的单个样本检测所需的最大内存（MB）
+func memScale(sbit int64) int {
+	switch sbit {
+	case 2e4:
+		return mem2E4
+	case 1e6:
+		return mem1E6
+	default:
+		if m := memDFT(sbit); m > mem1E8Stream {
+			return m
+		}
+		return mem1E8Stream
+	}
+}
+
+// memLimiter 按内存预算（MB）调度检测，各工作器检测前申请所需内存，超出预算时等待其他工作器释放。
+// 为 nil 时不限制。
+type memLimiter struct {
+	mu    sync.Mutex
+	cond  *sync.Cond
+	total int
+	used  int
+}
+
+func newMemLimiter(total int) *memLimiter {
+	l := &memLimiter{total: total}
+	l.cond = sync.NewCond(&l.mu)
+	return l
+}
+
+// acquire 申请 mb 内存，预算不足时阻塞
+func (l *memLimiter) acquire(mb int) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	for l.used+mb > l.total {
+		l.cond.Wait()
+	}
+	l.used += mb
+	l.mu.Unlock()
+}
+
+// release 释放 mb 内存，释放较大内存前将空闲堆内存归还操作系统，使进程常驻内存及时回落
+func (l *memLimiter) release(mb int) {
+	if l == nil {
+		return
+	}
+	if mb >= mem1E8Stream {
+		debug.FreeOSMemory()
+	}
+	l.mu.Lock()
+	l.used -= mb
+	l.cond.Broadcast()
+	l.mu.Unlock()
+}
@@ -0,0 +1,261 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/Trisia/randomness"
+)
+
+// extEncodings 由文件扩展名识别的输入编码
+var extEncodings = map[string]randomness.Encoding{
+	".bin":    randomness.EncodingBinary,
+	".dat":    randomness.EncodingBinary,
+	".txt":    randomness.EncodingASCII,
+	".asc":    randomness.EncodingASCII,
+	".hex":    randomness.EncodingHex,
+	".b64":    randomness.EncodingBase64,
+	".base64": randomness.EncodingBase64,
+}
+
+// fileEncoding 文件的输入编码，指定 -enc 时使用指定的编码，否则由扩展名识别；扩展名未知时返回 false
+func fileEncoding(p string) (randomness.Encoding, bool) {
+	enc, ok := extEncodings[strings.ToLower(filepath.Ext(p))]
+	if inputEncoding != nil {
+		enc = *inputEncoding
+	}
+	return enc, ok
+}
+
+// Sample 待检测样本，可以是目录中的一个文件，也可以是大文件或标准输入中的一段连续数据
+type Sample struct {
+	Name   string // 报告中的样本名称，目录中的样本为相对路径，切分的样本为 "文件名@字节偏移"
+	path   string // 文件路径，标准输入为 "-"
+	offset int64  // 样本在文件中的字节偏移，文本编码为解码后数据中的偏移
+	size   int64  // 样本字节数（解码后）
+	enc    randomness.Encoding
+	data   []byte // 来自标准输入或文本编码大文件的样本数据（已解码），不为 nil 时不读取文件
+	hash   string // 样本数据（解码后）的 SHA-256，记录检测日志时计算
+}
+
+// Size 样本字节数
+func (s *Sample) Size() int64 {
+	return s.size
+}
+
+// Open 打开样本数据流，读出的是解码后的数据
+func (s *Sample) Open() (io.ReadCloser, error) {
+	if s.data != nil {
+		return ioutil.NopCloser(bytes.NewReader(s.data)), nil
+	}
+	f, err := os.Open(s.path)
+	if err != nil {
+		return nil, err
+	}
+	var r io.Reader = f
+	if s.enc == randomness.EncodingBinary {
+		r = io.NewSectionReader(f, s.offset, s.size)
+	}
+	d, err := randomness.NewDecoder(r, s.enc, bitOrder())
+	if err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+	return struct {
+		io.Reader
+		io.Closer
+	}{d, f}, nil
+}
+
+// ReadAll 读取样本全部数据
+func (s *Sample) ReadAll() ([]byte, error) {
+	if s.data != nil {
+		return s.data, nil
+	}
+	r, err := s.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	buf := make([]byte, s.size)
+	_, err = io.ReadFull(r, buf)
+	return buf, err
+}
+
+// Skipped 未检测的文件及原因
+type Skipped struct {
+	Path   string
+	Reason string
+}
+
+// SampleGroup 同一数据规模的一组样本，使用该规模的检测工作器检测并生成一份报告
+type SampleGroup struct {
+	Bits    int64 // 样本长度（比特）
+	Count   int   // 样本数量，事先未知（顺序读取）时为 -1
+	produce func(emit func(*Sample))
+}
+
+// groupSamples 按样本长度分组，组按规模由小到大排列，长度不属于支持规模的样本被跳过
+func groupSamples(samples []*Sample, skipped []Skipped) ([]*SampleGroup, []Skipped) {
+	bySize := make(map[int64][]*Sample)
+	for _, s := range samples {
+		sbit := s.Size() * 8
+		if scaleWorker(sbit) == nil {
+			skipped = append(skipped, Skipped{Path: s.path, Reason: fmt.Sprintf("长度 %d bit 不属于支持的规模", sbit)})
+			continue
+		}
+		bySize[sbit] = append(bySize[sbit], s)
+	}
+	groups := make([]*SampleGroup, 0, len(bySize))
+	for sbit, list := range bySize {
+		list := list
+		groups = append(groups, &SampleGroup{Bits: sbit, Count: len(list), produce: func(emit func(*Sample)) {
+			for _, s := range list {
+				emit(s)
+			}
+		}})
+	}
+	sort.Slice(groups, func(i, j int) bool { return groups[i].Bits < groups[j].Bits })
+	return groups, skipped
+}
+
+// dirSamples 目录中可识别编码的文件各为一个样本，无法解码的文件被跳过
+// 样本以相对于 root 的路径（以 / 分隔）命名，不同子目录中的同名文件在报告中可以区分。
+func dirSamples(root string) ([]*Sample, []Skipped) {
+	var samples []*Sample
+	var skipped []Skipped
+	_ = filepath.Walk(root, func(p string, fInfo os.FileInfo, _ error) error {
+		if fInfo == nil || fInfo.IsDir() {
+			return nil
+		}
+		enc, ok := fileEncoding(p)
+		if !ok {
+			return nil
+		}
+		name, err := filepath.Rel(root, p)
+		if err != nil {
+			name = p
+		}
+		s := &Sample{Name: filepath.ToSlash(name), path: p, size: fInfo.Size(), enc: enc}
+		if enc != randomness.EncodingBinary {
+			// 文本编码的样本长度需要解码后才能确定
+			n, err := decodedSize(s)
+			if err != nil {
+				skipped = append(skipped, Skipped{Path: p, Reason: fmt.Sprintf("解码失败: %v", err)})
+				return nil
+			}
+			s.size = n
+		}
+		samples = append(samples, s)
+		return nil
+	})
+	return samples, skipped
+}
+
+// decodedSize 解码样本文件得到的字节数，结尾不足一个字节的比特不计入
+func decodedSize(s *Sample) (int64, error) {
+	r, err := s.Open()
+	if err != nil {
+		return 0, err
+	}
+	defer r.Close()
+	n, err := io.Copy(ioutil.Discard, r)
+	if err == randomness.ErrPartialByte {
+		err = nil
+	}
+	return n, err
+}
+
+// splitSamples 将单个文件或标准输入（"-"）按 sbit 比特切分为连续的样本
+// count 为样本数量，0 表示尽可能多地切分；返回样本数量，标准输入或文本编码的文件未指定数量时为 -1。
+func splitSamples(input string, sbit int64, count int) (int, func(emit func(*Sample)), error) {
+	if sbit <= 0 || sbit%8 != 0 {
+		return 0, nil, fmt.Errorf("样本长度 %d bit 须为8的倍数", sbit)
+	}
+	size := sbit / 8
+
+	unknown := count
+	if unknown == 0 {
+		unknown = -1
+	}
+	if input == "-" {
+		enc := randomness.EncodingBinary
+		if inputEncoding != nil {
+			enc = *inputEncoding
+		}
+		r, err := randomness.NewDecoder(os.Stdin, enc, bitOrder())
+		if err != nil {
+			return 0, nil, err
+		}
+		return unknown, func(emit func(*Sample)) { readerSamples("stdin", input, r, size, count, emit) }, nil
+	}
+
+	fInfo, err := os.Stat(input)
+	if err != nil {
+		return 0, nil, err
+	}
+	if !fInfo.Mode().IsRegular() {
+		return 0, nil, fmt.Errorf("%s 不是文件", input)
+	}
+	name := path.Base(input)
+	// 指定的单个文件扩展名未知时按原始二进制读取
+	enc, _ := fileEncoding(input)
+	if enc != randomness.EncodingBinary {
+		// 文本编码无法按偏移定位，顺序解码后依次读取样本
+		f, err := os.Open(input)
+		if err != nil {
+			return 0, nil, err
+		}
+		r, err := randomness.NewDecoder(f, enc, bitOrder())
+		if err != nil {
+			return 0, nil, err
+		}
+		return unknown, func(emit func(*Sample)) {
+			readerSamples(name, input, r, size, count, emit)
+			_ = f.Close()
+		}, nil
+	}
+	fit := int(fInfo.Size() / size)
+	if fit == 0 {
+		return 0, nil, fmt.Errorf("文件 %s 不足一个 %d bit 的样本", input, sbit)
+	}
+	if count > fit {
+		return 0, nil, fmt.Errorf("文件 %s 只能切分出 %d 个 %d bit 的样本", input, fit, sbit)
+	}
+	if count == 0 {
+		count = fit
+	}
+	return count, func(emit func(*Sample)) {
+		for i := 0; i < count; i++ {
+			offset := int64(i) * size
+			emit(&Sample{Name: fmt.Sprintf("%s@%d", name, offset), path: input, offset: offset, size: size, enc: enc})
+		}
+	}, nil
+}
+
+// readerSamples 从数据流中依次读取样本，不足一个样本的结尾数据被忽略
+// name 为报告中的样本名称前缀，p 为数据来源的文件路径，标准输入为 "-"
+func readerSamples(name, p string, r io.Reader, size int64, count int, emit func(*Sample)) {
+	for i := 0; count == 0 || i < count; i++ {
+		buf := make([]byte, size)
+		n, err := io.ReadFull(r, buf)
+		if err != nil {
+			if err == io.ErrUnexpectedEOF || err == randomness.ErrPartialByte {
+				log.Printf("%s 结尾 %d 字节不足一个样本，已忽略\n", name, n)
+			} else if err != io.EOF {
+				fatalf(ExitInput, "读取 %s 失败: %v\n", name, err)
+			}
+			return
+		}
+		offset := int64(i) * size
+		emit(&Sample{Name: fmt.Sprintf("%s@%d", name, offset), path: p, offset: offset, size: size, data: buf})
+	}
+}
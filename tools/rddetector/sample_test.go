@@ -0,0 +1,167 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// collectSamples 读出 produce 产生的全部样本
+func collectSamples(produce func(emit func(*Sample))) []*Sample {
+	var samples []*Sample
+	produce(func(s *Sample) { samples = append(samples, s) })
+	return samples
+}
+
+func TestSplitSamples(t *testing.T) {
+	dir, err := ioutil.TempDir("", "rddetector")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	data := []byte("0123456789")
+	p := filepath.Join(dir, "big.bin")
+	writeSample(t, p, data)
+
+	count, produce, err := splitSamples(p, 24, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	samples := collectSamples(produce)
+	if count != 3 || len(samples) != 3 {
+		t.Fatalf("count %d, %d samples", count, len(samples))
+	}
+	for i, s := range samples {
+		if want := []string{"big.bin@0", "big.bin@3", "big.bin@6"}[i]; s.Name != want || s.Size() != 3 {
+			t.Errorf("sample %d: %s, %d bytes", i, s.Name, s.Size())
+		}
+		if buf, err := s.ReadAll(); err != nil || !bytes.Equal(buf, data[3*i:3*i+3]) {
+			t.Errorf("sample %d: %q %v", i, buf, err)
+		}
+	}
+
+	if count, produce, err = splitSamples(p, 24, 2); err != nil || count != 2 || len(collectSamples(produce)) != 2 {
+		t.Errorf("count 2: %d %v", count, err)
+	}
+	for _, c := range []struct {
+		sbit  int64
+		count int
+	}{
+		{12, 0}, // 不是8的倍数
+		{24, 4}, // 超出文件能切分的数量
+		{88, 0}, // 不足一个样本
+	} {
+		if _, _, err := splitSamples(p, c.sbit, c.count); err == nil {
+			t.Errorf("%d bit x %d: expected error", c.sbit, c.count)
+		}
+	}
+	if _, _, err := splitSamples(dir, 24, 0); err == nil {
+		t.Error("directory: expected error")
+	}
+}
+
+func TestSplitSamplesEncoded(t *testing.T) {
+	dir, err := ioutil.TempDir("", "rddetector")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// 文本编码顺序解码，结尾不足一个样本的数据被忽略
+	p := filepath.Join(dir, "big.hex")
+	writeSample(t, p, []byte("0011 2233\n44"))
+	count, produce, err := splitSamples(p, 16, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	samples := collectSamples(produce)
+	if count != -1 || len(samples) != 2 {
+		t.Fatalf("count %d, %d samples", count, len(samples))
+	}
+	for i, s := range samples {
+		buf, err := s.ReadAll()
+		if want := [][]byte{{0x00, 0x11}, {0x22, 0x33}}[i]; err != nil || !bytes.Equal(buf, want) || s.offset != int64(2*i) || s.path != p {
+			t.Errorf("sample %d: %s %x %v", i, s.Name, buf, err)
+		}
+	}
+}
+
+func TestDirSamples(t *testing.T) {
+	dir, err := ioutil.TempDir("", "rddetector")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	writeSample(t, filepath.Join(dir, "a.bin"), []byte{1, 2, 3})
+	writeSample(t, filepath.Join(dir, "sub", "b.txt"), []byte("01000001\n0100"))
+	writeSample(t, filepath.Join(dir, "c.hex"), []byte("zz"))
+	writeSample(t, filepath.Join(dir, "d.log"), []byte("ignored"))
+	writeSample(t, filepath.Join(dir, "sub", "a.bin"), []byte{4})
+
+	samples, skipped := dirSamples(dir)
+	if len(samples) != 3 || len(skipped) != 1 || skipped[0].Path != filepath.Join(dir, "c.hex") {
+		t.Fatalf("%d samples, skipped %+v", len(samples), skipped)
+	}
+	// 样本以相对路径命名，文本编码的样本长度为解码后的字节数，结尾不足一个字节的比特不计入
+	for i, want := range []struct {
+		name string
+		data []byte
+	}{
+		{"a.bin", []byte{1, 2, 3}},
+		{"sub/a.bin", []byte{4}},
+		{"sub/b.txt", []byte{'A'}},
+	} {
+		s := samples[i]
+		buf, err := s.ReadAll()
+		if err != nil || s.Name != want.name || !bytes.Equal(buf, want.data) || s.Size() != int64(len(want.data)) {
+			t.Errorf("%s: %x %v", s.Name, buf, err)
+		}
+	}
+}
+
+func TestGroupSamples(t *testing.T) {
+	samples := []*Sample{
+		{Name: "m1", path: "m1", size: 125000},
+		{Name: "s1", path: "s1", size: 2500},
+		{Name: "x", path: "x", size: 100},
+		{Name: "l1", path: "l1", size: 12500000},
+		{Name: "s2", path: "s2", size: 2500},
+	}
+	groups, skipped := groupSamples(samples, []Skipped{{Path: "y", Reason: "解码失败"}})
+	if len(skipped) != 2 || skipped[1].Path != "x" {
+		t.Fatalf("skipped %+v", skipped)
+	}
+	// 组按规模由小到大排列，组内保持样本顺序
+	want := []struct {
+		bits  int64
+		names []string
+	}{
+		{2e4, []string{"s1", "s2"}},
+		{1e6, []string{"m1"}},
+		{1e8, []string{"l1"}},
+	}
+	if len(groups) != len(want) {
+		t.Fatalf("%d groups", len(groups))
+	}
+	for i, g := range groups {
+		var names []string
+		for _, s := range collectSamples(g.produce) {
+			names = append(names, s.Name)
+		}
+		if g.Bits != want[i].bits || g.Count != len(want[i].names) || !reflect.DeepEqual(names, want[i].names) {
+			t.Errorf("group %d: %d bit x %d %v", i, g.Bits, g.Count, names)
+		}
+	}
+
+	if p := groupPath("out/RandomnessTestReport.csv", 1e6); p != "out/RandomnessTestReport_1000000bit.csv" {
+		t.Errorf("groupPath: %s", p)
+	}
+	if p := groupPath("", 1e6); p != "" {
+		t.Errorf("groupPath: %s", p)
+	}
+}
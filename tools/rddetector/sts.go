@@ -0,0 +1,319 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/Trisia/randomness"
+	"github.com/Trisia/randomness/detect"
+)
+
+// STSFormatter NIST STS finalAnalysisReport.txt 格式输出
+//
+// 与 STS 的定义一致：C1–C10 为各样本P值在10个等长区间的分布，P-VALUE 为该分布的均匀性P值，
+// PROPORTION 为 P >= α 的样本比例，超出 STS 置信区间的比例与小于 0.0001 的均匀性P值以 * 标出。
+// 分析报告没有各样本的P值，C1–C10 与 P-VALUE 输出为 "-"，比例为分析报告中的通过数。
+type STSFormatter struct{}
+
+// STSRow finalAnalysisReport.txt 中的一个检测项目
+type STSRow struct {
+	Bins       []int   // C1–C10，没有P值分布时为 nil
+	Uniformity float64 // P值分布均匀性P值，无法计算时为 NaN
+	PassCount  int
+	SampleSize int
+	TestName   string
+}
+
+// STSReport finalAnalysisReport.txt 的内容
+type STSReport struct {
+	Generator string // 数据来源
+	Rows      []STSRow
+}
+
+const stsLine = "------------------------------------------------------------------------------"
+const stsDashes = "- - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - -"
+
+func (f *STSFormatter) FormatTestReport(results []*R, w io.Writer) error {
+	return writeSTSReport(w, &STSReport{Generator: inputPath, Rows: stsRows(results)})
+}
+
+// stsRows 按 STS 的定义统计各检测项目的P值分布与通过比例
+func stsRows(results []*R) []STSRow {
+	var rows []STSRow
+	if len(results) > 0 {
+		for i, item := range results[0].TestItems {
+			row := STSRow{Bins: make([]int, 10), TestName: item.TestName}
+			var pValues []float64
+			for _, result := range results {
+				if i >= len(result.TestItems) {
+					continue
+				}
+				p := result.TestItems[i].PValue
+				pValues = append(pValues, p)
+				row.Bins[stsBin(p)]++
+				if p >= randomness.Alpha {
+					row.PassCount++
+				}
+			}
+			row.SampleSize = len(pValues)
+			row.Uniformity = detect.ThresholdQ(pValues)
+			rows = append(rows, row)
+		}
+	}
+	return rows
+}
+
+func (f *STSFormatter) FormatAnalysisReport(results []AnalysisResult, w io.Writer) error {
+	rows := make([]STSRow, 0, len(results))
+	for _, result := range results {
+		rows = append(rows, STSRow{
+			Uniformity: math.NaN(),
+			PassCount:  result.PassCount,
+			SampleSize: result.TotalCount,
+			TestName:   result.TestName,
+		})
+	}
+	return writeSTSReport(w, &STSReport{Generator: inputPath, Rows: rows})
+}
+
+// stsBin P值所在区间，与 STS 一致为 floor(P*10)，P=1 计入 C10
+func stsBin(p float64) int {
+	k := int(p * 10)
+	if k < 0 {
+		k = 0
+	} else if k > 9 {
+		k = 9
+	}
+	return k
+}
+
+// stsProportionRange STS 判定通过比例的置信区间 p̂ ± 3·sqrt(p̂(1-p̂)/n)，以样本数计
+func stsProportionRange(n int) (min, max float64) {
+	pHat := 1 - randomness.Alpha
+	d := 3 * math.Sqrt(pHat*randomness.Alpha/float64(n))
+	return (pHat - d) * float64(n), (pHat + d) * float64(n)
+}
+
+// writeSTSReport 按 STS assess 程序的格式写出 finalAnalysisReport.txt
+func writeSTSReport(w io.Writer, report *STSReport) error {
+	bw := bufio.NewWriter(w)
+	_, _ = fmt.Fprintf(bw, "%s\nRESULTS FOR THE UNIFORMITY OF P-VALUES AND THE PROPORTION OF PASSING SEQUENCES\n%s\n", stsLine, stsLine)
+	_, _ = fmt.Fprintf(bw, "   generator is <%s>\n%s\n", report.Generator, stsLine)
+	_, _ = fmt.Fprintf(bw, " C1  C2  C3  C4  C5  C6  C7  C8  C9 C10  P-VALUE  PROPORTION  STATISTICAL TEST\n%s\n", stsLine)
+
+	sampleSize := 0
+	for _, row := range report.Rows {
+		for j := 0; j < 10; j++ {
+			if row.Bins == nil {
+				_, _ = fmt.Fprintf(bw, "%3s ", "-")
+			} else {
+				_, _ = fmt.Fprintf(bw, "%3d ", row.Bins[j])
+			}
+		}
+		switch {
+		case math.IsNaN(row.Uniformity) || row.SampleSize < 10:
+			_, _ = fmt.Fprint(bw, "    ----    ")
+		case row.Uniformity < randomness.AlphaT:
+			_, _ = fmt.Fprintf(bw, " %8.6f * ", row.Uniformity)
+		default:
+			_, _ = fmt.Fprintf(bw, " %8.6f   ", row.Uniformity)
+		}
+		if row.SampleSize == 0 {
+			_, _ = fmt.Fprintf(bw, " ------     %s\n", row.TestName)
+			continue
+		}
+		if min, max := stsProportionRange(row.SampleSize); float64(row.PassCount) < min || float64(row.PassCount) > max {
+			_, _ = fmt.Fprintf(bw, "%4d/%-4d *  %s\n", row.PassCount, row.SampleSize, row.TestName)
+		} else {
+			_, _ = fmt.Fprintf(bw, "%4d/%-4d    %s\n", row.PassCount, row.SampleSize, row.TestName)
+		}
+		if row.SampleSize > sampleSize {
+			sampleSize = row.SampleSize
+		}
+	}
+
+	minPass := 0
+	if sampleSize > 0 {
+		min, _ := stsProportionRange(sampleSize)
+		minPass = int(min)
+	}
+	_, _ = fmt.Fprintf(bw, "\n\n%s\n", stsDashes)
+	_, _ = fmt.Fprintf(bw, "The minimum pass rate for each statistical test with the exception of the\n")
+	_, _ = fmt.Fprintf(bw, "random excursion (variant) test is approximately = %d for a\n", minPass)
+	_, _ = fmt.Fprintf(bw, "sample size = %d binary sequences.\n\n", sampleSize)
+	_, _ = fmt.Fprintf(bw, "The minimum pass rate for the random excursion (variant) test\nis undefined.\n\n")
+	_, _ = fmt.Fprintf(bw, "For further guidelines construct a probability table using the MAPLE program\n")
+	_, _ = fmt.Fprintf(bw, "provided in the addendum section of the documentation.\n%s\n", stsDashes)
+	return bw.Flush()
+}
+
+// ParseSTSReport 解析 NIST STS（或本工具 sts 格式）的 finalAnalysisReport.txt
+func ParseSTSReport(r io.Reader) (*STSReport, error) {
+	report := &STSReport{}
+	scanner := bufio.NewScanner(r)
+	inTable := false
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := scanner.Text()
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(trimmed, "generator is"):
+			report.Generator = strings.Trim(strings.TrimSpace(strings.TrimPrefix(trimmed, "generator is")), "<>")
+		case strings.HasPrefix(trimmed, "C1 "):
+			inTable = true
+		case strings.HasPrefix(trimmed, "- - -"):
+			inTable = false
+		case !inTable || trimmed == "" || strings.HasPrefix(trimmed, "---"):
+		default:
+			row, err := parseSTSRow(trimmed)
+			if err != nil {
+				return nil, fmt.Errorf("第 %d 行: %v", lineNo, err)
+			}
+			report.Rows = append(report.Rows, row)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(report.Rows) == 0 {
+		return nil, errors.New("没有找到检测结果，不是 finalAnalysisReport.txt 格式")
+	}
+	return report, nil
+}
+
+// parseSTSRow 解析一行检测结果：C1–C10、均匀性P值（或 ----）、[*]、通过数/样本数（或 ------）、[*]、检测名称
+func parseSTSRow(line string) (STSRow, error) {
+	row := STSRow{Uniformity: math.NaN()}
+	fields := strings.Fields(line)
+	if len(fields) < 13 {
+		return row, fmt.Errorf("字段数 %d 过少", len(fields))
+	}
+	if fields[0] != "-" {
+		row.Bins = make([]int, 10)
+		for j := range row.Bins {
+			c, err := strconv.Atoi(fields[j])
+			if err != nil {
+				return row, fmt.Errorf("C%d: %v", j+1, err)
+			}
+			row.Bins[j] = c
+		}
+	}
+	i := 10
+	if fields[i] != "----" {
+		p, err := strconv.ParseFloat(fields[i], 64)
+		if err != nil {
+			return row, fmt.Errorf("P-VALUE: %v", err)
+		}
+		row.Uniformity = p
+	}
+	i++
+	if fields[i] == "*" {
+		i++
+	}
+	if i < len(fields) && fields[i] != "------" {
+		parts := strings.SplitN(fields[i], "/", 2)
+		if len(parts) != 2 {
+			return row, fmt.Errorf("PROPORTION: %q", fields[i])
+		}
+		var err error
+		if row.PassCount, err = strconv.Atoi(parts[0]); err != nil {
+			return row, fmt.Errorf("PROPORTION: %v", err)
+		}
+		if row.SampleSize, err = strconv.Atoi(parts[1]); err != nil {
+			return row, fmt.Errorf("PROPORTION: %v", err)
+		}
+	}
+	i++
+	if i < len(fields) && fields[i] == "*" {
+		i++
+	}
+	if i >= len(fields) {
+		return row, errors.New("缺少检测名称")
+	}
+	row.TestName = strings.Join(fields[i:], " ")
+	return row, nil
+}
+
+// stsNames GM/T 0005-2021 检测项目对应的 STS 检测名称，按检测名称前缀匹配；
+// 重叠子序列检测的 P1、P2 对应 STS Serial 的两行，累加和检测的前向、后向对应 CumulativeSums 的两行。
+var stsNames = []struct {
+	prefix string
+	name   string
+}{
+	{"单比特频数检测", "Frequency"},
+	{"块内频数检测", "BlockFrequency"},
+	{"重叠子序列检测", "Serial"},
+	{"游程总数检测", "Runs"},
+	{"块内最大\"1\"游程检测", "LongestRun"},
+	{"矩阵秩检测", "Rank"},
+	{"累加和检测", "CumulativeSums"},
+	{"近似熵检测", "ApproximateEntropy"},
+	{"线性复杂度检测", "LinearComplexity"},
+	{"Maurer通用统计检测", "Universal"},
+	{"离散傅里叶检测", "FFT"},
+}
+
+// stsName 检测项目对应的 STS 检测名称，STS 报告中的名称原样返回，没有对应检测时返回空串
+func stsName(testName string) string {
+	for _, n := range stsNames {
+		if strings.HasPrefix(testName, n.prefix) {
+			return n.name
+		}
+	}
+	for _, n := range stsNames {
+		if testName == n.name {
+			return n.name
+		}
+	}
+	return ""
+}
+
+// loadSTSReport 读取 STS 参考报告
+func loadSTSReport(p string) (*STSReport, error) {
+	f, err := os.Open(p)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ParseSTSReport(f)
+}
+
+// compareSTS 将本次检测结果与 STS 参考报告按 STS 检测名称对照输出，同名的多行按出现顺序对应，
+// 参考报告也可以是本工具 sts 格式的报告。
+//
+// GM/T 0005-2021 与 STS 同名检测的参数（如块长）可能不同，对照前请确认 STS 运行时使用了相同的参数。
+func compareSTS(results []*R, ref *STSReport) {
+	refRows := make(map[string][]STSRow)
+	for _, row := range ref.Rows {
+		if name := stsName(row.TestName); name != "" {
+			refRows[name] = append(refRows[name], row)
+		}
+	}
+	used := make(map[string]int)
+	log.Printf("与 STS 参考报告 <%s> 对照:\n", ref.Generator)
+	log.Printf("  %-32s %-20s %14s %10s %14s %10s\n", "检测项目", "STS检测", "本工具比例", "均匀性P值", "STS比例", "均匀性P值")
+	for _, row := range stsRows(results) {
+		name := stsName(row.TestName)
+		if name == "" {
+			continue
+		}
+		k := used[name]
+		used[name]++
+		if k >= len(refRows[name]) {
+			log.Printf("  %-32s %-20s %14s %10.6f %14s\n", row.TestName, name, stsProportion(row), row.Uniformity, "无")
+			continue
+		}
+		r := refRows[name][k]
+		log.Printf("  %-32s %-20s %14s %10.6f %14s %10.6f\n", row.TestName, name, stsProportion(row), row.Uniformity, stsProportion(r), r.Uniformity)
+	}
+}
+
+func stsProportion(row STSRow) string {
+	return fmt.Sprintf("%d/%d", row.PassCount, row.SampleSize)
+}
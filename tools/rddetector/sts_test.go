@@ -0,0 +1,108 @@
+package main
+
+import (
+	"bytes"
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestParseSTSReport(t *testing.T) {
+	report, err := loadSTSReport("testdata/finalAnalysisReport.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Generator != "data/data.e" || len(report.Rows) != 17 {
+		t.Fatalf("generator %q, %d rows", report.Generator, len(report.Rows))
+	}
+
+	row := report.Rows[0]
+	if !reflect.DeepEqual(row.Bins, []int{12, 9, 11, 8, 10, 9, 11, 10, 10, 10}) || row.Uniformity != 0.991468 ||
+		row.PassCount != 100 || row.SampleSize != 100 || row.TestName != "Frequency" {
+		t.Errorf("Frequency: %+v", row)
+	}
+	// 均匀性P值与比例均超出范围，以 * 标出
+	row = report.Rows[10]
+	if row.Bins[0] != 100 || row.Uniformity != 0 || row.PassCount != 0 || row.SampleSize != 100 || row.TestName != "Universal" {
+		t.Errorf("Universal: %+v", row)
+	}
+	// 没有满足条件的样本
+	row = report.Rows[12]
+	if !math.IsNaN(row.Uniformity) || row.SampleSize != 0 || row.TestName != "RandomExcursions" {
+		t.Errorf("RandomExcursions: %+v", row)
+	}
+	row = report.Rows[13]
+	if !math.IsNaN(row.Uniformity) || row.PassCount != 1 || row.SampleSize != 1 || row.TestName != "RandomExcursionsVariant" {
+		t.Errorf("RandomExcursionsVariant: %+v", row)
+	}
+	if report.Rows[14].TestName != "Serial" || report.Rows[15].TestName != "Serial" || report.Rows[15].PassCount != 99 {
+		t.Errorf("Serial: %+v %+v", report.Rows[14], report.Rows[15])
+	}
+
+	// 写出的报告读回后不变
+	var buf bytes.Buffer
+	if err := writeSTSReport(&buf, report); err != nil {
+		t.Fatal(err)
+	}
+	again, err := ParseSTSReport(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again.Generator != report.Generator || len(again.Rows) != len(report.Rows) {
+		t.Fatalf("round trip: %q, %d rows", again.Generator, len(again.Rows))
+	}
+	for i, r := range again.Rows {
+		o := report.Rows[i]
+		if !reflect.DeepEqual(r.Bins, o.Bins) || r.PassCount != o.PassCount || r.SampleSize != o.SampleSize || r.TestName != o.TestName ||
+			!(r.Uniformity == o.Uniformity || math.IsNaN(r.Uniformity) && math.IsNaN(o.Uniformity)) {
+			t.Errorf("round trip row %d: %+v, want %+v", i, r, o)
+		}
+	}
+}
+
+func TestParseSTSRow(t *testing.T) {
+	// 分析报告写出的行没有P值分布
+	row, err := parseSTSRow("-   -   -   -   -   -   -   -   -   -      ----     981/1000     单比特频数检测")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if row.Bins != nil || !math.IsNaN(row.Uniformity) || row.PassCount != 981 || row.SampleSize != 1000 || row.TestName != "单比特频数检测" {
+		t.Errorf("%+v", row)
+	}
+	// 检测名称中含空格
+	row, err = parseSTSRow(`1 2 3 4 5 6 7 8 9 10 0.5 50/55 块内最大"1"游程检测 m=10000`)
+	if err != nil || row.TestName != `块内最大"1"游程检测 m=10000` || row.Bins[9] != 10 {
+		t.Errorf("%+v %v", row, err)
+	}
+
+	for _, line := range []string{
+		"1 2 3 4 5 6 7 8 9 10 0.5 50/55",
+		"1 2 3 4 5 6 7 8 9 10 0.5",
+		"1 2 3 4 5 6 7 8 x 10 0.5 50/55 Frequency",
+		"1 2 3 4 5 6 7 8 9 10 p 50/55 Frequency",
+		"1 2 3 4 5 6 7 8 9 10 0.5 50-55 Frequency",
+		"1 2 3 4 5 6 7 8 9 10 0.5 50/x Frequency",
+	} {
+		if _, err := parseSTSRow(line); err == nil {
+			t.Errorf("expected error for %q", line)
+		}
+	}
+	if _, err := ParseSTSReport(bytes.NewReader([]byte("not a report\n"))); err == nil {
+		t.Error("expected error for empty report")
+	}
+}
+
+func TestSTSName(t *testing.T) {
+	for name, want := range map[string]string{
+		"单比特频数检测":          "Frequency",
+		"重叠子序列检测 m=5 P1":   "Serial",
+		"累加和检测 后向":         "CumulativeSums",
+		"离散傅里叶检测":          "FFT",
+		"LinearComplexity": "LinearComplexity",
+		"扑克检测 m=4":         "",
+	} {
+		if got := stsName(name); got != want {
+			t.Errorf("stsName(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
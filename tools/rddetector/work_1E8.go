@@ -1,137 +1,198 @@
 package main
 
 import (
-	"io/ioutil"
+	"io"
 	"log"
-	"path"
 
 	"github.com/Trisia/randomness"
 )
 
 // 数据规模为 100 000 000 个比特的随机数列检测工作器
-func worker_1E8(jobs <-chan string, out chan<- *R) {
-	for filename := range jobs {
-		buf, _ := ioutil.ReadFile(filename)
-		bits := randomness.B2bitArr(buf)
-
+//
+// 除离散傅里叶检测外的各项检测均以流式累计器实现，对文件进行单次流式读取，
+// 内存消耗与样本长度无关；离散傅里叶检测需要完整样本，单独读取并按内存预算（-mem）调度。
+func worker_1E8(jobs <-chan *Sample, out chan<- *R) {
+	for sample := range jobs {
+		filename := sample.Name
 		testItems := make([]TestItem, 0, 64)
 
 		log.Printf("[%s] 检测开始...\n", filename)
 
+		memLimit.acquire(mem1E8Stream)
+
+		mono := randomness.NewMonoBitFrequencyWriter()
+		block := randomness.NewFrequencyWithinBlockWriter(100000)
+		poker4 := randomness.NewPokerWriter(4)
+		poker8 := randomness.NewPokerWriter(8)
+		overlapping3 := randomness.NewOverlappingTemplateMatchingWriter(3)
+		overlapping5 := randomness.NewOverlappingTemplateMatchingWriter(5)
+		overlapping7 := randomness.NewOverlappingTemplateMatchingWriter(7)
+		runs := randomness.NewRunsWriter()
+		runsDistribution := randomness.NewRunsDistributionWriter()
+		longestRun1 := randomness.NewLongestRunOfOnesInABlockWriter(true)
+		longestRun0 := randomness.NewLongestRunOfOnesInABlockWriter(false)
+		derivative3 := randomness.NewBinaryDerivativeWriter(3)
+		derivative7 := randomness.NewBinaryDerivativeWriter(7)
+		derivative15 := randomness.NewBinaryDerivativeWriter(15)
+		autocorrelation1 := randomness.NewAutocorrelationWriter(1)
+		autocorrelation2 := randomness.NewAutocorrelationWriter(2)
+		autocorrelation8 := randomness.NewAutocorrelationWriter(8)
+		autocorrelation16 := randomness.NewAutocorrelationWriter(16)
+		matrixRank := randomness.NewMatrixRankWriter()
+		cumulative := randomness.NewCumulativeWriter()
+		entropy2 := randomness.NewApproximateEntropyWriter(2)
+		entropy5 := randomness.NewApproximateEntropyWriter(5)
+		linearComplexity := randomness.NewLinearComplexityWriter(500)
+		maurer := randomness.NewMaurerUniversalWriter()
+
+		writers := []io.Writer{
+			mono, block, poker4, poker8, overlapping3, overlapping5, overlapping7,
+			runs, runsDistribution, longestRun1, longestRun0,
+			derivative3, derivative7, derivative15,
+			autocorrelation1, autocorrelation2, autocorrelation8, autocorrelation16,
+			matrixRank, cumulative, entropy2, entropy5, linearComplexity, maurer,
+		}
+		// 字节统计（-ent）
+		var stats *randomness.ByteStatsWriter
+		if entStats {
+			stats = randomness.NewByteStatsWriter()
+			writers = append(writers, stats)
+		}
+
+		f, err := sample.Open()
+		if err != nil {
+			fatalf(ExitInput, "[%s] 读取失败: %v\n", filename, err)
+		}
+		_, err = io.CopyBuffer(io.MultiWriter(writers...), f, make([]byte, 1<<20))
+		_ = f.Close()
+		if err != nil {
+			fatalf(ExitInput, "[%s] 读取失败: %v\n", filename, err)
+		}
+
 		// [1] 单比特频数检测
-		p, q := randomness.MonoBitFrequencyTestBytes(buf)
+		p, q := mono.Result()
 		testItems = append(testItems, TestItem{PValue: p, QValue: q, TestName: "单比特频数检测"})
 		log.Printf("[%s] 单比特频数检测 P: %.5f Q: %.5f", filename, p, q)
 
 		// [2] 块内频数检测
-		p, q = randomness.FrequencyWithinBlockProto(bits, 100000)
+		p, q = block.Result()
 		testItems = append(testItems, TestItem{PValue: p, QValue: q, TestName: "块内频数检测 m=100000"})
 		log.Printf("[%s] 块内频数检测 m=100000 P: %.5f Q: %.5f", filename, p, q)
 
 		// [3] 扑克检测
-		p, q = randomness.PokerTestBytes(buf, 4)
+		p, q = poker4.Result()
 		testItems = append(testItems, TestItem{PValue: p, QValue: q, TestName: "扑克检测 m=4"})
 		log.Printf("[%s] 扑克检测 m=4 P: %.5f Q: %.5f", filename, p, q)
-		p, q = randomness.PokerTestBytes(buf, 8)
+		p, q = poker8.Result()
 		testItems = append(testItems, TestItem{PValue: p, QValue: q, TestName: "扑克检测 m=8"})
 		log.Printf("[%s] 扑克检测 m=8 P: %.5f Q: %.5f", filename, p, q)
 
-		// 下文中不再需要比特数组，释放以节约内存。
-		buf = nil
-
 		// [4] 重叠子序列检测
-		p1, p2, q1, q2 := randomness.OverlappingTemplateMatchingProto(bits, 3)
+		p1, p2, q1, q2 := overlapping3.Result()
 		testItems = append(testItems, TestItem{PValue: p1, QValue: q1, TestName: "重叠子序列检测 m=3 P1"})
 		testItems = append(testItems, TestItem{PValue: p2, QValue: q2, TestName: "重叠子序列检测 m=3 P2"})
 		log.Printf("[%s] 重叠子序列检测 m=3 P1: %.5f P2: %.5f Q1: %.5f Q2: %.5f", filename, p1, p2, q1, q2)
-		p1, p2, q1, q2 = randomness.OverlappingTemplateMatchingProto(bits, 5)
+		p1, p2, q1, q2 = overlapping5.Result()
 		testItems = append(testItems, TestItem{PValue: p1, QValue: q1, TestName: "重叠子序列检测 m=5 P1"})
 		testItems = append(testItems, TestItem{PValue: p2, QValue: q2, TestName: "重叠子序列检测 m=5 P2"})
 		log.Printf("[%s] 重叠子序列检测 m=5 P1: %.5f P2: %.5f Q1: %.5f Q2: %.5f", filename, p1, p2, q1, q2)
-		p1, p2, q1, q2 = randomness.OverlappingTemplateMatchingProto(bits, 7)
+		p1, p2, q1, q2 = overlapping7.Result()
 		testItems = append(testItems, TestItem{PValue: p1, QValue: q1, TestName: "重叠子序列检测 m=7 P1"})
 		testItems = append(testItems, TestItem{PValue: p2, QValue: q2, TestName: "重叠子序列检测 m=7 P2"})
 		log.Printf("[%s] 重叠子序列检测 m=7 P1: %.5f P2: %.5f Q1: %.5f Q2: %.5f", filename, p1, p2, q1, q2)
 
 		// [5] 游程总数检测
-		p, q = randomness.RunsTest(bits)
+		p, q = runs.Result()
 		testItems = append(testItems, TestItem{PValue: p, QValue: q, TestName: "游程总数检测"})
 		log.Printf("[%s] 游程总数检测 P: %.5f Q: %.5f", filename, p, q)
 
 		// [6] 游程分布检测
-		p, q = randomness.RunsDistributionTest(bits)
+		p, q = runsDistribution.Result()
 		testItems = append(testItems, TestItem{PValue: p, QValue: q, TestName: "游程分布检测"})
 		log.Printf("[%s] 游程分布检测 P: %.5f Q: %.5f", filename, p, q)
 
 		// [7] 块内最大游程检测
-		p, q = randomness.LongestRunOfOnesInABlockTest(bits, true)
+		p, q = longestRun1.Result()
 		testItems = append(testItems, TestItem{PValue: p, QValue: q, TestName: "块内最大\"1\"游程检测 m=10000"})
 		log.Printf("[%s] 块内最大\"1\"游程检测 P: %.5f Q: %.5f", filename, p, q)
-		p, q = randomness.LongestRunOfOnesInABlockTest(bits, false)
+		p, q = longestRun0.Result()
 		testItems = append(testItems, TestItem{PValue: p, QValue: q, TestName: "块内最大\"0\"游程检测 m=10000"})
 		log.Printf("[%s] 块内最大\"0\"游程检测 P: %.5f Q: %.5f", filename, p, q)
 
 		// [8] 二元推导检测
-		p, q = randomness.BinaryDerivativeProto(bits, 3)
+		p, q = derivative3.Result()
 		testItems = append(testItems, TestItem{PValue: p, QValue: q, TestName: "二元推导检测 k=3"})
 		log.Printf("[%s] 二元推导检测 k=3 P: %.5f Q: %.5f", filename, p, q)
-		p, q = randomness.BinaryDerivativeProto(bits, 7)
+		p, q = derivative7.Result()
 		testItems = append(testItems, TestItem{PValue: p, QValue: q, TestName: "二元推导检测 k=7"})
 		log.Printf("[%s] 二元推导检测 k=7 P: %.5f Q: %.5f", filename, p, q)
-		p, q = randomness.BinaryDerivativeProto(bits, 15)
+		p, q = derivative15.Result()
 		testItems = append(testItems, TestItem{PValue: p, QValue: q, TestName: "二元推导检测 k=15"})
 		log.Printf("[%s] 二元推导检测 k=15 P: %.5f Q: %.5f", filename, p, q)
 
 		// [9] 自相关检测
-		p, q = randomness.AutocorrelationProto(bits, 1)
+		p, q = autocorrelation1.Result()
 		testItems = append(testItems, TestItem{PValue: p, QValue: q, TestName: "自相关检测 d=1"})
 		log.Printf("[%s] 自相关检测 d=1 P: %.5f Q: %.5f", filename, p, q)
-		p, q = randomness.AutocorrelationProto(bits, 2)
+		p, q = autocorrelation2.Result()
 		testItems = append(testItems, TestItem{PValue: p, QValue: q, TestName: "自相关检测 d=2"})
 		log.Printf("[%s] 自相关检测 d=2 P: %.5f Q: %.5f", filename, p, q)
-		p, q = randomness.AutocorrelationProto(bits, 8)
+		p, q = autocorrelation8.Result()
 		testItems = append(testItems, TestItem{PValue: p, QValue: q, TestName: "自相关检测 d=8"})
 		log.Printf("[%s] 自相关检测 d=8 P: %.5f Q: %.5f", filename, p, q)
-		p, q = randomness.AutocorrelationProto(bits, 16)
+		p, q = autocorrelation16.Result()
 		testItems = append(testItems, TestItem{PValue: p, QValue: q, TestName: "自相关检测 d=16"})
 		log.Printf("[%s] 自相关检测 d=16 P: %.5f Q: %.5f", filename, p, q)
 
 		// [10] 矩阵秩检测
-		p, q = randomness.MatrixRankTest(bits)
+		p, q = matrixRank.Result()
 		testItems = append(testItems, TestItem{PValue: p, QValue: q, TestName: "矩阵秩检测"})
 		log.Printf("[%s] 矩阵秩检测 P: %.5f Q: %.5f", filename, p, q)
 
 		// [11] 累加和检测
-		p, q = randomness.CumulativeTest(bits, true)
+		p, q = cumulative.Result(true)
 		testItems = append(testItems, TestItem{PValue: p, QValue: q, TestName: "累加和检测 前向"})
 		log.Printf("[%s] 累加和检测 前向 P: %.5f Q: %.5f", filename, p, q)
-		p, q = randomness.CumulativeTest(bits, false)
+		p, q = cumulative.Result(false)
 		testItems = append(testItems, TestItem{PValue: p, QValue: q, TestName: "累加和检测 后向"})
 		log.Printf("[%s] 累加和检测 后向 P: %.5f Q: %.5f", filename, p, q)
 
 		// [12] 近似熵检测
-		p, q = randomness.ApproximateEntropyProto(bits, 2)
+		p, q = entropy2.Result()
 		testItems = append(testItems, TestItem{PValue: p, QValue: q, TestName: "近似熵检测 m=2"})
 		log.Printf("[%s] 近似熵检测 m=2 P: %.5f Q: %.5f", filename, p, q)
-		p, q = randomness.ApproximateEntropyProto(bits, 5)
+		p, q = entropy5.Result()
 		testItems = append(testItems, TestItem{PValue: p, QValue: q, TestName: "近似熵检测 m=5"})
 		log.Printf("[%s] 近似熵检测 m=5 P: %.5f Q: %.5f", filename, p, q)
 
 		// [13] 线性复杂度检测
-		p, q = randomness.LinearComplexityTest(bits)
+		p, q = linearComplexity.Result()
 		testItems = append(testItems, TestItem{PValue: p, QValue: q, TestName: "线性复杂度检测 m=500"})
 		log.Printf("[%s] 线性复杂度检测 m=500 P: %.5f Q: %.5f", filename, p, q)
 
 		// [14] Maurer通用统计检测
-		p, q = randomness.MaurerUniversalTest(bits)
+		p, q = maurer.Result()
 		testItems = append(testItems, TestItem{PValue: p, QValue: q, TestName: "Maurer通用统计检测 L=7 Q=1280"})
 		log.Printf("[%s] Maurer通用统计检测 P: %.5f Q: %.5f", filename, p, q)
 
-		// [15] 离散傅里叶检测
-		p, q = randomness.DiscreteFourierTransformTest(bits)
+		memLimit.release(mem1E8Stream)
+
+		// [15] 离散傅里叶检测，需要完整样本
+		dftMem := memDFT(sample.Size() * 8)
+		memLimit.acquire(dftMem)
+		buf, err := sample.ReadAll()
+		if err != nil {
+			fatalf(ExitInput, "[%s] 读取失败: %v\n", filename, err)
+		}
+		p, q = randomness.DiscreteFourierTransformTestBytes(buf)
+		memLimit.release(dftMem)
 		testItems = append(testItems, TestItem{PValue: p, QValue: q, TestName: "离散傅里叶检测"})
 		log.Printf("[%s] 离散傅里叶检测 P: %.5f Q: %.5f", filename, p, q)
 
-		out <- &R{Name: path.Base(filename), TestItems: testItems}
+		var byteStatistics *ByteStats
+		if stats != nil {
+			byteStatistics = toByteStats(stats.Statistics())
+		}
+		out <- &R{Name: sample.Name, TestItems: testItems, ByteStats: byteStatistics, sample: sample}
 	}
 }
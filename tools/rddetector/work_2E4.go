@@ -1,17 +1,20 @@
 package main
 
 import (
-	"io/ioutil"
 	"log"
-	"path"
 
 	"github.com/Trisia/randomness"
 )
 
 // 数据规模为 20 000 个比特的随机数列检测工作器
-func worker_2E4(jobs <-chan string, out chan<- *R) {
-	for filename := range jobs {
-		buf, _ := ioutil.ReadFile(filename)
+func worker_2E4(jobs <-chan *Sample, out chan<- *R) {
+	for sample := range jobs {
+		filename := sample.Name
+		memLimit.acquire(mem2E4)
+		buf, err := sample.ReadAll()
+		if err != nil {
+			fatalf(ExitInput, "[%s] 读取失败: %v\n", filename, err)
+		}
 		bits := randomness.B2bitArr(buf)
 
 		testItems := make([]TestItem, 0, 64)
@@ -36,6 +39,9 @@ func worker_2E4(jobs <-chan string, out chan<- *R) {
 		testItems = append(testItems, TestItem{PValue: p, QValue: q, TestName: "扑克检测 m=8"})
 		log.Printf("[%s] 扑克检测 m=8 P: %.5f Q: %.5f", filename, p, q)
 
+		// 字节统计（-ent）
+		stats := byteStats(buf)
+
 		// 下文中不再需要比特数组，释放以节约内存。
 		buf = nil
 
@@ -107,6 +113,7 @@ func worker_2E4(jobs <-chan string, out chan<- *R) {
 		testItems = append(testItems, TestItem{PValue: p, QValue: q, TestName: "离散傅里叶检测"})
 		log.Printf("[%s] 离散傅里叶检测 P: %.5f Q: %.5f", filename, p, q)
 
-		out <- &R{Name: path.Base(filename), TestItems: testItems}
+		memLimit.release(mem2E4)
+		out <- &R{Name: sample.Name, TestItems: testItems, ByteStats: stats, sample: sample}
 	}
 }
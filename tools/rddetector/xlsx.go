@@ -0,0 +1,358 @@
+package main
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math"
+	"runtime"
+	"strconv"
+	"time"
+
+	"github.com/Trisia/randomness"
+)
+
+// XLSXFormatter Excel（.xlsx）格式输出，使用标准库直接写出 OOXML 工作簿
+//
+// 检测报告工作簿包含 "检测结果"（各样本的P值、Q值）、"分析报告"、"运行信息" 三个工作表，
+// 分析报告工作簿包含后两个工作表。数值单元格以数字类型写入，未通过的单元格以红色标出。
+type XLSXFormatter struct {
+	Threshold float64 // 通过判定阈值
+}
+
+// 单元格样式，对应 xlsxStyles 中 cellXfs 的顺序
+const (
+	xlsxStyleDefault = iota
+	xlsxStyleHeader
+	xlsxStyleNumber
+	xlsxStyleNumberFail
+	xlsxStyleTextFail
+)
+
+// xlsxSheet 待写出的工作表
+type xlsxSheet struct {
+	Name     string
+	Rows     [][]xlsxCell
+	ColWidth []float64 // 各列宽度（字符数），未指定的列使用默认宽度
+}
+
+func (f *XLSXFormatter) FormatTestReport(results []*R, w io.Writer) error {
+	sheet := xlsxSheet{Name: "检测结果", ColWidth: []float64{28}}
+	header := []xlsxCell{xlsxText("文件名", xlsxStyleHeader)}
+	if len(results) > 0 {
+		for _, item := range results[0].TestItems {
+			header = append(header, xlsxText(item.TestName+" P值", xlsxStyleHeader), xlsxText(item.TestName+" Q值", xlsxStyleHeader))
+		}
+		if results[0].ByteStats != nil {
+			for _, h := range byteStatsHeaders {
+				header = append(header, xlsxText(h, xlsxStyleHeader))
+			}
+		}
+	}
+	sheet.Rows = append(sheet.Rows, header)
+	for _, result := range results {
+		row := []xlsxCell{xlsxText(result.Name, xlsxStyleDefault)}
+		for _, item := range result.TestItems {
+			style := xlsxStyleNumber
+			if !itemPassed(item) {
+				style = xlsxStyleNumberFail
+			}
+			row = append(row, xlsxNumber(item.PValue, style), xlsxNumber(item.QValue, style))
+		}
+		if st := result.ByteStats; st != nil {
+			for _, v := range []float64{st.Entropy, st.ChiSquare, st.ChiSquareP, st.Mean, st.MonteCarloPi, st.MonteCarloPiError, st.SerialCorrelation} {
+				row = append(row, xlsxNumber(v, xlsxStyleNumber))
+			}
+		}
+		sheet.Rows = append(sheet.Rows, row)
+	}
+
+	analysis := analyze(results, f.Threshold)
+	return writeXLSX(w, []xlsxSheet{sheet, f.analysisSheet(analysis), f.metadataSheet(len(results))})
+}
+
+func (f *XLSXFormatter) FormatAnalysisReport(results []AnalysisResult, w io.Writer) error {
+	total := 0
+	if len(results) > 0 {
+		total = results[0].TotalCount
+	}
+	return writeXLSX(w, []xlsxSheet{f.analysisSheet(results), f.metadataSheet(total)})
+}
+
+// analysisSheet 分析报告工作表
+func (f *XLSXFormatter) analysisSheet(results []AnalysisResult) xlsxSheet {
+	sheet := xlsxSheet{Name: "分析报告", ColWidth: []float64{36, 10, 10, 10, 16, 10}}
+	header := []xlsxCell{}
+	for _, h := range []string{"检测项目（含参数）", "通过数", "检测数", "通过率", "满足随机性要求", "是否通过"} {
+		header = append(header, xlsxText(h, xlsxStyleHeader))
+	}
+	sheet.Rows = append(sheet.Rows, header)
+	for _, result := range results {
+		isPassed := xlsxText("是", xlsxStyleDefault)
+		rateStyle := xlsxStyleNumber
+		if !result.IsPassed {
+			isPassed = xlsxText("否", xlsxStyleTextFail)
+			rateStyle = xlsxStyleNumberFail
+		}
+		sheet.Rows = append(sheet.Rows, []xlsxCell{
+			xlsxText(result.TestName, xlsxStyleDefault),
+			xlsxNumber(float64(result.PassCount), xlsxStyleDefault),
+			xlsxNumber(float64(result.TotalCount), xlsxStyleDefault),
+			xlsxNumber(result.PassRate, rateStyle),
+			xlsxNumber(result.Requirement, xlsxStyleNumber),
+			isPassed,
+		})
+	}
+	return sheet
+}
+
+// metadataSheet 运行信息工作表
+func (f *XLSXFormatter) metadataSheet(samples int) xlsxSheet {
+	enc := encName
+	if enc == "" {
+		enc = "按扩展名识别"
+	}
+	order := "高位在前"
+	if lsbFirst {
+		order = "低位在前"
+	}
+	meta := []xlsxCell{
+		xlsxText("工具版本", xlsxStyleDefault), xlsxText("rddetector v"+Version, xlsxStyleDefault),
+		xlsxText("生成时间", xlsxStyleDefault), xlsxText(time.Now().Format("2006-01-02 15:04:05"), xlsxStyleDefault),
+		xlsxText("待检测数据", xlsxStyleDefault), xlsxText(inputPath, xlsxStyleDefault),
+		xlsxText("输入编码", xlsxStyleDefault), xlsxText(enc, xlsxStyleDefault),
+		xlsxText("字节内比特序", xlsxStyleDefault), xlsxText(order, xlsxStyleDefault),
+		xlsxText("样本数", xlsxStyleDefault), xlsxNumber(float64(samples), xlsxStyleDefault),
+		xlsxText("显著性水平 α", xlsxStyleDefault), xlsxNumber(randomness.Alpha, xlsxStyleDefault),
+		xlsxText("分布均匀性显著性水平 αT", xlsxStyleDefault), xlsxNumber(randomness.AlphaT, xlsxStyleDefault),
+		xlsxText("通过判定阈值", xlsxStyleDefault), xlsxNumber(f.Threshold, xlsxStyleDefault),
+		xlsxText("工作线程数", xlsxStyleDefault), xlsxNumber(float64(NumWorkers), xlsxStyleDefault),
+		xlsxText("运行环境", xlsxStyleDefault), xlsxText(runtime.GOOS+"/"+runtime.GOARCH+" "+runtime.Version(), xlsxStyleDefault),
+	}
+	if sampleBits > 0 {
+		meta = append(meta,
+			xlsxText("切分样本长度（比特）", xlsxStyleDefault), xlsxNumber(float64(sampleBits), xlsxStyleDefault))
+	}
+	sheet := xlsxSheet{Name: "运行信息", ColWidth: []float64{24, 48}}
+	sheet.Rows = append(sheet.Rows, []xlsxCell{xlsxText("项目", xlsxStyleHeader), xlsxText("值", xlsxStyleHeader)})
+	for i := 0; i < len(meta); i += 2 {
+		sheet.Rows = append(sheet.Rows, meta[i:i+2])
+	}
+	return sheet
+}
+
+// xlsxCell 工作表单元格，文本以内联字符串写入，无需共享字符串表
+type xlsxCell struct {
+	Ref    string      `xml:"r,attr"`
+	Style  int         `xml:"s,attr,omitempty"`
+	Type   string      `xml:"t,attr,omitempty"`
+	Value  string      `xml:"v,omitempty"`
+	Inline *xlsxInline `xml:"is,omitempty"`
+}
+
+type xlsxInline struct {
+	Text string `xml:"t"`
+}
+
+func xlsxText(s string, style int) xlsxCell {
+	return xlsxCell{Style: style, Type: "inlineStr", Inline: &xlsxInline{Text: s}}
+}
+
+// xlsxNumber 数值单元格，NaN 与 Inf 无法以数字类型表示，以文本写入
+func xlsxNumber(v float64, style int) xlsxCell {
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return xlsxText(strconv.FormatFloat(v, 'g', -1, 64), style)
+	}
+	return xlsxCell{Style: style, Value: strconv.FormatFloat(v, 'g', -1, 64)}
+}
+
+// xlsxColName 列号（从0开始）对应的列名，如 0 为 A，26 为 AA
+func xlsxColName(i int) string {
+	name := ""
+	for i++; i > 0; i = (i - 1) / 26 {
+		name = string(rune('A'+(i-1)%26)) + name
+	}
+	return name
+}
+
+const xlsxNS = "http://schemas.openxmlformats.org/spreadsheetml/2006/main"
+const xlsxRelNS = "http://schemas.openxmlformats.org/officeDocument/2006/relationships"
+
+type xlsxWorksheet struct {
+	XMLName    xml.Name        `xml:"worksheet"`
+	NS         string          `xml:"xmlns,attr"`
+	SheetViews *xlsxSheetViews `xml:"sheetViews,omitempty"`
+	Cols       []xlsxCol       `xml:"cols>col,omitempty"`
+	Rows       []xlsxRow       `xml:"sheetData>row"`
+}
+
+// xlsxSheetViews 冻结首行表头
+type xlsxSheetViews struct {
+	View struct {
+		WorkbookViewID int `xml:"workbookViewId,attr"`
+		Pane           struct {
+			YSplit      int    `xml:"ySplit,attr"`
+			TopLeftCell string `xml:"topLeftCell,attr"`
+			ActivePane  string `xml:"activePane,attr"`
+			State       string `xml:"state,attr"`
+		} `xml:"pane"`
+	} `xml:"sheetView"`
+}
+
+type xlsxCol struct {
+	Min         int     `xml:"min,attr"`
+	Max         int     `xml:"max,attr"`
+	Width       float64 `xml:"width,attr"`
+	CustomWidth int     `xml:"customWidth,attr"`
+}
+
+type xlsxRow struct {
+	R     int        `xml:"r,attr"`
+	Cells []xlsxCell `xml:"c"`
+}
+
+type xlsxWorkbook struct {
+	XMLName xml.Name       `xml:"workbook"`
+	NS      string         `xml:"xmlns,attr"`
+	RelNS   string         `xml:"xmlns:r,attr"`
+	Sheets  []xlsxSheetRef `xml:"sheets>sheet"`
+}
+
+type xlsxSheetRef struct {
+	Name    string `xml:"name,attr"`
+	SheetID int    `xml:"sheetId,attr"`
+	RID     string `xml:"r:id,attr"`
+}
+
+type xlsxRelationships struct {
+	XMLName xml.Name  `xml:"http://schemas.openxmlformats.org/package/2006/relationships Relationships"`
+	Rels    []xlsxRel `xml:"Relationship"`
+}
+
+type xlsxRel struct {
+	ID     string `xml:"Id,attr"`
+	Type   string `xml:"Type,attr"`
+	Target string `xml:"Target,attr"`
+}
+
+type xlsxContentTypes struct {
+	XMLName   xml.Name       `xml:"http://schemas.openxmlformats.org/package/2006/content-types Types"`
+	Defaults  []xlsxDefault  `xml:"Default"`
+	Overrides []xlsxOverride `xml:"Override"`
+}
+
+type xlsxDefault struct {
+	Extension   string `xml:"Extension,attr"`
+	ContentType string `xml:"ContentType,attr"`
+}
+
+type xlsxOverride struct {
+	PartName    string `xml:"PartName,attr"`
+	ContentType string `xml:"ContentType,attr"`
+}
+
+// xlsxStyles 字体：默认、粗体、深红；填充：无、gray125（规范要求）、表头灰、未通过浅红；
+// 单元格样式顺序与 xlsxStyle* 常量一致，P值等小数使用 0.000000 格式
+const xlsxStyles = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">
+<numFmts count="1"><numFmt numFmtId="164" formatCode="0.000000"/></numFmts>
+<fonts count="3"><font><sz val="11"/><name val="Calibri"/></font><font><b/><sz val="11"/><name val="Calibri"/></font><font><sz val="11"/><color rgb="FF9C0006"/><name val="Calibri"/></font></fonts>
+<fills count="4"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill><fill><patternFill patternType="solid"><fgColor rgb="FFD9D9D9"/></patternFill></fill><fill><patternFill patternType="solid"><fgColor rgb="FFFFC7CE"/></patternFill></fill></fills>
+<borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders>
+<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>
+<cellXfs count="5"><xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/><xf numFmtId="0" fontId="1" fillId="2" borderId="0" xfId="0" applyFont="1" applyFill="1"/><xf numFmtId="164" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/><xf numFmtId="164" fontId="2" fillId="3" borderId="0" xfId="0" applyNumberFormat="1" applyFont="1" applyFill="1"/><xf numFmtId="0" fontId="2" fillId="3" borderId="0" xfId="0" applyFont="1" applyFill="1"/></cellXfs>
+</styleSheet>
+`
+
+// xlsxPart 工作簿中的一个 XML 部件
+type xlsxPart struct {
+	name string
+	v    interface{}
+}
+
+// writeXLSX 将工作表写为 xlsx 工作簿
+func writeXLSX(w io.Writer, sheets []xlsxSheet) error {
+	const (
+		typeSheet     = "application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"
+		typeWorkbook  = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"
+		typeStyles    = "application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"
+		relDocument   = "http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument"
+		relWorksheet  = "http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet"
+		relStylesheet = "http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles"
+	)
+
+	types := xlsxContentTypes{
+		Defaults: []xlsxDefault{
+			{"rels", "application/vnd.openxmlformats-package.relationships+xml"},
+			{"xml", "application/xml"},
+		},
+		Overrides: []xlsxOverride{
+			{"/xl/workbook.xml", typeWorkbook},
+			{"/xl/styles.xml", typeStyles},
+		},
+	}
+	rootRels := xlsxRelationships{Rels: []xlsxRel{{"rId1", relDocument, "xl/workbook.xml"}}}
+	workbook := xlsxWorkbook{NS: xlsxNS, RelNS: xlsxRelNS}
+	var workbookRels xlsxRelationships
+	var sheetParts []xlsxPart
+	for i, sheet := range sheets {
+		id := fmt.Sprintf("rId%d", i+1)
+		part := fmt.Sprintf("worksheets/sheet%d.xml", i+1)
+		workbook.Sheets = append(workbook.Sheets, xlsxSheetRef{sheet.Name, i + 1, id})
+		workbookRels.Rels = append(workbookRels.Rels, xlsxRel{id, relWorksheet, part})
+		types.Overrides = append(types.Overrides, xlsxOverride{"/xl/" + part, typeSheet})
+		sheetParts = append(sheetParts, xlsxPart{"xl/" + part, sheet.worksheet()})
+	}
+	workbookRels.Rels = append(workbookRels.Rels, xlsxRel{fmt.Sprintf("rId%d", len(sheets)+1), relStylesheet, "styles.xml"})
+
+	parts := append([]xlsxPart{
+		{"[Content_Types].xml", types},
+		{"_rels/.rels", rootRels},
+		{"xl/workbook.xml", workbook},
+		{"xl/_rels/workbook.xml.rels", workbookRels},
+	}, sheetParts...)
+
+	zw := zip.NewWriter(w)
+	for _, part := range parts {
+		fw, err := zw.Create(part.name)
+		if err != nil {
+			return err
+		}
+		if _, err = io.WriteString(fw, xml.Header); err != nil {
+			return err
+		}
+		if err = xml.NewEncoder(fw).Encode(part.v); err != nil {
+			return err
+		}
+	}
+	fw, err := zw.Create("xl/styles.xml")
+	if err != nil {
+		return err
+	}
+	if _, err = io.WriteString(fw, xlsxStyles); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+// worksheet 生成工作表的 XML 结构，为各单元格填写引用并冻结首行
+func (s xlsxSheet) worksheet() *xlsxWorksheet {
+	ws := &xlsxWorksheet{NS: xlsxNS, SheetViews: &xlsxSheetViews{}}
+	ws.SheetViews.View.Pane.YSplit = 1
+	ws.SheetViews.View.Pane.TopLeftCell = "A2"
+	ws.SheetViews.View.Pane.ActivePane = "bottomLeft"
+	ws.SheetViews.View.Pane.State = "frozen"
+	for i, width := range s.ColWidth {
+		ws.Cols = append(ws.Cols, xlsxCol{Min: i + 1, Max: i + 1, Width: width, CustomWidth: 1})
+	}
+	for r, cells := range s.Rows {
+		row := xlsxRow{R: r + 1, Cells: make([]xlsxCell, len(cells))}
+		for c, cell := range cells {
+			cell.Ref = xlsxColName(c) + strconv.Itoa(r+1)
+			row.Cells[c] = cell
+		}
+		ws.Rows = append(ws.Rows, row)
+	}
+	return ws
+}
@@ -0,0 +1,91 @@
+// Copyright (c) 2021 Quan guanyu
+// randomness is licensed under Mulan PSL v2.
+// You can use this software according to the terms and conditions of the Mulan PSL v2.
+// You may obtain a copy of Mulan PSL v2 at:
+//          http://license.coscl.org.cn/MulanPSL2
+// THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND,
+// EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT,
+// MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+// See the Mulan PSL v2 for more details.
+
+package randomness
+
+import (
+	"math"
+	"math/bits"
+)
+
+// TopologicalBinary 拓扑二元检测
+func TopologicalBinary(data []byte) *TestResult {
+	p, q := TopologicalBinaryTestBytes(data, topologicalBinaryParam(len(data)*8))
+	return &TestResult{Name: "拓扑二元检测", P: p, Q: q, Pass: p >= Alpha}
+}
+
+// TopologicalBinaryTest 拓扑二元检测，参数按序列长度选取，见 TopologicalBinaryProto
+func TopologicalBinaryTest(bits []bool) (float64, float64) {
+	return TopologicalBinaryProto(bits, topologicalBinaryParam(len(bits)))
+}
+
+// TopologicalBinaryTestBytes 拓扑二元检测
+// 直接由字节组装 m 比特模式，避免字节切片到位切片的转换。
+//
+// data: 检测序列
+// m: 模式比特数
+func TopologicalBinaryTestBytes(data []byte, m int) (float64, float64) {
+	return topologicalBinaryWords(Words(data, m, MSBFirst), m)
+}
+
+// TopologicalBinaryProto 拓扑二元检测（Alcover 等提出的 topological binary test）
+// 将序列划分为 k 个不重叠的 m 比特模式，统计其中不同模式的个数 D。
+// 随机序列下 L=2^m，E[D]=L(1-(1-1/L)^k)，
+// Var[D]=L(1-1/L)^k + L(L-1)(1-2/L)^k - L^2(1-1/L)^{2k}，
+// V=(D-E[D])/√Var[D] 近似服从标准正态分布，P=erfc(|V|/√2)，Q=erfc(V/√2)/2。
+// 不同模式过少（重复过多）或过多（模式分布过于均匀）都会被检出。
+//
+// 参数选取建议：取满足 m·2^m<=n 的最大 m，使 k 约为 L 的1~2倍，n=10^6 时 m=15，n=10^8 时 m=22。
+//
+// bits: 检测序列
+// m: 模式比特数，1<=m<=28
+func TopologicalBinaryProto(bits []bool, m int) (float64, float64) {
+	return topologicalBinaryWords(bitWords(bits, m), m)
+}
+
+// topologicalBinaryParam 按序列长度选取拓扑二元检测的模式比特数
+func topologicalBinaryParam(n int) int {
+	m := 1
+	for m < 28 && (m+1)<<uint(m+1) <= n {
+		m++
+	}
+	return m
+}
+
+// topologicalBinaryWords 对 m 比特模式序列进行拓扑二元检测
+func topologicalBinaryWords(words []uint64, m int) (float64, float64) {
+	if m < 1 || m > 28 {
+		panic("pattern size m must be in [1, 28]")
+	}
+	k := float64(len(words))
+	L := float64(uint64(1) << uint(m))
+	// a=(1-1/L)^k, b=(1-2/L)^k, b-a^2 = a^2(exp(k·log(1-1/(L-1)^2))-1)
+	a := math.Exp(k * math.Log1p(-1/L))
+	b := math.Exp(k * math.Log1p(-2/L))
+	mean := L * (1 - a)
+	variance := L*(a-b) + L*L*a*a*math.Expm1(k*math.Log1p(-1/((L-1)*(L-1))))
+	if len(words) < 2 || variance <= 1 {
+		panic("please provide valid test bits")
+	}
+
+	seen := make([]uint64, (1<<uint(m)+63)/64)
+	for _, w := range words {
+		seen[w>>6] |= 1 << (w & 63)
+	}
+	D := 0
+	for _, v := range seen {
+		D += bits.OnesCount64(v)
+	}
+
+	V := (float64(D) - mean) / math.Sqrt(variance)
+	P := math.Erfc(math.Abs(V) / math.Sqrt2)
+	Q := math.Erfc(V/math.Sqrt2) / 2
+	return P, Q
+}
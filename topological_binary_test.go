@@ -0,0 +1,54 @@
+package randomness
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestTopologicalBinarySample(t *testing.T) {
+	p, q := TopologicalBinaryTest(sampleTestBits128)
+	fmt.Printf("n: %v, P-value: %f, Q-value: %f\n", len(sampleTestBits128), p, q)
+	if fmt.Sprintf("%.6f", p) != "0.386623" || fmt.Sprintf("%.6f", q) != "0.806689" {
+		t.FailNow()
+	}
+	p, q = TopologicalBinaryTestBytes([]byte{0xcc, 0x15, 0x6c, 0x4c, 0xe0, 0x02, 0x4d, 0x51, 0x13, 0xd6, 0x80, 0xd7, 0xcc, 0xe6, 0xd8, 0xb2}, 4)
+	if fmt.Sprintf("%.6f", p) != "0.386623" || fmt.Sprintf("%.6f", q) != "0.806689" {
+		t.FailNow()
+	}
+}
+
+func TestTopologicalBinaryE(t *testing.T) {
+	bits := getEConstantBits()
+	p, q := TopologicalBinaryTest(bits)
+	fmt.Printf("n: %v, P-value: %f, Q-value: %f\n", len(bits), p, q)
+	if fmt.Sprintf("%.6f", p) != "0.452271" || fmt.Sprintf("%.6f", q) != "0.226136" {
+		t.FailNow()
+	}
+	p2, q2 := TopologicalBinaryTestBytes(getEConstantBytes(), 15)
+	if p2 != p || q2 != q {
+		t.Fatalf("bytes %f %f, bits %f %f", p2, q2, p, q)
+	}
+}
+
+func TestTopologicalBinaryParam(t *testing.T) {
+	if m := topologicalBinaryParam(128); m != 4 {
+		t.Fatalf("m = %d for n=128, want 4", m)
+	}
+	if m := topologicalBinaryParam(1000000); m != 15 {
+		t.Fatalf("m = %d for n=10^6, want 15", m)
+	}
+	if m := topologicalBinaryParam(100000000); m != 22 {
+		t.Fatalf("m = %d for n=10^8, want 22", m)
+	}
+}
+
+func TestTopologicalBinaryRepeated(t *testing.T) {
+	// 序列中段的1500个15比特模式重复序列开头的模式，不同模式个数偏少
+	bits := append([]bool(nil), getEConstantBits()...)
+	copy(bits[500010:500010+15*1500], bits)
+	p, q := TopologicalBinaryTest(bits)
+	fmt.Printf("n: %v, P-value: %f, Q-value: %f\n", len(bits), p, q)
+	if fmt.Sprintf("%.6f", p) != "0.000405" || fmt.Sprintf("%.6f", q) != "0.999797" {
+		t.FailNow()
+	}
+}
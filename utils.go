@@ -30,6 +30,15 @@ func subsequencepattern(bits []bool, m int) int {
 	return tmp
 }
 
+// bitWords 将比特序列按顺序转换为不重叠的 w 比特整数，先读到的比特为高位，末尾不足 w 比特的部分被丢弃
+func bitWords(bits []bool, w int) []uint64 {
+	words := make([]uint64, len(bits)/w)
+	for i := range words {
+		words[i] = uint64(subsequencepattern(bits[i*w:], w))
+	}
+	return words
+}
+
 func igam(a, x float64) float64 {
 	var ans, ax, c, r float64
 
@@ -412,7 +421,8 @@ func ReadGroup(filename string) []bool {
 	return bits
 }
 
-// ReadGroupInASCIIFormat
+// ReadGroupInASCIIFormat 从 ASCII '0'/'1' 文本文件中读取 10^6 比特，读取失败时 panic，
+// 需要任意长度或返回错误时使用 DecodeASCIIBits
 func ReadGroupInASCIIFormat(filename string) []bool {
 	file, err := os.Open(filename)
 	if err != nil {
@@ -0,0 +1,113 @@
+// Copyright (c) 2021 Quan guanyu
+// randomness is licensed under Mulan PSL v2.
+// You can use this software according to the terms and conditions of the Mulan PSL v2.
+// You may obtain a copy of Mulan PSL v2 at:
+//          http://license.coscl.org.cn/MulanPSL2
+// THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND,
+// EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT,
+// MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+// See the Mulan PSL v2 for more details.
+
+package randomness
+
+import (
+	"math"
+
+	"github.com/Trisia/randomness/fft"
+)
+
+// WalshHadamard Walsh-Hadamard 变换检测，m=64
+func WalshHadamard(data []byte) *TestResult {
+	p, q := WalshHadamardTestBytes(data, 64)
+	return &TestResult{Name: "Walsh-Hadamard变换检测", P: p, Q: q, Pass: p >= Alpha}
+}
+
+// WalshHadamardTest Walsh-Hadamard 变换检测，m=64
+func WalshHadamardTest(bits []bool) (float64, float64) {
+	return WalshHadamardProto(bits, 64)
+}
+
+// WalshHadamardTestBytes Walsh-Hadamard 变换检测
+// 这里直接对字节直接处理，使用查表完成块内每个字节的前3级蝶形运算，避免字节切片到位切片的转换。
+//
+// data: 检测序列
+// m: 分块长度，2的幂次且 m>=2
+func WalshHadamardTestBytes(data []byte, m int) (float64, float64) {
+	if len(data) == 0 {
+		panic("please provide valid test bits")
+	}
+	if m < 8 || m&(m-1) != 0 { // 块长小于一个字节时回退到位级别处理
+		return WalshHadamardProto(B2bitArr(data), m)
+	}
+	N := len(data) * 8 / m
+	if N < 2 {
+		panic("please provide valid test bits")
+	}
+	S := make([]float64, m)
+	w := make([]int32, m)
+	for i := 0; i < N; i++ {
+		fft.WHTBytes(data[i*m/8:(i+1)*m/8], w)
+		walshHadamardAccumulate(S, w)
+	}
+	P := walshHadamardPValue(S, N, m)
+	return P, P
+}
+
+// WalshHadamardProto Walsh-Hadamard 变换检测
+// 将序列划分为 N 个长度为 m 的块，对每块的 ±1 序列做 Walsh-Hadamard 变换得到 W_i(u)，
+// 若块内比特满足（常数项逐块变化的）线性关系 b_x = u·x ⊕ c，则 |W_i(u)| = m。
+//
+// 对每个 u 统计 S(u) = Σ W_i(u)^2 / m，随机序列下 E[S(u)] = N，Var[S(u)] = N(2-2/m)，
+// 由于 Parseval 等式 Σ_u W_i(u)^2 = m^2，标准化后的 Z(u) 两两相关系数为 -1/(m-1)，
+// 因此 V = (m-1)/m * Σ Z(u)^2 近似服从自由度为 m-1 的卡方分布。
+//
+// 与只统计符号一致偏差的检测不同，平方和对线性关系的常数项不敏感，可检出逐块仿射的结构。
+// N 应足够大（建议 N>=100）以保证 S(u) 的正态近似。
+//
+// bits: 检测序列
+// m: 分块长度，2的幂次且 m>=2
+func WalshHadamardProto(bits []bool, m int) (float64, float64) {
+	n := len(bits)
+	if m < 2 || m&(m-1) != 0 {
+		panic("m must be a power of 2 and m >= 2")
+	}
+	N := n / m
+	if N < 2 {
+		panic("please provide valid test bits")
+	}
+	S := make([]float64, m)
+	w := make([]int32, m)
+	for i := 0; i < N; i++ {
+		for x := 0; x < m; x++ {
+			w[x] = 1
+			if bits[i*m+x] {
+				w[x] = -1
+			}
+		}
+		fft.WHT(w)
+		walshHadamardAccumulate(S, w)
+	}
+	P := walshHadamardPValue(S, N, m)
+	return P, P
+}
+
+// walshHadamardAccumulate 累加块的 Walsh-Hadamard 系数平方
+func walshHadamardAccumulate(S []float64, w []int32) {
+	for u, v := range w {
+		S[u] += float64(v) * float64(v)
+	}
+}
+
+// walshHadamardPValue 由系数平方和 S(u) 计算P值
+func walshHadamardPValue(S []float64, N, m int) float64 {
+	fm := float64(m)
+	mean := float64(N)
+	sd := math.Sqrt(float64(N) * (2 - 2/fm))
+	var V float64 = 0
+	for _, s := range S {
+		z := (s/fm - mean) / sd
+		V += z * z
+	}
+	V *= (fm - 1) / fm
+	return igamc((fm-1)/2, V/2)
+}
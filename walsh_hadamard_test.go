@@ -0,0 +1,58 @@
+package randomness
+
+import (
+	"fmt"
+	"math/bits"
+	"testing"
+)
+
+func TestWalshHadamardSample(t *testing.T) {
+	p, q := WalshHadamardProto(sampleTestBits128, 8)
+	fmt.Printf("n: %v, P-value: %f, Q-value: %f\n", len(sampleTestBits128), p, q)
+	if fmt.Sprintf("%.6f", p) != "0.010145" || fmt.Sprintf("%.6f", q) != "0.010145" {
+		t.FailNow()
+	}
+	p, q = WalshHadamardTestBytes([]byte{0xcc, 0x15, 0x6c, 0x4c, 0xe0, 0x02, 0x4d, 0x51, 0x13, 0xd6, 0x80, 0xd7, 0xcc, 0xe6, 0xd8, 0xb2}, 4)
+	if fmt.Sprintf("%.6f", p) != "0.297246" || fmt.Sprintf("%.6f", q) != "0.297246" {
+		t.FailNow()
+	}
+}
+
+func TestWalshHadamardE(t *testing.T) {
+	bitsArr := getEConstantBits()
+	data := getEConstantBytes()
+	for _, c := range []struct {
+		m    int
+		want string
+	}{
+		{8, "0.377017"},
+		{64, "0.066352"},
+		{256, "0.422115"},
+	} {
+		p1, q1 := WalshHadamardTestBytes(data, c.m)
+		p2, q2 := WalshHadamardProto(bitsArr, c.m)
+		fmt.Printf("m: %v, P-value: %f, Q-value: %f\n", c.m, p1, q1)
+		if fmt.Sprintf("%.6f", p1) != c.want || fmt.Sprintf("%.6f", q1) != c.want ||
+			fmt.Sprintf("%.6f", p2) != c.want || fmt.Sprintf("%.6f", q2) != c.want {
+			t.Fatalf("m=%d bytes %f proto %f, want %s", c.m, p1, p2, c.want)
+		}
+	}
+}
+
+// 每1000个块中有一个满足 b_x = u·x ⊕ c，c 逐块变化，单比特频数检测无法发现
+func TestWalshHadamardAffine(t *testing.T) {
+	const m = 64
+	const u = 0x2d
+	bitsArr := append([]bool(nil), getEConstantBits()...)
+	for i := 0; i < len(bitsArr)/m; i += 1000 {
+		c := bitsArr[i*m]
+		for x := 0; x < m; x++ {
+			bitsArr[i*m+x] = bits.OnesCount(uint(u&x))%2 == 1 != c
+		}
+	}
+	wp, wq := WalshHadamardTest(bitsArr)
+	fmt.Printf("Walsh-Hadamard P: %f, Q: %f\n", wp, wq)
+	if fmt.Sprintf("%.6f", wp) != "0.000134" || fmt.Sprintf("%.6f", wq) != "0.000134" {
+		t.FailNow()
+	}
+}
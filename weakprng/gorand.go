@@ -0,0 +1,109 @@
+package weakprng
+
+import (
+	"math/rand"
+	"strconv"
+)
+
+const (
+	goRandLen = 607
+	goRandTap = 273
+	// goRandSeedSearch 识别成立后搜索的种子范围 [0, goRandSeedSearch)
+	goRandSeedSearch = 1 << 16
+)
+
+// goRandLayout Go math/rand 输出在样本中的排列方式
+type goRandLayout struct {
+	layout
+	bits  uint // 满足递推关系的低位比特数
+	carry bool // 输出为状态的高位截断时，低位递推可能带有来自被截断部分的进位
+}
+
+var goRandLayouts = []goRandLayout{
+	// Rand.Read 每次取 Int63 的低56比特，按小端序输出7个字节
+	{layout{"Rand.Read 字节流", 7, false}, 56, false},
+	{layouts64[0], 63, false},
+	{layouts64[1], 63, false},
+	// Uint32 与 Int31 分别为 Int63 右移31与32比特
+	{layouts32[0], 31, true},
+	{layouts32[1], 31, true},
+}
+
+// RecognizeGoMathRand 识别 Go math/rand 的 rand.NewSource 发生器（加法滞后斐波那契发生器）
+// 其内部状态满足 x_n = x_{n-607} + x_{n-273} mod 2^64，依次尝试 Rand.Read 字节流、
+// Int63/Uint64 与 Uint32/Int31 的输出排列方式，以前607个输出为状态验证其余输出。
+// 识别成立后在 [0, 65536) 内搜索重新生成相同首个输出的种子（样本须从第一个输出开始），
+// rand.NewSource 将种子模 2^31-1 后使用，给出的种子为其模 2^31-1 的余数。
+// Go 1.20 起未调用 rand.Seed 的全局函数使用运行时随机源，不在识别范围内。
+//
+// data: 待识别样本
+func RecognizeGoMathRand(data []byte) *Recognition {
+	for i := range goRandLayouts {
+		l := &goRandLayouts[i]
+		for offset := 0; offset < l.size; offset++ {
+			if r := l.recognize(l.words(data, offset)); r != nil {
+				r.Layout = l.name
+				r.Offset = offset
+				return r
+			}
+		}
+	}
+	return nil
+}
+
+func (l *goRandLayout) recognize(words []uint64) *Recognition {
+	n := len(words)
+	if n < goRandLen+minVerified {
+		return nil
+	}
+	mask := uint64(1)<<l.bits - 1
+	for k := goRandLen; k < n; k++ {
+		d := (words[k] - words[k-goRandLen] - words[k-goRandTap]) & mask
+		if d != 0 && !(l.carry && d == 1) {
+			return nil
+		}
+	}
+
+	// 由样本的取值范围区分输出方法
+	var top uint64
+	for _, w := range words {
+		top |= w
+	}
+	var method string
+	var output func(src rand.Source64) uint64
+	switch {
+	case l.size == 7:
+		method = "Read"
+		output = func(src rand.Source64) uint64 { return uint64(src.Int63()) & mask }
+	case l.size == 8 && top>>63 == 0:
+		method = "Int63"
+		output = func(src rand.Source64) uint64 { return uint64(src.Int63()) }
+	case l.size == 8:
+		method = "Uint64"
+		output = func(src rand.Source64) uint64 { return src.Uint64() }
+	case top>>31 == 0:
+		method = "Int31"
+		output = func(src rand.Source64) uint64 { return uint64(src.Int63() >> 32) }
+	default:
+		method = "Uint32"
+		output = func(src rand.Source64) uint64 { return uint64(uint32(src.Int63() >> 31)) }
+	}
+
+	r := &Recognition{
+		Generator: "Go math/rand",
+		Verified:  n - goRandLen,
+		Params:    []Param{{"输出方法", method}},
+	}
+	src := rand.NewSource(0).(rand.Source64)
+	for seed := int64(0); seed < goRandSeedSearch; seed++ {
+		src.Seed(seed)
+		i := 0
+		for ; i < minVerified && output(src) == words[i]; i++ {
+		}
+		if i == minVerified {
+			r.Params = append(r.Params, Param{"种子", strconv.FormatInt(seed, 10)})
+			break
+		}
+	}
+	return r
+}
@@ -0,0 +1,209 @@
+package weakprng
+
+import (
+	"math/big"
+	"strconv"
+)
+
+// RecognizeLCG 识别输出完整状态的线性同余发生器 x_{n+1} = (a x_n + c) mod m
+// 依次尝试32比特与64比特、小端与大端的排列方式，模数 m、乘数 a 与增量 c 均未知：
+// 记 t_n = x_{n+1} - x_n，则 t_{n+2} t_n - t_{n+1}^2 均为 m 的倍数，取其最大公因数得到 m，
+// 再由 a = t_{n+1} / t_n mod m、c = x_1 - a x_0 mod m 得到其余参数，并验证全部输出。
+// 可识别 MINSTD、Numerical Recipes、Knuth MMIX 等各类完整输出的LCG。
+//
+// data: 待识别样本
+func RecognizeLCG(data []byte) *Recognition {
+	layouts := append(append([]layout{}, layouts32...), layouts64...)
+	return scan(data, layouts, recognizeLCGWords)
+}
+
+func recognizeLCGWords(words []uint64, _ layout) *Recognition {
+	n := len(words)
+	if n < minVerified+2 {
+		return nil
+	}
+	x := make([]*big.Int, 16)
+	for i := range x {
+		x[i] = new(big.Int).SetUint64(words[i])
+	}
+	t := make([]*big.Int, len(x)-1)
+	for i := range t {
+		t[i] = new(big.Int).Sub(x[i+1], x[i])
+	}
+	m := new(big.Int)
+	u, v := new(big.Int), new(big.Int)
+	for i := 0; i+2 < len(t); i++ {
+		u.Mul(t[i+2], t[i])
+		v.Mul(t[i+1], t[i+1])
+		u.Sub(u, v).Abs(u)
+		m.GCD(nil, nil, m, u)
+	}
+	// 模数须大于所有输出
+	maxWord := uint64(0)
+	for _, w := range words {
+		if w > maxWord {
+			maxWord = w
+		}
+	}
+	if m.Cmp(new(big.Int).SetUint64(maxWord)) <= 0 {
+		return nil
+	}
+
+	a := new(big.Int)
+	found := false
+	for i := 0; i+1 < len(t) && !found; i++ {
+		ti := new(big.Int).Mod(t[i], m)
+		if inv := new(big.Int).ModInverse(ti, m); inv != nil {
+			a.Mul(t[i+1], inv).Mod(a, m)
+			found = true
+		}
+	}
+	if !found {
+		return nil
+	}
+	c := new(big.Int).Mul(a, x[0])
+	c.Sub(x[1], c).Mod(c, m)
+
+	xi := new(big.Int).SetUint64(words[0])
+	for i := 1; i < n; i++ {
+		xi.Mul(xi, a).Add(xi, c).Mod(xi, m)
+		if !xi.IsUint64() || xi.Uint64() != words[i] {
+			return nil
+		}
+	}
+
+	r := &Recognition{
+		Generator: "线性同余发生器",
+		Verified:  n - 2,
+		Params: []Param{
+			{"a", a.String()},
+			{"c", c.String()},
+			{"m", modulusString(m)},
+		},
+	}
+	// 样本从发生器的第一个输出开始时，种子即 x_0 的前一个状态
+	if inv := new(big.Int).ModInverse(a, m); inv != nil {
+		seed := new(big.Int).Sub(x[0], c)
+		seed.Mul(seed, inv).Mod(seed, m)
+		r.Params = append(r.Params, Param{"种子", seed.String()})
+	}
+	return r
+}
+
+// modulusString 模数为2的幂时格式化为 2^k
+func modulusString(m *big.Int) string {
+	if k := m.BitLen() - 1; k > 0 && new(big.Int).Lsh(big.NewInt(1), uint(k)).Cmp(m) == 0 {
+		return "2^" + strconv.Itoa(k)
+	}
+	return m.String()
+}
+
+// truncatedLCG 模数为2的幂、只输出部分状态比特的常见LCG
+type truncatedLCG struct {
+	name    string
+	a, c    uint64
+	mBits   uint // 模数 2^mBits
+	shift   uint // 输出为 (state >> shift) 的低 outBits 比特
+	outBits uint
+	// seed 由首个输出的前一个状态推导种子
+	seed func(prev uint64) []Param
+}
+
+var truncatedLCGs = []truncatedLCG{
+	{
+		name: "Java Random.nextInt / drand48 mrand48", a: 0x5DEECE66D, c: 0xB, mBits: 48, shift: 16, outBits: 32,
+		seed: rand48Seed,
+	},
+	{
+		name: "drand48 lrand48", a: 0x5DEECE66D, c: 0xB, mBits: 48, shift: 17, outBits: 31,
+		seed: rand48Seed,
+	},
+	{
+		name: "MSVC rand", a: 214013, c: 2531011, mBits: 32, shift: 16, outBits: 15,
+		seed: func(prev uint64) []Param { return []Param{{"种子", strconv.FormatUint(prev, 10)}} },
+	},
+}
+
+// rand48Seed Java Random 的种子为初始状态异或 0x5DEECE66D，srand48 的初始状态为 seed<<16|0x330E
+func rand48Seed(prev uint64) []Param {
+	p := []Param{{"Java种子", strconv.FormatUint(prev^0x5DEECE66D, 10)}}
+	if prev&0xFFFF == 0x330E {
+		p = append(p, Param{"srand48种子", strconv.FormatUint(prev>>16, 10)})
+	}
+	return p
+}
+
+// RecognizeTruncatedLCG 识别只输出部分状态比特的常见LCG（Java Random、drand48、MSVC rand）
+// 参数已知，未输出的状态比特不超过17比特：由第一个输出枚举全部可能的完整状态，
+// 以后续连续输出筛选，唯一保留的状态再验证全部输出，并推导种子。
+//
+// data: 待识别样本
+func RecognizeTruncatedLCG(data []byte) *Recognition {
+	for i := range truncatedLCGs {
+		g := &truncatedLCGs[i]
+		layouts := layouts32
+		if g.outBits <= 16 {
+			layouts = append(append([]layout{}, layouts16...), layouts32...)
+		}
+		if r := scan(data, layouts, g.recognize); r != nil {
+			return r
+		}
+	}
+	return nil
+}
+
+func (g *truncatedLCG) recognize(words []uint64, _ layout) *Recognition {
+	n := len(words)
+	if n < minVerified+1 {
+		return nil
+	}
+	outMask := uint64(1)<<g.outBits - 1
+	for _, w := range words {
+		if w > outMask {
+			return nil
+		}
+	}
+	mMask := uint64(1)<<g.mBits - 1
+	lowMask := uint64(1)<<g.shift - 1
+	hidden := g.mBits - g.outBits
+	next := func(s uint64) uint64 { return (g.a*s + g.c) & mMask }
+	out := func(s uint64) uint64 { return s >> g.shift & outMask }
+
+	// 状态最高的未输出比特不影响输出时（如 MSVC rand 的第31比特）先枚举到的状态取0
+	for k := uint64(0); k < 1<<hidden; k++ {
+		s := k&lowMask | words[0]<<g.shift | (k>>g.shift)<<(g.shift+g.outBits)
+		t, i := s, 1
+		for ; i < n; i++ {
+			t = next(t)
+			if out(t) != words[i] {
+				break
+			}
+		}
+		if i < n {
+			continue
+		}
+		r := &Recognition{
+			Generator: g.name,
+			Verified:  n - 1,
+			Params: []Param{
+				{"a", hex(g.a)},
+				{"c", hex(g.c)},
+				{"m", "2^" + strconv.Itoa(int(g.mBits))},
+				{"状态", hex(s)},
+			},
+		}
+		// 首个输出的前一个状态 (s - c) a^{-1} mod 2^mBits
+		r.Params = append(r.Params, g.seed((s-g.c)*inverse64(g.a)&mMask)...)
+		return r
+	}
+	return nil
+}
+
+// inverse64 奇数 a 模 2^64 的乘法逆元（牛顿迭代）
+func inverse64(a uint64) uint64 {
+	x := a
+	for i := 0; i < 5; i++ {
+		x *= 2 - a*x
+	}
+	return x
+}
@@ -0,0 +1,87 @@
+package weakprng
+
+import (
+	"bytes"
+	"strconv"
+
+	"github.com/Trisia/randomness"
+)
+
+// lfsrMaxBits LFSR 识别最多分析的比特数，可识别级数不超过 lfsrMaxBits/4 的LFSR
+const lfsrMaxBits = 100000
+
+// RecognizeLFSR 识别线性反馈移位寄存器（LFSR）
+// 分别按字节内高位在前与低位在前展开样本的前 100000 比特，使用 Berlekamp-Massey 算法计算线性复杂度 L。
+// 随机序列的线性复杂度约为 n/2，L<=n/4 时判定为 L 级LFSR生成，取 L 较小的展开顺序给出连接多项式与初始状态，
+// 整个序列均由该LFSR生成，前 2L 比特之后的比特均被正确预测。
+//
+// data: 待识别样本
+func RecognizeLFSR(data []byte) *Recognition {
+	n := len(data) * 8
+	if n > lfsrMaxBits {
+		n = lfsrMaxBits
+	}
+	if n < 4*minVerified {
+		return nil
+	}
+	orders := []struct {
+		name string
+		bit  func(i int) bool
+	}{
+		{"比特流（字节内高位在前）", func(i int) bool { return data[i>>3]&(0x80>>uint(i&7)) != 0 }},
+		{"比特流（字节内低位在前）", func(i int) bool { return data[i>>3]&(1<<uint(i&7)) != 0 }},
+	}
+	// 按错误的比特顺序展开时序列仍由LFSR生成，但级数变为8倍，取线性复杂度较小的展开顺序
+	var r *Recognition
+	for _, o := range orders {
+		res := randomness.LinearComplexityProfileAnalyze(o.bit, n, n)
+		L := res.L
+		// 全零序列的线性复杂度为0，不视为LFSR
+		if L == 0 || res.Polynomial == nil || n-2*L < minVerified || (r != nil && n-2*L <= r.Verified) {
+			continue
+		}
+		r = &Recognition{
+			Generator: "LFSR",
+			Layout:    o.name,
+			Verified:  n - 2*L,
+			Params: []Param{
+				{"级数", strconv.Itoa(L)},
+				{"连接多项式", polynomialString(res.Polynomial)},
+			},
+		}
+		if L <= 256 {
+			var state bytes.Buffer
+			for i := 0; i < L; i++ {
+				state.WriteByte('0' + byte(b2i(o.bit(i))))
+			}
+			r.Params = append(r.Params, Param{"初始状态", state.String()})
+		}
+	}
+	return r
+}
+
+// polynomialString 由系数为1的项的次数（降序）格式化连接多项式
+func polynomialString(exponents []int) string {
+	var buf bytes.Buffer
+	for i, e := range exponents {
+		if i > 0 {
+			buf.WriteByte('+')
+		}
+		switch e {
+		case 0:
+			buf.WriteByte('1')
+		case 1:
+			buf.WriteByte('x')
+		default:
+			buf.WriteString("x^" + strconv.Itoa(e))
+		}
+	}
+	return buf.String()
+}
+
+func b2i(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,129 @@
+package weakprng
+
+import "strconv"
+
+const (
+	mtN        = 624
+	mtM        = 397
+	mtMatrixA  = 0x9908B0DF
+	mtUpper    = 0x80000000
+	mtLower    = 0x7FFFFFFF
+	mtInitMult = 1812433253
+)
+
+// RecognizeMT19937 识别 MT19937（32比特梅森旋转）
+// 由连续624个输出逆向回火（untemper）恢复完整内部状态，
+// 此后每个输出均满足 x_{k+624} = x_{k+397} ⊕ twist(x_k, x_{k+1})，据此预测并验证其余输出。
+// 样本从 init_genrand(seed) 之后的第一个输出开始时（如 C++ std::mt19937），
+// 逆推第一次旋转与初始化递推恢复32比特种子，重新生成验证后给出。
+//
+// data: 待识别样本
+func RecognizeMT19937(data []byte) *Recognition {
+	return scan(data, layouts32, recognizeMT19937Words)
+}
+
+func recognizeMT19937Words(words []uint64, _ layout) *Recognition {
+	n := len(words)
+	if n < mtN+minVerified {
+		return nil
+	}
+	x := make([]uint32, n)
+	for i, w := range words {
+		x[i] = mtUntemper(uint32(w))
+	}
+	for k := 0; k+mtN < n; k++ {
+		if x[k+mtN] != x[k+mtM]^mtTwist(x[k], x[k+1]) {
+			return nil
+		}
+	}
+
+	r := &Recognition{
+		Generator: "MT19937",
+		Verified:  n - mtN,
+	}
+	if seed, ok := mtRecoverSeed(x[:mtN]); ok {
+		r.Params = []Param{{"种子", strconv.FormatUint(uint64(seed), 10)}}
+	}
+	return r
+}
+
+// mtTwist 由 x_k 的最高位与 x_{k+1} 的低31位计算旋转项
+func mtTwist(a, b uint32) uint32 {
+	y := a&mtUpper | b&mtLower
+	return y>>1 ^ (y&1)*mtMatrixA
+}
+
+// mtTemper MT19937 的回火变换
+func mtTemper(y uint32) uint32 {
+	y ^= y >> 11
+	y ^= y << 7 & 0x9D2C5680
+	y ^= y << 15 & 0xEFC60000
+	y ^= y >> 18
+	return y
+}
+
+// mtUntemper 回火变换的逆变换
+func mtUntemper(y uint32) uint32 {
+	y ^= y >> 18
+	y ^= y << 15 & 0xEFC60000
+	// y ^= y<<7 & mask 的逆：每次恢复7比特
+	t := y
+	for i := 0; i < 4; i++ {
+		t = y ^ t<<7&0x9D2C5680
+	}
+	y = t
+	// y ^= y>>11 的逆
+	t = y
+	for i := 0; i < 2; i++ {
+		t = y ^ t>>11
+	}
+	return t
+}
+
+// mtInit init_genrand 初始化状态
+func mtInit(seed uint32) []uint32 {
+	mt := make([]uint32, mtN)
+	mt[0] = seed
+	for i := 1; i < mtN; i++ {
+		mt[i] = mtInitMult*(mt[i-1]^mt[i-1]>>30) + uint32(i)
+	}
+	return mt
+}
+
+// mtGenerate 对状态进行一次完整旋转
+func mtGenerate(mt []uint32) {
+	for i := 0; i < mtN; i++ {
+		mt[i] = mt[(i+mtM)%mtN] ^ mtTwist(mt[i], mt[(i+1)%mtN])
+	}
+}
+
+// mtRecoverSeed 由第一次旋转后的状态恢复 init_genrand 的种子
+// 对 i>=227，旋转时 mt[i+397] 已被更新，因此 new[i]⊕new[i-227] 即旋转项，
+// 由 i=227、228 的旋转项得到初始状态 mt[228]，再逆推初始化递推得到 mt[0]。
+func mtRecoverSeed(state []uint32) (uint32, bool) {
+	untwist := func(i int) uint32 {
+		t := state[i] ^ state[i-(mtN-mtM)]
+		// 旋转项最高位为 y 的最低位
+		b := t >> 31
+		if b == 1 {
+			t ^= mtMatrixA
+		}
+		return t<<1 | b
+	}
+	i := mtN - mtM
+	v := untwist(i+1)&mtUpper | untwist(i)&mtLower
+	inv := uint32(inverse64(mtInitMult))
+	for j := i + 1; j > 0; j-- {
+		v = (v - uint32(j)) * inv
+		v ^= v >> 30
+	}
+
+	mt := mtInit(v)
+	mtGenerate(mt)
+	for k := range mt {
+		if mt[k] != state[k] {
+			return 0, false
+		}
+	}
+	return v, true
+}
@@ -0,0 +1,117 @@
+// Package weakprng 识别样本是否由常见的弱伪随机数发生器生成。
+//
+// 真随机数发生器检测失败时，最常见的原因是实际输出来自普通的伪随机数发生器。
+// 本包尝试识别线性同余发生器（含截断输出的常见LCG）、MT19937、LFSR 与 Go math/rand，
+// 并尽可能给出恢复出的参数、内部状态或种子。识别成立意味着样本的后续输出可以被完全预测。
+package weakprng
+
+import (
+	"bytes"
+	"fmt"
+)
+
+const (
+	// maxWords 每种排列方式最多分析的输出个数
+	maxWords = 1 << 16
+	// minVerified 判定识别成立所需的最少被正确预测的输出个数
+	minVerified = 16
+)
+
+// Param 恢复出的参数、状态或种子
+type Param struct {
+	Name  string
+	Value string
+}
+
+// Recognition 发生器识别结果
+type Recognition struct {
+	Generator string  // 发生器名称
+	Layout    string  // 输出在样本中的排列方式
+	Offset    int     // 第一个完整输出在样本中的字节偏移
+	Verified  int     // 由恢复出的参数或状态正确预测的输出个数
+	Params    []Param // 恢复出的参数、状态或种子
+}
+
+func (r *Recognition) String() string {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "%s（%s，偏移 %d 字节，已验证 %d 个输出）", r.Generator, r.Layout, r.Offset, r.Verified)
+	for _, p := range r.Params {
+		fmt.Fprintf(&buf, " %s=%s", p.Name, p.Value)
+	}
+	return buf.String()
+}
+
+// Recognize 依次尝试各识别方法，返回全部识别结果，未识别出任何发生器时返回 nil
+// data: 待识别样本
+func Recognize(data []byte) []*Recognition {
+	var res []*Recognition
+	for _, f := range []func([]byte) *Recognition{
+		RecognizeLCG,
+		RecognizeTruncatedLCG,
+		RecognizeMT19937,
+		RecognizeGoMathRand,
+		RecognizeLFSR,
+	} {
+		if r := f(data); r != nil {
+			res = append(res, r)
+		}
+	}
+	return res
+}
+
+// layout 输出在样本中的排列方式
+type layout struct {
+	name string
+	size int  // 每个输出的字节数
+	big  bool // 是否大端序
+}
+
+var (
+	layouts16 = []layout{{"16比特小端", 2, false}, {"16比特大端", 2, true}}
+	layouts32 = []layout{{"32比特小端", 4, false}, {"32比特大端", 4, true}}
+	layouts64 = []layout{{"64比特小端", 8, false}, {"64比特大端", 8, true}}
+)
+
+// words 从 offset 开始按排列方式解析至多 maxWords 个输出
+func (l layout) words(data []byte, offset int) []uint64 {
+	n := (len(data) - offset) / l.size
+	if n > maxWords {
+		n = maxWords
+	}
+	if n < 0 {
+		n = 0
+	}
+	w := make([]uint64, n)
+	for i := range w {
+		b := data[offset+i*l.size : offset+(i+1)*l.size]
+		var v uint64
+		for j := range b {
+			if l.big {
+				v = v<<8 | uint64(b[j])
+			} else {
+				v |= uint64(b[j]) << uint(8*j)
+			}
+		}
+		w[i] = v
+	}
+	return w
+}
+
+// scan 对每种排列方式与每个对齐偏移调用 f，返回第一个识别结果
+func scan(data []byte, layouts []layout, f func(words []uint64, l layout) *Recognition) *Recognition {
+	for _, l := range layouts {
+		for offset := 0; offset < l.size; offset++ {
+			if r := f(l.words(data, offset), l); r != nil {
+				r.Layout = l.name
+				r.Offset = offset
+				return r
+			}
+		}
+	}
+	return nil
+}
+
+// hex 十六进制格式化
+func hex(v uint64) string {
+	return fmt.Sprintf("0x%X", v)
+}
@@ -0,0 +1,173 @@
+package weakprng
+
+import (
+	crand "crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"math/rand"
+	"testing"
+)
+
+func param(r *Recognition, name string) string {
+	for _, p := range r.Params {
+		if p.Name == name {
+			return p.Value
+		}
+	}
+	return ""
+}
+
+func TestRecognizeRandom(t *testing.T) {
+	data := make([]byte, 125000)
+	if _, err := crand.Read(data); err != nil {
+		t.Fatal(err)
+	}
+	if res := Recognize(data); res != nil {
+		t.Fatalf("random data recognized as %v", res[0])
+	}
+}
+
+func TestRecognizeLCG(t *testing.T) {
+	// MINSTD，32比特小端
+	data := make([]byte, 4000)
+	x := uint64(2021)
+	for i := 0; i < len(data); i += 4 {
+		x = x * 48271 % 2147483647
+		binary.LittleEndian.PutUint32(data[i:], uint32(x))
+	}
+	r := RecognizeLCG(data)
+	if r == nil || param(r, "a") != "48271" || param(r, "c") != "0" || param(r, "m") != "2147483647" || param(r, "种子") != "2021" {
+		t.Fatalf("MINSTD not recognized: %v", r)
+	}
+	fmt.Println(r)
+
+	// Knuth MMIX，64比特大端，从第3个字节开始
+	data = make([]byte, 8003)
+	x = 1
+	for i := 3; i+8 <= len(data); i += 8 {
+		x = 6364136223846793005*x + 1442695040888963407
+		binary.BigEndian.PutUint64(data[i:], x)
+	}
+	r = RecognizeLCG(data)
+	if r == nil || r.Layout != "64比特大端" || r.Offset != 3 || param(r, "m") != "2^64" || param(r, "种子") != "1" {
+		t.Fatalf("MMIX not recognized: %v", r)
+	}
+}
+
+func TestRecognizeTruncatedLCG(t *testing.T) {
+	// java.util.Random(42).nextInt()，DataOutputStream 大端输出
+	data := make([]byte, 4000)
+	s := (uint64(42) ^ 0x5DEECE66D) & (1<<48 - 1)
+	for i := 0; i < len(data); i += 4 {
+		s = (s*0x5DEECE66D + 0xB) & (1<<48 - 1)
+		binary.BigEndian.PutUint32(data[i:], uint32(s>>16))
+	}
+	r := RecognizeTruncatedLCG(data)
+	if r == nil || r.Generator != truncatedLCGs[0].name || param(r, "Java种子") != "42" {
+		t.Fatalf("Java Random not recognized: %v", r)
+	}
+	fmt.Println(r)
+
+	// MSVC srand(7); rand()，16比特小端
+	data = make([]byte, 2000)
+	s = 7
+	for i := 0; i < len(data); i += 2 {
+		s = (s*214013 + 2531011) & 0xFFFFFFFF
+		binary.LittleEndian.PutUint16(data[i:], uint16(s>>16&0x7FFF))
+	}
+	r = RecognizeTruncatedLCG(data)
+	if r == nil || r.Generator != "MSVC rand" || param(r, "种子") != "7" {
+		t.Fatalf("MSVC rand not recognized: %v", r)
+	}
+}
+
+func TestRecognizeMT19937(t *testing.T) {
+	mt := mtInit(5489)
+	mtGenerate(mt)
+	if v := mtTemper(mt[0]); v != 3499211612 {
+		t.Fatalf("first output of MT19937(5489) = %d", v)
+	}
+	for _, y := range []uint32{0, 1, 0xFFFFFFFF, 0x12345678, 3499211612} {
+		if mtUntemper(mtTemper(y)) != y {
+			t.Fatalf("untemper(temper(%#x)) != %#x", y, y)
+		}
+	}
+
+	data := make([]byte, 4*2000)
+	mt = mtInit(20211019)
+	for i := 0; i < len(data); i += 4 {
+		if i/4%mtN == 0 {
+			mtGenerate(mt)
+		}
+		binary.LittleEndian.PutUint32(data[i:], mtTemper(mt[i/4%mtN]))
+	}
+	r := RecognizeMT19937(data)
+	if r == nil || param(r, "种子") != "20211019" {
+		t.Fatalf("MT19937 not recognized: %v", r)
+	}
+	fmt.Println(r)
+
+	// 不从第一个输出开始时只能恢复状态
+	r = RecognizeMT19937(data[4*100+1:])
+	if r == nil || r.Offset != 3 || param(r, "种子") != "" {
+		t.Fatalf("MT19937 with skipped outputs not recognized: %v", r)
+	}
+}
+
+func TestRecognizeGoMathRand(t *testing.T) {
+	data := make([]byte, 10000)
+	rand.New(rand.NewSource(12345)).Read(data)
+	r := RecognizeGoMathRand(data)
+	if r == nil || param(r, "输出方法") != "Read" || param(r, "种子") != "12345" {
+		t.Fatalf("math/rand Read not recognized: %v", r)
+	}
+	fmt.Println(r)
+
+	rnd := rand.New(rand.NewSource(99))
+	for i := 0; i < len(data); i += 4 {
+		binary.BigEndian.PutUint32(data[i:], rnd.Uint32())
+	}
+	r = RecognizeGoMathRand(data)
+	if r == nil || param(r, "输出方法") != "Uint32" || param(r, "种子") != "99" {
+		t.Fatalf("math/rand Uint32 not recognized: %v", r)
+	}
+
+	// 种子模 2^31-1 后使用，2^40 ≡ 2^9
+	rnd = rand.New(rand.NewSource(1 << 40))
+	for i := 0; i+8 <= len(data); i += 8 {
+		binary.LittleEndian.PutUint64(data[i:], rnd.Uint64())
+	}
+	r = RecognizeGoMathRand(data)
+	if r == nil || param(r, "输出方法") != "Uint64" || param(r, "种子") != "512" {
+		t.Fatalf("math/rand Uint64 not recognized: %v", r)
+	}
+}
+
+func TestRecognizeLFSR(t *testing.T) {
+	// x^31+x^3+1，s_n = s_{n-3} ⊕ s_{n-31}
+	n := 8 * 5000
+	s := make([]bool, n)
+	for i := 0; i < 31; i++ {
+		s[i] = i%3 == 0
+	}
+	for i := 31; i < n; i++ {
+		s[i] = s[i-3] != s[i-31]
+	}
+	data := make([]byte, n/8)
+	for i, b := range s {
+		if b {
+			data[i/8] |= 1 << uint(i%8)
+		}
+	}
+	r := RecognizeLFSR(data)
+	if r == nil || r.Layout != "比特流（字节内低位在前）" || param(r, "级数") != "31" || param(r, "连接多项式") != "x^31+x^3+1" ||
+		param(r, "初始状态") != "1001001001001001001001001001001" {
+		t.Fatalf("LFSR not recognized: %v", r)
+	}
+	fmt.Println(r)
+
+	res := Recognize(data)
+	if len(res) != 1 || res[0].Generator != "LFSR" {
+		t.Fatalf("Recognize = %v", res)
+	}
+}